@@ -0,0 +1,468 @@
+package flattenhtml
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The IDs of the accessibility rules returned by AccessibilityRules.
+const (
+	// RuleImageAlt reports the <img> elements with no alt attribute, and the image
+	// buttons with no text alternative.
+	RuleImageAlt = "image-alt"
+	// RuleInputLabel reports the form controls that have no label.
+	RuleInputLabel = "input-label"
+	// RuleLinkName reports the links that have no text or accessible name.
+	RuleLinkName = "link-name"
+	// RuleButtonName reports the buttons that have no text or accessible name.
+	RuleButtonName = "button-name"
+	// RuleHeadingOrder reports the headings that skip a level, e.g., an <h4> right
+	// after an <h2>.
+	RuleHeadingOrder = "heading-order"
+	// RuleHTMLLang reports the <html> element with no lang attribute.
+	RuleHTMLLang = "html-lang"
+	// RuleARIARole reports the role attributes with no valid WAI-ARIA role.
+	RuleARIARole = "aria-role"
+	// RuleARIAAttribute reports the unknown aria-* attributes and the aria-* attributes
+	// with an invalid value.
+	RuleARIAAttribute = "aria-attribute"
+	// RuleDuplicateIDARIA reports the aria-labelledby attributes that refer to an id
+	// used by more than one element.
+	RuleDuplicateIDARIA = "duplicate-id-aria"
+	// RuleColorOnly reports the inline text that is distinguished from the text around
+	// it only by the color of its inline style.
+	RuleColorOnly = "color-only"
+)
+
+// ariaRoles is the set of the non-abstract WAI-ARIA 1.2 roles.
+var ariaRoles = map[string]bool{
+	"alert": true, "alertdialog": true, "application": true, "article": true, "banner": true,
+	"blockquote": true, "button": true, "caption": true, "cell": true, "checkbox": true, "code": true,
+	"columnheader": true, "combobox": true, "complementary": true, "contentinfo": true,
+	"definition": true, "deletion": true, "dialog": true, "directory": true, "document": true,
+	"emphasis": true, "feed": true, "figure": true, "form": true, "generic": true, "grid": true,
+	"gridcell": true, "group": true, "heading": true, "img": true, "insertion": true, "link": true,
+	"list": true, "listbox": true, "listitem": true, "log": true, "main": true, "marquee": true,
+	"math": true, "menu": true, "menubar": true, "menuitem": true, "menuitemcheckbox": true,
+	"menuitemradio": true, "meter": true, "navigation": true, "none": true, "note": true,
+	"option": true, "paragraph": true, "presentation": true, "progressbar": true, "radio": true,
+	"radiogroup": true, "region": true, "row": true, "rowgroup": true, "rowheader": true,
+	"scrollbar": true, "search": true, "searchbox": true, "separator": true, "slider": true,
+	"spinbutton": true, "status": true, "strong": true, "subscript": true, "superscript": true,
+	"switch": true, "tab": true, "table": true, "tablist": true, "tabpanel": true, "term": true,
+	"textbox": true, "time": true, "timer": true, "toolbar": true, "tooltip": true, "tree": true,
+	"treegrid": true, "treeitem": true,
+}
+
+var (
+	ariaBoolean   = []string{"true", "false"}
+	ariaUndefined = []string{"true", "false", "undefined"}
+	ariaTristate  = []string{"true", "false", "mixed"}
+)
+
+// ariaAttributes is the set of the WAI-ARIA 1.2 attributes along with their allowed
+// values. A nil slice allows any value.
+var ariaAttributes = map[string][]string{
+	"aria-activedescendant": nil, "aria-atomic": ariaBoolean,
+	"aria-autocomplete": {"inline", "list", "both", "none"}, "aria-braillelabel": nil,
+	"aria-brailleroledescription": nil, "aria-busy": ariaBoolean, "aria-checked": ariaTristate,
+	"aria-colcount": nil, "aria-colindex": nil, "aria-colindextext": nil, "aria-colspan": nil,
+	"aria-controls": nil, "aria-current": {"page", "step", "location", "date", "time", "true", "false"},
+	"aria-describedby": nil, "aria-description": nil, "aria-details": nil, "aria-disabled": ariaBoolean,
+	"aria-dropeffect": nil, "aria-errormessage": nil, "aria-expanded": ariaUndefined, "aria-flowto": nil,
+	"aria-grabbed":  ariaUndefined,
+	"aria-haspopup": {"false", "true", "menu", "listbox", "tree", "grid", "dialog"},
+	"aria-hidden":   ariaUndefined, "aria-invalid": {"grammar", "false", "spelling", "true"},
+	"aria-keyshortcuts": nil, "aria-label": nil, "aria-labelledby": nil, "aria-level": nil,
+	"aria-live": {"assertive", "off", "polite"}, "aria-modal": ariaBoolean,
+	"aria-multiline": ariaBoolean, "aria-multiselectable": ariaBoolean,
+	"aria-orientation": {"horizontal", "undefined", "vertical"}, "aria-owns": nil,
+	"aria-placeholder": nil, "aria-posinset": nil, "aria-pressed": ariaTristate,
+	"aria-readonly": ariaBoolean, "aria-relevant": nil, "aria-required": ariaBoolean,
+	"aria-roledescription": nil, "aria-rowcount": nil, "aria-rowindex": nil, "aria-rowindextext": nil,
+	"aria-rowspan": nil, "aria-selected": ariaUndefined, "aria-setsize": nil,
+	"aria-sort": {"ascending", "descending", "none", "other"}, "aria-valuemax": nil,
+	"aria-valuemin": nil, "aria-valuenow": nil, "aria-valuetext": nil,
+}
+
+// colorCueProperties are the inline style properties that distinguish a text by
+// something other than its color.
+var colorCueProperties = []string{
+	"font-weight", "font-style", "text-decoration", "text-decoration-line", "border", "border-bottom",
+	"background", "background-color", "outline",
+}
+
+// AccessibilityRules returns the built-in accessibility rules, i.e., RuleImageAlt,
+// RuleInputLabel, RuleLinkName, RuleButtonName, RuleHeadingOrder, RuleHTMLLang,
+// RuleARIARole, RuleARIAAttribute, RuleDuplicateIDARIA and RuleColorOnly. The rules
+// are heuristics over the HTML, so they do not replace checking the rendered page.
+func AccessibilityRules() []LintRule {
+	return []LintRule{
+		{ID: RuleImageAlt, Check: checkImageAlt},
+		{ID: RuleInputLabel, Check: checkInputLabel},
+		{ID: RuleLinkName, Check: checkLinkName},
+		{ID: RuleButtonName, Check: checkButtonName},
+		{ID: RuleHeadingOrder, Check: checkHeadingOrder},
+		{ID: RuleHTMLLang, Check: checkHTMLLang},
+		{ID: RuleARIARole, Check: checkARIARole},
+		{ID: RuleARIAAttribute, Check: checkARIAAttribute},
+		{ID: RuleDuplicateIDARIA, Check: checkDuplicateIDARIA},
+		{ID: RuleColorOnly, Check: checkColorOnly},
+	}
+}
+
+// NewAccessibilityLinter creates a new Linter that checks the AccessibilityRules
+// along with the given extra rules.
+func NewAccessibilityLinter(rules ...LintRule) *Linter {
+	return NewLinter(append(AccessibilityRules(), rules...)...)
+}
+
+// checkImageAlt reports the images with no alt attribute and the image buttons with
+// no text alternative. An empty alt marks a decorative image and is allowed.
+func checkImageAlt(index *LintIndex, report LintReport) {
+	for node := range index.Tag("img").All() {
+		if _, ok := htmlAttribute(node.htmlNode, "alt"); ok || hasLabelAttributes(index, node.htmlNode) {
+			continue
+		}
+
+		if role := trimmedAttribute(node.htmlNode, "role"); role == "none" || role == "presentation" {
+			continue
+		}
+
+		report(node, "image has no alt attribute")
+	}
+
+	for node := range index.Tag("input").All() {
+		if inputType(node.htmlNode) != "image" {
+			continue
+		}
+
+		if trimmedAttribute(node.htmlNode, "alt") == "" && !hasLabelAttributes(index, node.htmlNode) {
+			report(node, "image button has no text alternative")
+		}
+	}
+}
+
+// checkInputLabel reports the form controls that are not labelled by a <label>, or
+// by the aria-label, aria-labelledby or title attributes.
+func checkInputLabel(index *LintIndex, report LintReport) {
+	labelled := make(map[string]bool)
+
+	for label := range index.Tag("label").All() {
+		if id := trimmedAttribute(label.htmlNode, "for"); id != "" {
+			labelled[id] = true
+		}
+	}
+
+	for _, tag := range []string{"input", "select", "textarea"} {
+		for node := range index.Tag(tag).All() {
+			switch inputType(node.htmlNode) {
+			case "hidden", "submit", "reset", "button", "image":
+				if node.htmlNode.DataAtom == atom.Input {
+					continue
+				}
+			}
+
+			if id, ok := htmlAttribute(node.htmlNode, "id"); ok && labelled[id] {
+				continue
+			}
+
+			if hasAncestor(node.htmlNode, atom.Label) || hasLabelAttributes(index, node.htmlNode) {
+				continue
+			}
+
+			report(node, fmt.Sprintf("%s has no label", tag))
+		}
+	}
+}
+
+// checkLinkName reports the links with no text or accessible name.
+func checkLinkName(index *LintIndex, report LintReport) {
+	for node := range index.Tag("a").All() {
+		if _, ok := htmlAttribute(node.htmlNode, "href"); !ok || ariaHidden(node.htmlNode) {
+			continue
+		}
+
+		if !hasTextName(node.htmlNode) && !hasLabelAttributes(index, node.htmlNode) {
+			report(node, "link has no text")
+		}
+	}
+}
+
+// checkButtonName reports the buttons with no text or accessible name.
+func checkButtonName(index *LintIndex, report LintReport) {
+	check := func(node *Node) {
+		if !ariaHidden(node.htmlNode) && !hasTextName(node.htmlNode) && !hasLabelAttributes(index, node.htmlNode) {
+			report(node, "button has no text")
+		}
+	}
+
+	for node := range index.Tag("button").All() {
+		check(node)
+	}
+
+	// The submit and reset buttons have a default label.
+	for node := range index.Tag("input").All() {
+		if inputType(node.htmlNode) == "button" && trimmedAttribute(node.htmlNode, "value") == "" {
+			check(node)
+		}
+	}
+
+	for node := range index.Attribute("role").All() {
+		if node.htmlNode.DataAtom != atom.Button && node.htmlNode.DataAtom != atom.Input &&
+			strings.EqualFold(trimmedAttribute(node.htmlNode, "role"), "button") {
+			check(node)
+		}
+	}
+}
+
+// checkHeadingOrder reports the headings whose level is more than one level deeper
+// than the previous heading.
+func checkHeadingOrder(index *LintIndex, report LintReport) {
+	previous := 0
+
+	for node := range index.Elements().All() {
+		level := headingLevel(node.htmlNode)
+		if level == 0 {
+			continue
+		}
+
+		if previous > 0 && level > previous+1 {
+			report(node, fmt.Sprintf("heading level skips from h%d to h%d", previous, level))
+		}
+
+		previous = level
+	}
+}
+
+// checkHTMLLang reports the <html> element with no lang attribute.
+func checkHTMLLang(index *LintIndex, report LintReport) {
+	for node := range index.Tag("html").All() {
+		if trimmedAttribute(node.htmlNode, "lang") == "" && trimmedAttribute(node.htmlNode, "xml:lang") == "" {
+			report(node, "html element has no lang attribute")
+		}
+	}
+}
+
+// checkARIARole reports the role attributes with no valid role. The role attribute
+// can list fallback roles, so it is valid if any of them is a valid role.
+func checkARIARole(index *LintIndex, report LintReport) {
+	for node := range index.Attribute("role").All() {
+		roles := strings.Fields(strings.ToLower(trimmedAttribute(node.htmlNode, "role")))
+		if len(roles) == 0 {
+			continue
+		}
+
+		valid := false
+
+		for _, role := range roles {
+			valid = valid || ariaRoles[role]
+		}
+
+		if !valid {
+			report(node, fmt.Sprintf("role %q is not a valid ARIA role", strings.Join(roles, " ")))
+		}
+	}
+}
+
+// checkARIAAttribute reports the unknown aria-* attributes and the values that are
+// not allowed for the attribute.
+func checkARIAAttribute(index *LintIndex, report LintReport) {
+	for key := range index.Attributes() {
+		if !strings.HasPrefix(key, "aria-") {
+			continue
+		}
+
+		allowed, known := ariaAttributes[key]
+
+		for node := range index.Attribute(key).All() {
+			if !known {
+				report(node, fmt.Sprintf("%s is not a valid ARIA attribute", key))
+
+				continue
+			}
+
+			value := strings.ToLower(trimmedAttribute(node.htmlNode, key))
+
+			if allowed != nil && value != "" && !slices.Contains(allowed, value) {
+				report(node, fmt.Sprintf("%q is not a valid value of %s", value, key))
+			}
+		}
+	}
+}
+
+// checkDuplicateIDARIA reports the aria-labelledby attributes that refer to an id
+// used by more than one element, since the label depends on which one is used.
+func checkDuplicateIDARIA(index *LintIndex, report LintReport) {
+	for node := range index.Attribute("aria-labelledby").All() {
+		for _, id := range strings.Fields(trimmedAttribute(node.htmlNode, "aria-labelledby")) {
+			if index.ID(id).Len() > 1 {
+				report(node, fmt.Sprintf("aria-labelledby refers to duplicate id %q", id))
+			}
+		}
+	}
+}
+
+// checkColorOnly reports the inline elements inside a text whose inline style only
+// changes their color, and the links inside a text whose underline is removed.
+func checkColorOnly(index *LintIndex, report LintReport) {
+	candidates := index.Attribute("style").FilterOr(WithTag("span"), WithTag("a"))
+
+	for node := range index.Tag("font").All() {
+		candidates.Add(node)
+	}
+
+	for node := range candidates.All() {
+		if !insideText(node.htmlNode) {
+			continue
+		}
+
+		declarations := parseStyleDeclarations(trimmedAttribute(node.htmlNode, "style"))
+		color, decoration := "", ""
+
+		for _, declaration := range declarations {
+			switch declaration.property {
+			case "color":
+				color = declaration.value
+			case "text-decoration", "text-decoration-line":
+				decoration = declaration.value
+			}
+		}
+
+		if node.htmlNode.DataAtom == atom.Font {
+			color = trimmedAttribute(node.htmlNode, "color")
+		}
+
+		if color == "" {
+			continue
+		}
+
+		if node.htmlNode.DataAtom == atom.A {
+			if strings.EqualFold(decoration, "none") {
+				report(node, "link is distinguished from the text only by color")
+			}
+
+			continue
+		}
+
+		cue := false
+
+		for _, declaration := range declarations {
+			cue = cue || (slices.Contains(colorCueProperties, declaration.property) &&
+				!strings.EqualFold(declaration.value, "none") && !strings.EqualFold(declaration.value, "normal"))
+		}
+
+		if !cue && !hasEmphasisAncestor(node.htmlNode) {
+			report(node, "text is distinguished only by color")
+		}
+	}
+}
+
+// hasLabelAttributes checks whether the node has a non-empty aria-label or title,
+// or an aria-labelledby that refers to an element with text.
+func hasLabelAttributes(index *LintIndex, node *html.Node) bool {
+	if trimmedAttribute(node, "aria-label") != "" || trimmedAttribute(node, "title") != "" {
+		return true
+	}
+
+	for _, id := range strings.Fields(trimmedAttribute(node, "aria-labelledby")) {
+		if label := index.ID(id).First(); label != nil && strings.TrimSpace(textContent(label.htmlNode)) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasTextName checks whether the node has a text, or an image with an alt text.
+func hasTextName(node *html.Node) bool {
+	found := false
+
+	walkTree(node.FirstChild, func(child *html.Node) {
+		switch {
+		case child.Type == html.TextNode:
+			found = found || strings.TrimSpace(child.Data) != ""
+		case child.DataAtom == atom.Img:
+			found = found || trimmedAttribute(child, "alt") != "" || trimmedAttribute(child, "aria-label") != ""
+		}
+	})
+
+	return found
+}
+
+// insideText checks whether the node has a sibling text, i.e., it is a part of a
+// running text rather than a standalone label.
+func insideText(node *html.Node) bool {
+	if node.Parent == nil {
+		return false
+	}
+
+	for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling != node && sibling.Type == html.TextNode && strings.TrimSpace(sibling.Data) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasEmphasisAncestor checks whether the node is inside an element that is rendered
+// with a non-color emphasis, e.g., <strong> or <u>.
+func hasEmphasisAncestor(node *html.Node) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		switch parent.DataAtom {
+		case atom.Strong, atom.B, atom.Em, atom.I, atom.U, atom.Mark:
+			return true
+		case atom.P, atom.Div, atom.Li, atom.Td, atom.Th, atom.Body:
+			return false
+		}
+	}
+
+	return false
+}
+
+// headingLevel returns the level of an <h1> to <h6> element, or zero.
+func headingLevel(node *html.Node) int {
+	switch node.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// inputType returns the lowercased type of an <input>, which is text by default.
+func inputType(node *html.Node) string {
+	if value := strings.ToLower(trimmedAttribute(node, "type")); value != "" {
+		return value
+	}
+
+	return "text"
+}
+
+// ariaHidden checks whether the node has aria-hidden="true".
+func ariaHidden(node *html.Node) bool {
+	return strings.EqualFold(trimmedAttribute(node, "aria-hidden"), "true")
+}
+
+// trimmedAttribute returns the value of the given attribute without the surrounding
+// whitespace, or an empty string.
+func trimmedAttribute(node *html.Node, key string) string {
+	value, _ := htmlAttribute(node, key)
+
+	return strings.TrimSpace(value)
+}
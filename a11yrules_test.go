@@ -0,0 +1,143 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessibilityRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		rule     string
+		html     string
+		expected []string
+	}{
+		{
+			name: "image alt",
+			rule: flattenhtml.RuleImageAlt,
+			html: `<img id="bad" src="a.png"><img alt="" src="b.png"><img alt="Logo" src="c.png">
+				<img role="presentation" src="d.png"><img aria-label="Chart" src="e.png">
+				<input id="bad-button" type="image" src="go.png"><input type="image" alt="Go" src="go.png">`,
+			expected: []string{"bad", "bad-button"},
+		},
+		{
+			name: "input label",
+			rule: flattenhtml.RuleInputLabel,
+			html: `<label for="name">Name</label><input id="name">
+				<label>Email <input type="email"></label>
+				<input id="bad" type="text"><input type="hidden"><input type="submit">
+				<input aria-label="Search"><select id="bad-select"></select><textarea title="Note"></textarea>`,
+			expected: []string{"bad", "bad-select"},
+		},
+		{
+			name: "link name",
+			rule: flattenhtml.RuleLinkName,
+			html: `<a id="bad" href="/"></a><a href="/">Home</a><a href="/"><img alt="Home"></a>
+				<a name="anchor"></a><a href="/" aria-hidden="true"></a>
+				<span id="label">Profile</span><a href="/me" aria-labelledby="label"></a>`,
+			expected: []string{"bad"},
+		},
+		{
+			name: "button name",
+			rule: flattenhtml.RuleButtonName,
+			html: `<button id="bad">  </button><button>Save</button><button aria-label="Close">x</button>
+				<input id="bad-input" type="button"><input type="button" value="Go"><input type="submit">
+				<div id="bad-div" role="button"></div><div role="button">Menu</div>`,
+			expected: []string{"bad", "bad-input", "bad-div"},
+		},
+		{
+			name:     "heading order",
+			rule:     flattenhtml.RuleHeadingOrder,
+			html:     `<h1>a</h1><h2>b</h2><h4 id="bad">c</h4><h2>d</h2><h3>e</h3><h1>f</h1><h3 id="bad-2">g</h3>`,
+			expected: []string{"bad", "bad-2"},
+		},
+		{
+			name: "aria role",
+			rule: flattenhtml.RuleARIARole,
+			html: `<div id="bad" role="buton"></div><div role="button"></div><div role="switch checkbox"></div>
+				<div role="Navigation"></div><div role=""></div>`,
+			expected: []string{"bad"},
+		},
+		{
+			name: "aria attribute",
+			rule: flattenhtml.RuleARIAAttribute,
+			html: `<div id="bad" aria-lable="x"></div><div id="bad-value" aria-hidden="yes"></div>
+				<div aria-hidden="true" aria-label="x" aria-checked="mixed"></div>`,
+			expected: []string{"bad", "bad-value"},
+		},
+		{
+			name: "duplicate id aria",
+			rule: flattenhtml.RuleDuplicateIDARIA,
+			html: `<span id="x">a</span><span id="x">b</span><span id="y">c</span>
+				<input id="bad" aria-labelledby="x y"><input aria-labelledby="y">`,
+			expected: []string{"bad"},
+		},
+		{
+			name: "color only",
+			rule: flattenhtml.RuleColorOnly,
+			html: `<p>Fields in <span id="bad" style="color: red">red</span> are required.</p>
+				<p>Fields in <span style="color: red; font-weight: bold">bold</span> are required.</p>
+				<p>Fields in <strong><span style="color: red">strong</span></strong> are required.</p>
+				<p><span style="color: red">standalone</span></p>
+				<p>Read <a id="bad-link" href="/" style="color: blue; text-decoration: none">more</a> here.</p>
+				<p>Read <a href="/" style="color: blue">more</a> here.</p>
+				<p>Old <font id="bad-font" color="red">markup</font> here.</p>`,
+			expected: []string{"bad", "bad-link", "bad-font"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<html lang="en"><body>` + tc.html))
+			require.NoError(t, err)
+
+			linter := flattenhtml.NewAccessibilityLinter()
+
+			_, err = nm.Parse(linter)
+			require.NoError(t, err)
+
+			ids := make([]string, 0)
+
+			for node := range linter.GetNodesByKey(tc.rule).All() {
+				ids = append(ids, node.Attributes()["id"])
+			}
+
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestAccessibilityRules_HTMLLang(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>no lang</p>`))
+	require.NoError(t, err)
+
+	extra := flattenhtml.LintRule{
+		ID: "no-p",
+		Check: func(index *flattenhtml.LintIndex, report flattenhtml.LintReport) {
+			for node := range index.Tag("p").All() {
+				report(node, "p is not allowed")
+			}
+		},
+	}
+
+	linter := flattenhtml.NewAccessibilityLinter(extra)
+
+	_, err = nm.Parse(linter)
+	require.NoError(t, err)
+
+	findings := linter.Findings()
+	require.Len(t, findings, 2)
+	require.Equal(t, flattenhtml.RuleHTMLLang, findings[0].Rule)
+	require.Equal(t, "html", findings[0].Node.TagName())
+	require.Equal(t, "no-p", findings[1].Rule)
+	require.Len(t, flattenhtml.AccessibilityRules(), 10)
+}
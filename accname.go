@@ -0,0 +1,320 @@
+package flattenhtml
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// nameFromContentRoles is the set of the roles whose accessible name is computed
+// from their content when no other source names them.
+var nameFromContentRoles = map[string]bool{
+	"button": true, "cell": true, "checkbox": true, "columnheader": true, "gridcell": true,
+	"heading": true, "link": true, "menuitem": true, "menuitemcheckbox": true, "menuitemradio": true,
+	"option": true, "radio": true, "row": true, "rowheader": true, "switch": true, "tab": true,
+	"tooltip": true, "treeitem": true,
+}
+
+// inlineElements is the set of the tags that are rendered inline by default, so
+// their names are joined to the text around them without a space.
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Bdi: true, atom.Bdo: true, atom.Cite: true,
+	atom.Code: true, atom.Data: true, atom.Dfn: true, atom.Em: true, atom.Font: true, atom.I: true,
+	atom.Kbd: true, atom.Label: true, atom.Mark: true, atom.Q: true, atom.S: true, atom.Samp: true,
+	atom.Small: true, atom.Span: true, atom.Strong: true, atom.Sub: true, atom.Sup: true,
+	atom.Time: true, atom.U: true, atom.Var: true,
+}
+
+// accname is the state of a single accessible name computation.
+type accname struct {
+	// visited is the set of the elements that are already used in the computation,
+	// so an element is not named twice and aria-labelledby loops end.
+	visited map[*html.Node]bool
+}
+
+// AccessibleName returns the accessible name of the element, following the
+// WAI-ARIA Accessible Name and Description Computation. The name comes from the
+// first of these sources that is not empty:
+//
+//   - The elements referred by aria-labelledby, joined by a space.
+//   - The aria-label attribute.
+//   - The native label of the element, i.e., the <label> elements of a form control,
+//     the alt of an image, the value of an input button, the <legend> of a
+//     fieldset, the <figcaption> of a figure or the <caption> of a table.
+//   - The text content, for the roles that are named from their content, e.g.,
+//     buttons, links and headings. The hidden descendants are skipped and the
+//     embedded controls contribute their value.
+//   - The title attribute, or the placeholder of an input.
+//
+// The whitespace of the name is collapsed and trimmed. The HTML has no style
+// sheets, so the elements are hidden only by the hidden and aria-hidden
+// attributes and by the display and visibility of their inline style.
+func (n *Node) AccessibleName() string {
+	if n.htmlNode.Type != html.ElementNode {
+		return ""
+	}
+
+	computation := &accname{visited: make(map[*html.Node]bool)}
+
+	return strings.TrimSpace(collapseSpaces(computation.name(n.htmlNode, false, false)))
+}
+
+// name computes the text alternative of the given node. referenced is true for the
+// elements referred by aria-labelledby, whose content names them regardless of
+// their role, and descendant is true for the nodes whose text is a part of the
+// name of an ancestor.
+func (a *accname) name(node *html.Node, referenced, descendant bool) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	if node.Type != html.ElementNode || a.visited[node] {
+		return ""
+	}
+
+	if descendant && accnameHidden(node) {
+		return ""
+	}
+
+	a.visited[node] = true
+	root := topAncestor(node)
+
+	if !referenced {
+		parts := make([]string, 0)
+
+		for _, id := range strings.Fields(trimmedAttribute(node, "aria-labelledby")) {
+			if target := elementByID(root, id); target != nil {
+				parts = append(parts, strings.TrimSpace(a.name(target, true, false)))
+			}
+		}
+
+		if name := strings.Join(parts, " "); strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	role := nodeRole(node)
+
+	// An embedded control inside the name of another element contributes its value.
+	if descendant {
+		if value, ok := a.controlValue(node, role); ok {
+			return value
+		}
+	}
+
+	if label := trimmedAttribute(node, "aria-label"); label != "" {
+		return label
+	}
+
+	if role != "none" && role != "presentation" {
+		if name := a.nativeName(node, root); strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	if referenced || descendant || nameFromContentRoles[role] {
+		if name := a.content(node); strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	if title := trimmedAttribute(node, "title"); title != "" {
+		return title
+	}
+
+	if node.DataAtom == atom.Input || node.DataAtom == atom.Textarea {
+		return trimmedAttribute(node, "placeholder")
+	}
+
+	return ""
+}
+
+// nativeName returns the name of the element from its HTML semantics.
+func (a *accname) nativeName(node *html.Node, root *html.Node) string {
+	switch node.DataAtom {
+	case atom.Input:
+		switch (&FormControl{node: NewNode(node)}).Type() {
+		case "hidden":
+			return ""
+		case "button":
+			return trimmedAttribute(node, "value")
+		case "submit", "reset":
+			if value, ok := htmlAttribute(node, "value"); ok {
+				return value
+			}
+
+			if inputType(node) == "reset" {
+				return "Reset"
+			}
+
+			return "Submit"
+		case "image":
+			if alt := trimmedAttribute(node, "alt"); alt != "" {
+				return alt
+			}
+
+			if value := trimmedAttribute(node, "value"); value != "" {
+				return value
+			}
+
+			return "Submit"
+		}
+
+		return a.labels(node, root)
+	case atom.Button, atom.Meter, atom.Output, atom.Progress, atom.Select, atom.Textarea:
+		return a.labels(node, root)
+	case atom.Img, atom.Area:
+		alt, _ := htmlAttribute(node, "alt")
+
+		return alt
+	case atom.Fieldset:
+		return a.childName(node, atom.Legend)
+	case atom.Figure:
+		return a.childName(node, atom.Figcaption)
+	case atom.Table:
+		return a.childName(node, atom.Caption)
+	}
+
+	return ""
+}
+
+// labels returns the names of the <label> elements of a form control in the tree
+// order, joined by a space. A label refers to the control by its for attribute,
+// or contains it if it has no for attribute.
+func (a *accname) labels(node *html.Node, root *html.Node) string {
+	id, _ := htmlAttribute(node, "id")
+	parts := make([]string, 0)
+
+	walkTree(root, func(label *html.Node) {
+		if label.Type != html.ElementNode || label.DataAtom != atom.Label {
+			return
+		}
+
+		target, ok := htmlAttribute(label, "for")
+
+		if (ok && id != "" && target == id && elementByID(root, id) == node) || (!ok && isAncestorOf(label, node)) {
+			parts = append(parts, strings.TrimSpace(a.name(label, true, false)))
+		}
+	})
+
+	return strings.Join(parts, " ")
+}
+
+// childName returns the name of the first child of the node with the given tag.
+func (a *accname) childName(node *html.Node, tag atom.Atom) string {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == tag {
+			return a.name(child, true, false)
+		}
+	}
+
+	return ""
+}
+
+// content returns the names of the children of the node joined together. The
+// names of the elements that are not inline are separated by a space.
+func (a *accname) content(node *html.Node) string {
+	builder := strings.Builder{}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		name := a.name(child, false, true)
+
+		if child.Type == html.ElementNode && !inlineElements[child.DataAtom] {
+			name = " " + name + " "
+		}
+
+		builder.WriteString(name)
+	}
+
+	return builder.String()
+}
+
+// controlValue returns the value of an embedded control, e.g., the text of a
+// textbox or the selected option of a combobox.
+func (a *accname) controlValue(node *html.Node, role string) (string, bool) {
+	switch role {
+	case "textbox", "searchbox":
+		if node.DataAtom == atom.Textarea {
+			return textContent(node), true
+		}
+
+		value, _ := htmlAttribute(node, "value")
+
+		return value, true
+	case "combobox", "listbox":
+		if node.DataAtom != atom.Select {
+			value, _ := htmlAttribute(node, "value")
+
+			return value, true
+		}
+
+		parts := make([]string, 0)
+
+		for _, option := range (&FormControl{node: NewNode(node)}).selectedOptions() {
+			parts = append(parts, strings.TrimSpace(a.name(option, true, false)))
+		}
+
+		return strings.Join(parts, " "), true
+	case "slider", "spinbutton":
+		if text := trimmedAttribute(node, "aria-valuetext"); text != "" {
+			return text, true
+		}
+
+		if now := trimmedAttribute(node, "aria-valuenow"); now != "" {
+			return now, true
+		}
+
+		return trimmedAttribute(node, "value"), true
+	}
+
+	return "", false
+}
+
+// accnameHidden checks whether the element is excluded from the accessible names,
+// e.g., by the hidden attribute or a display: none inline style.
+func accnameHidden(node *html.Node) bool {
+	switch node.DataAtom {
+	case atom.Script, atom.Style, atom.Template, atom.Noscript, atom.Head:
+		return true
+	}
+
+	if _, ok := htmlAttribute(node, "hidden"); ok || ariaHidden(node) {
+		return true
+	}
+
+	for _, declaration := range parseStyleDeclarations(trimmedAttribute(node, "style")) {
+		value := strings.ToLower(declaration.value)
+
+		if (declaration.property == "display" && value == "none") ||
+			(declaration.property == "visibility" && (value == "hidden" || value == "collapse")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// elementByID returns the first element in the tree with the given id, or nil.
+func elementByID(root *html.Node, id string) *html.Node {
+	for node := root; node != nil; node = nextInTreeOrder(node) {
+		if node.Type == html.ElementNode {
+			if value, ok := htmlAttribute(node, "id"); ok && value == id {
+				return node
+			}
+		}
+	}
+
+	return nil
+}
+
+// isAncestorOf checks whether the given ancestor is an ancestor of the node.
+func isAncestorOf(ancestor, node *html.Node) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_AccessibleName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "aria-labelledby chain",
+			html:     `<span id="a">Billing</span><span id="b" aria-label="street address">address</span><input id="target" aria-labelledby="a b" aria-label="Other">`,
+			expected: "Billing street address",
+		},
+		{
+			name:     "aria-labelledby to a hidden element",
+			html:     `<span id="a" hidden>Hidden label</span><button id="target" aria-labelledby="a">Text</button>`,
+			expected: "Hidden label",
+		},
+		{
+			name:     "aria-labelledby loop",
+			html:     `<div id="a" aria-labelledby="target">A</div><button id="target" aria-labelledby="a target">B</button>`,
+			expected: "A",
+		},
+		{
+			name:     "aria-label over the content",
+			html:     `<button id="target" aria-label="Close dialog">x</button>`,
+			expected: "Close dialog",
+		},
+		{
+			name:     "label for",
+			html:     `<label for="target">First <b>name</b></label><input id="target">`,
+			expected: "First name",
+		},
+		{
+			name:     "wrapping label skips the control",
+			html:     `<label>Email <input id="target" value="me@example.com"></label>`,
+			expected: "Email",
+		},
+		{
+			name:     "multiple labels",
+			html:     `<label for="target">Quantity</label><label>per item <input id="target" type="number"></label>`,
+			expected: "Quantity per item",
+		},
+		{
+			name:     "embedded control in the label",
+			html:     `<label for="target">Remind me <select><option>daily</option><option selected>weekly</option></select></label><input id="target" type="checkbox">`,
+			expected: "Remind me weekly",
+		},
+		{
+			name:     "image alt",
+			html:     `<img id="target" alt="Company logo" title="Logo">`,
+			expected: "Company logo",
+		},
+		{
+			name:     "link with image content",
+			html:     `<a id="target" href="/"><img alt="Home"> page</a>`,
+			expected: "Home page",
+		},
+		{
+			name:     "content skips the hidden descendants",
+			html:     `<button id="target">Save<span aria-hidden="true">*</span><span style="display: none">now</span><script>x()</script></button>`,
+			expected: "Save",
+		},
+		{
+			name:     "block elements are separated",
+			html:     `<a id="target" href="/"><div>Read</div><div>more</div></a>`,
+			expected: "Read more",
+		},
+		{
+			name:     "submit input",
+			html:     `<input id="target" type="submit">`,
+			expected: "Submit",
+		},
+		{
+			name:     "button input value",
+			html:     `<input id="target" type="button" value="Go">`,
+			expected: "Go",
+		},
+		{
+			name:     "fieldset legend",
+			html:     `<fieldset id="target"><legend>Shipping</legend><input></fieldset>`,
+			expected: "Shipping",
+		},
+		{
+			name:     "table caption",
+			html:     `<table id="target"><caption>Prices</caption><tr><td>1</td></tr></table>`,
+			expected: "Prices",
+		},
+		{
+			name:     "title fallback",
+			html:     `<div id="target" title="Tooltip">Not named from content</div>`,
+			expected: "Tooltip",
+		},
+		{
+			name:     "placeholder fallback",
+			html:     `<input id="target" placeholder="Search...">`,
+			expected: "Search...",
+		},
+		{
+			name:     "no name",
+			html:     `<div id="target">Text</div>`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.html))
+			require.NoError(t, err)
+
+			mc, err := nm.Parse(flattenhtml.NewKeyFlattener("ids", func(node *flattenhtml.Node) []string {
+				id, _ := node.Attribute("id")
+
+				return []string{id}
+			}))
+			require.NoError(t, err)
+
+			target := mc.First().SelectNodes("target").First()
+			require.NotNil(t, target)
+			require.Equal(t, tc.expected, target.AccessibleName())
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// AncestryFlattener is a Flattener that flattens the HTML tree by the depth of the
+// nodes, i.e., the key "0" gives the document node, "1" gives the <html> element,
+// and so on. It also records the pre-order and post-order number of each node, so
+// checking whether a node is an ancestor of another one is a comparison of their
+// numbers rather than a walk up the tree. It is meant to be combined with the other
+// flatteners, e.g., to select the links of a navigation bar:
+//
+//	links := tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, nav))
+//
+// The numbers are computed lazily on the first query after the nodes are flattened
+// or the structure of the HTML tree is changed using Node, e.g., by Node.MoveTo or
+// Node.Wrap, so they follow the moved nodes without registering them again. The
+// nodes that are added to the tree are numbered once they are registered using
+// MultiCursor.RegisterNewNode. The removed nodes are not a descendant of any node.
+type AncestryFlattener struct {
+	treeBinding
+
+	// mu guards the lazy numbering and the numbers, so the read methods can be called
+	// concurrently.
+	mu      sync.Mutex
+	entries map[*html.Node]*ancestryEntry
+	roots   []*html.Node
+	byDepth []*NodeIterator
+	// dirty is true if a node is flattened after the last numbering.
+	dirty bool
+	// version is the version of the HTML trees at the last numbering.
+	version uint64
+	// generation is the number of the numberings, used to find the nodes that are
+	// not reachable from the roots anymore.
+	generation int
+}
+
+// ancestryEntry is the recorded position of a flattened node.
+type ancestryEntry struct {
+	node       *Node
+	depth      int
+	pre        int
+	post       int
+	generation int
+	// removed is true if the node is in a detached tree, i.e., the root of its tree is
+	// neither the root of its Node nor a document node.
+	removed bool
+}
+
+var (
+	_ Flattener        = (*AncestryFlattener)(nil)
+	_ FlattenerFactory = (*AncestryFlattener)(nil)
+	_ KeyLister        = (*AncestryFlattener)(nil)
+)
+
+// NewAncestryFlattener creates a new AncestryFlattener.
+func NewAncestryFlattener() *AncestryFlattener {
+	return &AncestryFlattener{
+		entries: make(map[*html.Node]*ancestryEntry),
+		roots:   make([]*html.Node, 0),
+		byDepth: make([]*NodeIterator, 0),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It records all the nodes, including the document node.
+// This method does not return an error.
+func (a *AncestryFlattener) Flatten(node *html.Node) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.entries[node]; !ok {
+		a.entries[node] = &ancestryEntry{node: a.newNode(node)}
+	}
+
+	if node.Parent == nil {
+		a.addRoot(node)
+	}
+
+	a.dirty = true
+
+	return nil
+}
+
+// GetNodesByKey returns the nodes at the given depth, e.g., "2" for the children
+// of the <html> element, in the document order.
+func (a *AncestryFlattener) GetNodesByKey(key string) *NodeIterator {
+	depth, err := strconv.Atoi(key)
+	if err != nil {
+		return nil
+	}
+
+	return a.AtDepth(depth)
+}
+
+func (a *AncestryFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*AncestryFlattener)
+
+	return ok
+}
+
+// Len for AncestryFlattener gives you the number of distinct depths in the HTML tree.
+func (a *AncestryFlattener) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	counter := 0
+
+	for _, nodes := range a.byDepth {
+		if nodes != nil {
+			counter++
+		}
+	}
+
+	return counter
+}
+
+// Keys returns the depths of the AncestryFlattener in the ascending order.
+func (a *AncestryFlattener) Keys() iter.Seq[string] {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	keys := make([]string, 0, len(a.byDepth))
+
+	for depth, nodes := range a.byDepth {
+		if nodes != nil {
+			keys = append(keys, strconv.Itoa(depth))
+		}
+	}
+
+	return slices.Values(keys)
+}
+
+// NewFlattener returns a new and empty AncestryFlattener.
+func (a *AncestryFlattener) NewFlattener() Flattener {
+	return NewAncestryFlattener()
+}
+
+// AtDepth returns the nodes at the given depth in the document order. The document
+// node has a depth of zero. If there is no node at the given depth, it returns nil.
+func (a *AncestryFlattener) AtDepth(depth int) *NodeIterator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	if depth < 0 || depth >= len(a.byDepth) {
+		return nil
+	}
+
+	return a.byDepth[depth]
+}
+
+// Depth returns the depth of the given node. The second return value is false if
+// the node is not flattened by the AncestryFlattener.
+func (a *AncestryFlattener) Depth(node *Node) (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	return a.depth(node.htmlNode)
+}
+
+// IsAncestor checks whether the ancestor is a proper ancestor of the descendant, by
+// comparing their pre-order and post-order numbers. It returns false if any of the
+// nodes is not flattened by the AncestryFlattener, or the descendant is removed.
+func (a *AncestryFlattener) IsAncestor(ancestor, descendant *Node) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	return a.isAncestor(ancestor.htmlNode, descendant.htmlNode)
+}
+
+// Ancestors returns the flattened ancestors of the given node, from its parent up
+// to the document node.
+func (a *AncestryFlattener) Ancestors(node *Node) *NodeIterator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.number()
+
+	ancestors := NewNodeIterator()
+
+	for parent := node.htmlNode.Parent; parent != nil; parent = parent.Parent {
+		if entry, ok := a.entries[parent]; ok {
+			ancestors.Add(entry.node)
+		}
+	}
+
+	return ancestors
+}
+
+// isAncestor compares the numbers of the given nodes, and returns false if the
+// descendant is removed. The nodes must be numbered.
+func (a *AncestryFlattener) isAncestor(ancestor, descendant *html.Node) bool {
+	ancestorEntry, ok := a.entries[ancestor]
+	if !ok {
+		return false
+	}
+
+	descendantEntry, ok := a.entries[descendant]
+	if !ok || descendantEntry.removed {
+		return false
+	}
+
+	return ancestorEntry.pre < descendantEntry.pre && descendantEntry.post < ancestorEntry.post
+}
+
+// depth returns the recorded depth of the given node. The nodes must be numbered.
+func (a *AncestryFlattener) depth(node *html.Node) (int, bool) {
+	entry, ok := a.entries[node]
+	if !ok {
+		return 0, false
+	}
+
+	return entry.depth, true
+}
+
+// addRoot adds the given node to the roots of the numbering, if it is not added yet.
+func (a *AncestryFlattener) addRoot(node *html.Node) {
+	if !slices.Contains(a.roots, node) {
+		a.roots = append(a.roots, node)
+	}
+}
+
+// number numbers the flattened nodes if any node is flattened, or the structure of
+// the HTML tree is changed, after the last numbering. It must be called with mu held.
+// The numbers are replaced rather than changed in place, so the NodeIterator values
+// that are returned before are not changed.
+func (a *AncestryFlattener) number() {
+	version := a.treeVersion()
+	if !a.dirty && a.version == version {
+		return
+	}
+
+	a.version = version
+
+	a.generation++
+	a.byDepth = make([]*NodeIterator, 0, len(a.byDepth))
+
+	counter := 0
+
+	// The roots that are added to another tree later are numbered as a part of it.
+	a.roots = slices.DeleteFunc(a.roots, func(root *html.Node) bool {
+		return root.Parent != nil
+	})
+
+	for _, root := range a.roots {
+		counter = a.numberTree(root, root, 0, counter)
+	}
+
+	// The nodes that are not reachable from the roots are in a detached tree now.
+	for node, entry := range a.entries {
+		if entry.generation == a.generation {
+			continue
+		}
+
+		root := topAncestor(node)
+		a.addRoot(root)
+
+		counter = a.numberTree(root, root, 0, counter)
+	}
+
+	a.dirty = false
+}
+
+// numberTree numbers the node and its descendants in the depth-first order, starting
+// from the given counter, and returns the next counter. The root is the root of the
+// tree of the node.
+func (a *AncestryFlattener) numberTree(root, node *html.Node, depth, counter int) int {
+	entry, ok := a.entries[node]
+
+	if ok {
+		entry.depth, entry.pre, entry.generation = depth, counter, a.generation
+		entry.removed = root != entry.node.root && root.Type != html.DocumentNode
+
+		for len(a.byDepth) <= depth {
+			a.byDepth = append(a.byDepth, nil)
+		}
+
+		if a.byDepth[depth] == nil {
+			a.byDepth[depth] = NewNodeIterator()
+		}
+
+		a.byDepth[depth].Add(entry.node)
+	}
+
+	counter++
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		counter = a.numberTree(root, child, depth+1, counter)
+	}
+
+	if ok {
+		entry.post = counter
+	}
+
+	return counter + 1
+}
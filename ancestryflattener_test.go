@@ -0,0 +1,164 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAncestryFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<nav><a href="/">home</a><ul><li><a href="/docs">docs</a></li></ul></nav><main><a href="/x">x</a></main>`,
+	))
+	require.NoError(t, err)
+
+	tags := flattenhtml.NewTagFlattener()
+	ancestry := flattenhtml.NewAncestryFlattener()
+
+	mc, err := nm.Parse(tags, ancestry)
+	require.NoError(t, err)
+
+	tagCursor, err := mc.SelectCursor(&flattenhtml.TagFlattener{})
+	require.NoError(t, err)
+
+	nav := tagCursor.SelectNodes("nav").First()
+	main := tagCursor.SelectNodes("main").First()
+	body := tagCursor.SelectNodes("body").First()
+
+	links := tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, nav))
+	require.Equal(t, 2, links.Len())
+	require.Equal(t, 1, tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, main)).Len())
+
+	docs := links.Filter(flattenhtml.WithDepth(ancestry, 6)).First()
+	require.NotNil(t, docs)
+
+	href, _ := docs.Attribute("href")
+	require.Equal(t, "/docs", href)
+
+	depth, ok := ancestry.Depth(docs)
+	require.True(t, ok)
+	require.Equal(t, 6, depth)
+
+	require.True(t, ancestry.IsAncestor(nav, docs))
+	require.True(t, ancestry.IsAncestor(body, docs))
+	require.False(t, ancestry.IsAncestor(main, docs))
+	require.False(t, ancestry.IsAncestor(docs, docs))
+	require.False(t, ancestry.IsAncestor(docs, nav))
+
+	ancestors := ancestry.Ancestors(docs)
+	require.Equal(t, 6, ancestors.Len())
+	require.Equal(t, "li", ancestors.First().TagName())
+	require.Same(t, nav.HTMLNode(), ancestry.AtDepth(3).Filter(flattenhtml.WithDescendant(ancestry, docs)).First().HTMLNode())
+	require.Equal(t, 0, tagCursor.SelectNodes("main").Filter(flattenhtml.WithDescendant(ancestry, docs)).Len())
+
+	cursor, err := mc.SelectCursor(&flattenhtml.AncestryFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, 1, cursor.SelectNodes("0").Len())
+	require.Equal(t, "html", cursor.SelectNodes("1").First().TagName())
+	require.Equal(t, 2, cursor.SelectNodes("2").Len())
+	require.Equal(t, 0, cursor.SelectNodes("x").Len())
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7"}, slices.Collect(cursor.Keys()))
+	require.Equal(t, 8, ancestry.Len())
+
+	// Moved nodes are renumbered without registering them again.
+	require.NoError(t, docs.MoveTo(main, -1))
+	require.True(t, ancestry.IsAncestor(main, docs))
+	require.False(t, ancestry.IsAncestor(nav, docs))
+
+	depth, _ = ancestry.Depth(docs)
+	require.Equal(t, 4, depth)
+	require.Equal(t, 2, tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, main)).Len())
+
+	// Added nodes are numbered once they are registered.
+	section := main.AppendChild(flattenhtml.NodeTypeElement, "section", nil)
+	link := section.AppendChild(flattenhtml.NodeTypeElement, "a", nil)
+	require.NoError(t, mc.RegisterNewNode(section))
+	require.True(t, ancestry.IsAncestor(main, link))
+	require.True(t, ancestry.IsAncestor(section, link))
+	require.Equal(t, 3, tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, main)).Len())
+
+	// Removed nodes are not a descendant of any node.
+	require.NoError(t, section.Remove())
+	require.False(t, ancestry.IsAncestor(main, link))
+	require.False(t, ancestry.IsAncestor(body, link))
+	require.Equal(t, 2, tagCursor.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, main)).Len())
+	require.Equal(t, 0, ancestry.AtDepth(5).FilterAnd(flattenhtml.WithTag("a"), flattenhtml.WithAncestor(ancestry, main)).Len())
+
+	// Wrapping and unwrapping change the depth of the existing nodes without
+	// registering them again.
+	wrapper, err := nav.Wrap("div", nil)
+	require.NoError(t, err)
+
+	depth, _ = ancestry.Depth(nav)
+	require.Equal(t, 4, depth)
+	require.True(t, ancestry.IsAncestor(body, nav))
+	require.Equal(t, 1, ancestry.AtDepth(4).Filter(flattenhtml.WithTag("nav")).Len())
+
+	require.NoError(t, wrapper.Unwrap())
+
+	depth, _ = ancestry.Depth(nav)
+	require.Equal(t, 3, depth)
+	require.Equal(t, 3, tagCursor.SelectNodes("a").Filter(flattenhtml.WithDepth(ancestry, 4)).Len())
+
+	fresh, ok := ancestry.NewFlattener().(*flattenhtml.AncestryFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(ancestry))
+	require.False(t, fresh.IsMyType(tags))
+}
+
+func TestAncestryFlattener_ConcurrentNumbering(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<nav><ul><li><a>1</a></li></ul></nav>`))
+	require.NoError(t, err)
+
+	tags := flattenhtml.NewTagFlattener()
+	ancestry := flattenhtml.NewAncestryFlattener()
+
+	_, err = nm.Parse(tags, ancestry)
+	require.NoError(t, err)
+
+	nav := tags.GetNodesByKey("nav").First()
+	ul := tags.GetNodesByKey("ul").First()
+	link := tags.GetNodesByKey("a").First()
+
+	// The nodes are numbered again by the first of the concurrent queries.
+	_, err = link.Wrap("span", nil)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	results := make([]bool, 8)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			depth, ok := ancestry.Depth(link)
+			results[i] = ok && depth == 7 &&
+				ancestry.IsAncestor(nav, link) &&
+				ancestry.AtDepth(7).Len() == 1 &&
+				tags.GetNodesByKey("a").Filter(flattenhtml.WithAncestor(ancestry, ul)).Len() == 1
+		}()
+	}
+
+	wg.Wait()
+
+	for _, ok := range results {
+		require.True(t, ok)
+	}
+
+	// A removed node is not a descendant of any node, without walking up the tree.
+	require.NoError(t, ul.Remove())
+	require.False(t, ancestry.IsAncestor(ul, link))
+	require.Zero(t, tags.GetNodesByKey("a").Filter(flattenhtml.WithAncestor(ancestry, ul)).Len())
+	require.Zero(t, tags.GetNodesByKey("ul").Filter(flattenhtml.WithDescendant(ancestry, link)).Len())
+}
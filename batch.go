@@ -0,0 +1,312 @@
+package flattenhtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BatchHandler is called for every document of a Batch after it is parsed.
+// It receives the MultiCursor of the document, created by fresh flatteners.
+// Returning an error marks the document as failed without stopping the Batch.
+type BatchHandler func(mc *MultiCursor) error
+
+// BatchOption is a function that configures the Batch.
+type BatchOption func(batch *Batch)
+
+// BatchDocument is a single input of a Batch. Open is called by the worker that
+// processes the document, so the documents are opened lazily and at most as many
+// of them as the number of workers are open at the same time.
+type BatchDocument struct {
+	// Name identifies the document in the BatchErrors, e.g., its file path.
+	Name string
+	// Open returns the content of the document. The returned reader is closed
+	// after the document is parsed.
+	Open func() (io.ReadCloser, error)
+}
+
+// BatchError is the error of a single document of a Batch.
+type BatchError struct {
+	// Index is the position of the document in the source.
+	Index int
+	// Name is the name of the document.
+	Name string
+	// Err is the error returned while opening, parsing or handling the document.
+	Err error
+}
+
+// BatchStats is the aggregate stats of a Batch run.
+type BatchStats struct {
+	// Documents is the number of documents that were processed.
+	Documents int
+	// Succeeded is the number of documents that were handled without any error.
+	Succeeded int
+	// Failed is the number of documents that returned an error.
+	Failed int
+	// Skipped is the number of documents that were canceled while being processed,
+	// because the run was stopped. They are not counted as processed documents.
+	Skipped int
+	// Bytes is the total number of bytes read from the documents.
+	Bytes int64
+	// Duration is the wall-clock time of the run.
+	Duration time.Duration
+}
+
+// BatchResult is the outcome of Batch.Run.
+type BatchResult struct {
+	// Errors holds the errors of the failed documents, in the order of the source.
+	Errors []*BatchError
+	// Stats holds the aggregate stats of the run.
+	Stats BatchStats
+}
+
+// Batch parses many documents on a bounded pool of workers. Since flatteners are
+// stateful, each document is parsed by new flatteners that are created by the
+// given FlattenerFactory values, and then passed to the BatchHandler.
+type Batch struct {
+	handler     BatchHandler
+	factories   []FlattenerFactory
+	workers     int
+	nmOptions   []NodeManagerOption
+	stopOnError bool
+}
+
+// WithBatchWorkers sets the number of documents that are processed concurrently.
+// By default, it is the number of CPUs.
+func WithBatchWorkers(workers int) BatchOption {
+	return func(batch *Batch) {
+		batch.workers = max(workers, 1)
+	}
+}
+
+// WithBatchNodeManagerOptions sets the options of the NodeManager created for
+// each document, e.g., WithMaxNodes to limit the size of untrusted documents.
+func WithBatchNodeManagerOptions(opts ...NodeManagerOption) BatchOption {
+	return func(batch *Batch) {
+		batch.nmOptions = opts
+	}
+}
+
+// WithBatchStopOnError stops the Batch after the first failed document. The
+// documents that are already being processed are canceled and counted as skipped,
+// and Batch.Run returns the *BatchError of the failed document.
+func WithBatchStopOnError() BatchOption {
+	return func(batch *Batch) {
+		batch.stopOnError = true
+	}
+}
+
+// NewBatch creates a new Batch that handles each document using the given handler
+// after parsing it with new flatteners created by the given factories.
+func NewBatch(handler BatchHandler, factories []FlattenerFactory, opts ...BatchOption) *Batch {
+	batch := &Batch{
+		handler:   handler,
+		factories: factories,
+		workers:   runtime.NumCPU(),
+	}
+
+	for _, opt := range opts {
+		opt(batch)
+	}
+
+	return batch
+}
+
+// BatchFiles returns a source of documents that reads the given files.
+func BatchFiles(paths ...string) iter.Seq[BatchDocument] {
+	return func(yield func(BatchDocument) bool) {
+		for _, path := range paths {
+			document := BatchDocument{
+				Name: path,
+				Open: func() (io.ReadCloser, error) {
+					return os.Open(path)
+				},
+			}
+
+			if !yield(document) {
+				return
+			}
+		}
+	}
+}
+
+// BatchReaders returns a source of documents that reads the given readers. The
+// documents are named by their position, e.g., "#0". The readers are closed after
+// being parsed if they implement io.Closer.
+func BatchReaders(readers ...io.Reader) iter.Seq[BatchDocument] {
+	return func(yield func(BatchDocument) bool) {
+		for index, reader := range readers {
+			document := BatchDocument{
+				Name: fmt.Sprintf("#%d", index),
+				Open: func() (io.ReadCloser, error) {
+					if closer, ok := reader.(io.ReadCloser); ok {
+						return closer, nil
+					}
+
+					return io.NopCloser(reader), nil
+				},
+			}
+
+			if !yield(document) {
+				return
+			}
+		}
+	}
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("document %s: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error of the document.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Run processes all the documents of the given source and returns the errors of
+// the failed documents along with the aggregate stats. The error of the document
+// itself never stops the run, unless WithBatchStopOnError is used. If there is no
+// factory, ErrNoFlattener is returned. If the run is stopped, because the context
+// is done or a document failed with WithBatchStopOnError, the remaining documents
+// are skipped and the error that stopped the run is returned along with the result
+// of the processed documents. The documents that are canceled while being processed
+// are not reported in BatchResult.Errors.
+func (b *Batch) Run(parent context.Context, source iter.Seq[BatchDocument]) (*BatchResult, error) {
+	if len(b.factories) == 0 {
+		return nil, ErrNoFlattener
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	type batchJob struct {
+		index    int
+		document BatchDocument
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		start  = time.Now()
+		jobs   = make(chan batchJob)
+		result = &BatchResult{Errors: make([]*BatchError, 0)}
+	)
+
+	for range b.workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				read, err := b.process(ctx, job.document)
+
+				mu.Lock()
+
+				result.Stats.Bytes += read
+
+				switch {
+				case err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()):
+					result.Stats.Skipped++
+				case err != nil:
+					batchErr := &BatchError{Index: job.index, Name: job.document.Name, Err: err}
+
+					result.Stats.Documents++
+					result.Stats.Failed++
+					result.Errors = append(result.Errors, batchErr)
+
+					if b.stopOnError {
+						cancel(batchErr)
+					}
+				default:
+					result.Stats.Documents++
+					result.Stats.Succeeded++
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+	index := 0
+
+	for document := range source {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case jobs <- batchJob{index: index, document: document}:
+			index++
+		case <-ctx.Done():
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	slices.SortFunc(result.Errors, func(a, b *BatchError) int {
+		return a.Index - b.Index
+	})
+
+	result.Stats.Duration = time.Since(start)
+
+	if ctx.Err() != nil {
+		return result, context.Cause(ctx)
+	}
+
+	return result, nil
+}
+
+// process parses a single document with new flatteners and calls the handler.
+// It returns the number of bytes read from the document along with the error.
+func (b *Batch) process(ctx context.Context, document BatchDocument) (int64, error) {
+	reader, err := document.Open()
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	counter := &countingReader{reader: reader}
+
+	nm, err := NewNodeManagerFromReader(counter, b.nmOptions...)
+	if err != nil {
+		return counter.read, err
+	}
+
+	flatteners := make([]Flattener, 0, len(b.factories))
+
+	for _, factory := range b.factories {
+		flatteners = append(flatteners, factory.NewFlattener())
+	}
+
+	mc, err := nm.ParseContext(ctx, flatteners...)
+	if err != nil {
+		return counter.read, err
+	}
+
+	return counter.read, b.handler(mc)
+}
+
+// countingReader counts the number of bytes read from the underlying reader.
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+// Read implements the io.Reader interface.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,178 @@
+package flattenhtml_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch_Run(t *testing.T) {
+	t.Parallel()
+
+	var paragraphs atomic.Int64
+
+	handler := func(mc *flattenhtml.MultiCursor) error {
+		cursor, err := mc.SelectCursor(&flattenhtml.TagFlattener{})
+		if err != nil {
+			return err
+		}
+
+		nodes := cursor.SelectNodes("p")
+		if nodes.Len() == 0 {
+			return errSample
+		}
+
+		paragraphs.Add(int64(nodes.Len()))
+
+		return nil
+	}
+
+	batch := flattenhtml.NewBatch(
+		handler,
+		[]flattenhtml.FlattenerFactory{flattenhtml.NewTagFlattener()},
+		flattenhtml.WithBatchWorkers(2),
+	)
+
+	result, err := batch.Run(context.Background(), flattenhtml.BatchReaders(
+		strings.NewReader(`<p>a</p><p>b</p>`),
+		strings.NewReader(`<div>no paragraph</div>`),
+		strings.NewReader(`<p>c</p>`),
+	))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(3), paragraphs.Load())
+	require.Equal(t, 3, result.Stats.Documents)
+	require.Equal(t, 2, result.Stats.Succeeded)
+	require.Equal(t, 1, result.Stats.Failed)
+	require.Equal(t, int64(47), result.Stats.Bytes)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, 1, result.Errors[0].Index)
+	require.Equal(t, "#1", result.Errors[0].Name)
+	require.ErrorIs(t, result.Errors[0], errSample)
+}
+
+func TestBatch_Run_Files(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	paths := make([]string, 0)
+
+	for _, name := range []string{"a.html", "b.html"} {
+		path := filepath.Join(dir, name)
+
+		require.NoError(t, os.WriteFile(path, []byte(`<p>text</p>`), 0o600))
+
+		paths = append(paths, path)
+	}
+
+	paths = append(paths, filepath.Join(dir, "missing.html"))
+
+	batch := flattenhtml.NewBatch(
+		func(_ *flattenhtml.MultiCursor) error { return nil },
+		[]flattenhtml.FlattenerFactory{flattenhtml.NewTagFlattener()},
+		flattenhtml.WithBatchNodeManagerOptions(flattenhtml.WithMaxNodes(100)),
+	)
+
+	result, err := batch.Run(context.Background(), flattenhtml.BatchFiles(paths...))
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Stats.Succeeded)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, paths[2], result.Errors[0].Name)
+	require.ErrorIs(t, result.Errors[0], os.ErrNotExist)
+}
+
+func TestBatch_Run_Errors(t *testing.T) {
+	t.Parallel()
+
+	handler := func(_ *flattenhtml.MultiCursor) error { return errSample }
+
+	t.Run("no factory", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := flattenhtml.NewBatch(handler, nil).Run(context.Background(), flattenhtml.BatchReaders())
+		require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+		require.Nil(t, result)
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		t.Parallel()
+
+		batch := flattenhtml.NewBatch(
+			handler,
+			[]flattenhtml.FlattenerFactory{flattenhtml.NewTagFlattener()},
+			flattenhtml.WithBatchWorkers(1),
+			flattenhtml.WithBatchStopOnError(),
+		)
+
+		readers := make([]io.Reader, 0)
+
+		for range 5 {
+			readers = append(readers, strings.NewReader(`<p>a</p>`))
+		}
+
+		result, err := batch.Run(context.Background(), flattenhtml.BatchReaders(readers...))
+		require.ErrorIs(t, err, errSample)
+
+		var batchErr *flattenhtml.BatchError
+
+		require.ErrorAs(t, err, &batchErr)
+		require.Equal(t, 0, batchErr.Index)
+		require.Equal(t, result.Stats.Documents, result.Stats.Failed)
+		require.Less(t, result.Stats.Documents, 5)
+		require.Len(t, result.Errors, result.Stats.Failed)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		batch := flattenhtml.NewBatch(handler, []flattenhtml.FlattenerFactory{flattenhtml.NewTagFlattener()})
+
+		result, err := batch.Run(ctx, flattenhtml.BatchReaders(strings.NewReader(`<p>a</p>`)))
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Equal(t, 0, result.Stats.Documents)
+	})
+
+	t.Run("canceled while processing", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		batch := flattenhtml.NewBatch(handler, []flattenhtml.FlattenerFactory{flattenhtml.NewTagFlattener()})
+
+		result, err := batch.Run(ctx, flattenhtml.BatchReaders(&cancelingReader{
+			reader: strings.NewReader(`<p>a</p>`),
+			cancel: cancel,
+		}))
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, result.Errors)
+		require.Equal(t, 0, result.Stats.Documents)
+		require.Equal(t, 1, result.Stats.Skipped)
+	})
+}
+
+// cancelingReader cancels the context of the Batch once the document is read.
+type cancelingReader struct {
+	reader io.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		c.cancel()
+	}
+
+	return n, err
+}
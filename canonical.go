@@ -0,0 +1,308 @@
+package flattenhtml
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// CanonicalOption is a function that configures Canonicalize and Equal.
+// By default, all the normalizations are applied and the options can be used
+// to keep some differences significant.
+type CanonicalOption func(options *canonicalOptions)
+
+// canonicalOptions holds the configurations of Canonicalize and Equal.
+type canonicalOptions struct {
+	keepWhitespace      bool
+	keepAttributeOrder  bool
+	keepImpliedElements bool
+}
+
+// booleanAttributes are the attributes whose presence is their value. Their value
+// is normalized to an empty string.
+var booleanAttributes = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true,
+	"checked": true, "controls": true, "default": true, "defer": true,
+	"disabled": true, "formnovalidate": true, "hidden": true, "inert": true,
+	"ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true,
+	"playsinline": true, "readonly": true, "required": true, "reversed": true,
+	"selected": true,
+}
+
+// impliedElements are the elements whose tags are optional in HTML and are
+// inserted by the parser when they are missing.
+var impliedElements = map[string]bool{
+	"html": true, "head": true, "body": true, "tbody": true,
+}
+
+// whitespaceSensitiveTags are the elements whose whitespace is never normalized.
+var whitespaceSensitiveTags = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true, "listing": true,
+	"plaintext": true, "xmp": true,
+}
+
+// WithCanonicalKeepWhitespace keeps the whitespace of the text nodes as it is.
+func WithCanonicalKeepWhitespace() CanonicalOption {
+	return func(options *canonicalOptions) {
+		options.keepWhitespace = true
+	}
+}
+
+// WithCanonicalKeepAttributeOrder keeps the order of the attributes significant.
+func WithCanonicalKeepAttributeOrder() CanonicalOption {
+	return func(options *canonicalOptions) {
+		options.keepAttributeOrder = true
+	}
+}
+
+// WithCanonicalKeepImpliedElements keeps the optional implied elements (html,
+// head, body and tbody) as they are.
+func WithCanonicalKeepImpliedElements() CanonicalOption {
+	return func(options *canonicalOptions) {
+		options.keepImpliedElements = true
+	}
+}
+
+// Canonicalize normalizes the HTML tree of the given NodeManager in place, so two
+// documents that mean the same thing render to the same output. It sorts the
+// attributes by their key, normalizes the value of boolean attributes, collapses
+// the insignificant whitespace and wraps the table rows in an implied <tbody>.
+// Entities are always normalized, since the parser decodes them and Render
+// encodes them in a single way.
+// The NodeIterators of the document are not updated, so the document should be
+// parsed again if nodes are removed by the normalization.
+func Canonicalize(nm *NodeManager, opts ...CanonicalOption) {
+	options := newCanonicalOptions(opts)
+
+	canonicalizeNode(nm.root, options, false)
+}
+
+// Equal checks whether two nodes and their descendants are structurally equal,
+// regardless of their formatting. The same normalizations of Canonicalize are
+// applied during the comparison without changing any of the trees. Additionally,
+// the implied elements without attributes are ignored, so a fragment and its
+// parsed document are equal.
+func Equal(a, b *Node, opts ...CanonicalOption) bool {
+	options := newCanonicalOptions(opts)
+
+	return equalNodes(a.htmlNode, b.htmlNode, options, false)
+}
+
+// newCanonicalOptions applies the given options on the default configurations.
+func newCanonicalOptions(opts []CanonicalOption) canonicalOptions {
+	options := canonicalOptions{}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// canonicalizeNode normalizes the node and its descendants in place.
+func canonicalizeNode(node *html.Node, options canonicalOptions, preserveSpace bool) {
+	if node.Type == html.ElementNode {
+		node.Attr = canonicalAttributes(node.Attr, options)
+		preserveSpace = preserveSpace || whitespaceSensitiveTags[node.Data]
+
+		if node.Data == "table" && !options.keepImpliedElements {
+			wrapTableRows(node)
+		}
+	}
+
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if child.Type == html.TextNode && !preserveSpace && !options.keepWhitespace {
+			if text, keep := canonicalText(child); keep {
+				child.Data = text
+			} else {
+				node.RemoveChild(child)
+			}
+		} else {
+			canonicalizeNode(child, options, preserveSpace)
+		}
+
+		child = next
+	}
+}
+
+// equalNodes compares two nodes after applying the normalizations.
+func equalNodes(a, b *html.Node, options canonicalOptions, preserveSpace bool) bool {
+	if a.Type != b.Type {
+		return false
+	}
+
+	switch a.Type {
+	case html.TextNode:
+		if preserveSpace || options.keepWhitespace {
+			return a.Data == b.Data
+		}
+
+		aText, _ := canonicalText(a)
+		bText, _ := canonicalText(b)
+
+		return aText == bText
+	case html.CommentNode, html.DoctypeNode:
+		return a.Data == b.Data
+	case html.ElementNode:
+		if a.Data != b.Data || a.Namespace != b.Namespace {
+			return false
+		}
+
+		if !slices.Equal(canonicalAttributes(a.Attr, options), canonicalAttributes(b.Attr, options)) {
+			return false
+		}
+
+		preserveSpace = preserveSpace || whitespaceSensitiveTags[a.Data]
+	}
+
+	aChildren := canonicalChildren(a, options, preserveSpace)
+	bChildren := canonicalChildren(b, options, preserveSpace)
+
+	if len(aChildren) != len(bChildren) {
+		return false
+	}
+
+	for i := range aChildren {
+		if !equalNodes(aChildren[i], bChildren[i], options, preserveSpace) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// canonicalChildren returns the children of the node that take part in Equal.
+// Insignificant whitespace is dropped and implied elements are replaced by their children.
+func canonicalChildren(node *html.Node, options canonicalOptions, preserveSpace bool) []*html.Node {
+	children := make([]*html.Node, 0)
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode && !preserveSpace && !options.keepWhitespace {
+			if _, keep := canonicalText(child); !keep {
+				continue
+			}
+		}
+
+		if !options.keepImpliedElements && child.Type == html.ElementNode &&
+			impliedElements[child.Data] && len(child.Attr) == 0 {
+			children = append(children, canonicalChildren(child, options, preserveSpace)...)
+
+			continue
+		}
+
+		children = append(children, child)
+	}
+
+	return children
+}
+
+// canonicalAttributes returns a copy of the attributes with normalized boolean
+// values, sorted by their key unless the attribute order is kept.
+func canonicalAttributes(attrs []html.Attribute, options canonicalOptions) []html.Attribute {
+	normalized := make([]html.Attribute, len(attrs))
+
+	for i, attr := range attrs {
+		if attr.Namespace == "" && booleanAttributes[attr.Key] {
+			attr.Val = ""
+		}
+
+		normalized[i] = attr
+	}
+
+	if !options.keepAttributeOrder {
+		slices.SortStableFunc(normalized, func(a, b html.Attribute) int {
+			if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+				return c
+			}
+
+			return strings.Compare(a.Key, b.Key)
+		})
+	}
+
+	return normalized
+}
+
+// canonicalText returns the text of a text node with collapsed whitespace. The
+// second return value is false if the node is insignificant whitespace, i.e., a
+// whitespace-only text at the start or the end of a block or next to a block element.
+func canonicalText(node *html.Node) (string, bool) {
+	text := collapseSpaces(node.Data)
+
+	if strings.TrimSpace(text) != "" {
+		if atBlockBoundary(node, true) {
+			text = strings.TrimLeft(text, " ")
+		}
+
+		if atBlockBoundary(node, false) {
+			text = strings.TrimRight(text, " ")
+		}
+
+		return text, true
+	}
+
+	if atBlockBoundary(node, true) || atBlockBoundary(node, false) {
+		return "", false
+	}
+
+	return text, true
+}
+
+// atBlockBoundary checks whether the given node starts, if previous is true, or ends
+// an inline formatting context. It is the case if the sibling on that side is a block
+// element, or if there is no sibling and the parent is not an inline element, or is
+// an inline element at the same boundary itself.
+func atBlockBoundary(node *html.Node, previous bool) bool {
+	for {
+		sibling := node.NextSibling
+		if previous {
+			sibling = node.PrevSibling
+		}
+
+		if sibling != nil {
+			return isBlockElement(sibling)
+		}
+
+		node = node.Parent
+		if node == nil || node.Type != html.ElementNode || isBlockElement(node) {
+			return true
+		}
+	}
+}
+
+// isBlockElement checks whether the given node is an element that breaks the inline
+// content around it.
+func isBlockElement(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+
+	return textParagraphTags[node.Data] || textBlockTags[node.Data] || markdownSkippedTags[node.Data]
+}
+
+// wrapTableRows moves the <tr> children of a table into an implied <tbody>.
+func wrapTableRows(table *html.Node) {
+	var tbody *html.Node
+
+	for child := table.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if child.Type == html.ElementNode && child.Data == "tr" {
+			if tbody == nil {
+				tbody = &html.Node{Type: html.ElementNode, Data: "tbody", DataAtom: atom.Tbody}
+				table.InsertBefore(tbody, child)
+			}
+
+			table.RemoveChild(child)
+			tbody.AppendChild(child)
+		} else if child.Type == html.ElementNode {
+			tbody = nil
+		}
+
+		child = next
+	}
+}
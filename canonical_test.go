@@ -0,0 +1,129 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		options  []flattenhtml.CanonicalOption
+		expected bool
+	}{
+		{
+			name:     "attribute order",
+			a:        `<p class="a" id="b">x</p>`,
+			b:        `<p id="b" class="a">x</p>`,
+			expected: true,
+		},
+		{
+			name:     "kept attribute order",
+			a:        `<p class="a" id="b">x</p>`,
+			b:        `<p id="b" class="a">x</p>`,
+			options:  []flattenhtml.CanonicalOption{flattenhtml.WithCanonicalKeepAttributeOrder()},
+			expected: false,
+		},
+		{
+			name:     "whitespace and entities",
+			a:        "<div>\n  <p>a &amp;   b</p>\n</div>",
+			b:        `<div><p>a &#38; b</p></div>`,
+			expected: true,
+		},
+		{
+			name:     "kept whitespace",
+			a:        "<div>\n  <p>a</p>\n</div>",
+			b:        `<div><p>a</p></div>`,
+			options:  []flattenhtml.CanonicalOption{flattenhtml.WithCanonicalKeepWhitespace()},
+			expected: false,
+		},
+		{
+			name:     "preformatted whitespace",
+			a:        "<pre>a  b</pre>",
+			b:        "<pre>a b</pre>",
+			expected: false,
+		},
+		{
+			name:     "boolean attributes",
+			a:        `<input disabled="disabled" required>`,
+			b:        `<input required="" disabled>`,
+			expected: true,
+		},
+		{
+			name:     "whitespace inside an inline element",
+			a:        `<p>x<b> y</b></p>`,
+			b:        `<p>x<b>y</b></p>`,
+			expected: false,
+		},
+		{
+			name:     "whitespace at the start of a block inside an inline element",
+			a:        `<p><b> y </b></p>`,
+			b:        `<p><b>y</b></p>`,
+			expected: true,
+		},
+		{
+			name:     "different text",
+			a:        `<p>a</p>`,
+			b:        `<p>b</p>`,
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.a))
+			require.NoError(t, err)
+
+			b, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.b))
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expected, flattenhtml.Equal(a.Root(), b.Root(), tc.options...))
+		})
+	}
+}
+
+func TestEqual_ImpliedElements(t *testing.T) {
+	t.Parallel()
+
+	fragment := flattenhtml.NewNode(&html.Node{Type: html.ElementNode, Data: "p"})
+	fragment.AppendChild(flattenhtml.NodeTypeText, "text", nil)
+
+	document, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>text</p>`))
+	require.NoError(t, err)
+
+	root := flattenhtml.NewNode(&html.Node{Type: html.DocumentNode})
+	root.HTMLNode().AppendChild(fragment.HTMLNode())
+
+	require.True(t, flattenhtml.Equal(root, document.Root()))
+	require.False(t, flattenhtml.Equal(root, document.Root(), flattenhtml.WithCanonicalKeepImpliedElements()))
+}
+
+func TestCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		"<div   id=\"x\" class=\"y\">\n  <input disabled=\"disabled\">  <b>a</b> <i>b</i>\n<pre> keep  me </pre></div>",
+	))
+	require.NoError(t, err)
+
+	flattenhtml.Canonicalize(nm)
+
+	rendered := bytes.Buffer{}
+
+	require.NoError(t, nm.Render(&rendered))
+	require.Equal(t,
+		`<html><head></head><body><div class="y" id="x"><input disabled=""/> <b>a</b> <i>b</i><pre> keep  me </pre></div></body></html>`,
+		rendered.String(),
+	)
+}
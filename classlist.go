@@ -0,0 +1,88 @@
+package flattenhtml
+
+import (
+	"slices"
+	"strings"
+)
+
+// ClassList gives access to the space-separated classes of the class attribute
+// of a Node. All changes are written back using Node.SetAttribute, so the
+// *html.Node and the attributes of the Node stay in sync.
+type ClassList struct {
+	node *Node
+}
+
+// ClassList returns the ClassList of the Node.
+func (n *Node) ClassList() *ClassList {
+	return &ClassList{node: n}
+}
+
+// Values returns the classes of the Node in their order, without duplicates.
+func (c *ClassList) Values() []string {
+	class, _ := c.node.Attribute("class")
+	values := make([]string, 0)
+
+	for _, name := range strings.Fields(class) {
+		if !slices.Contains(values, name) {
+			values = append(values, name)
+		}
+	}
+
+	return values
+}
+
+// Len returns the number of unique classes of the Node.
+func (c *ClassList) Len() int {
+	return len(c.Values())
+}
+
+// Contains checks whether the Node has the given class.
+func (c *ClassList) Contains(name string) bool {
+	return slices.Contains(c.Values(), name)
+}
+
+// Add adds the given classes to the Node. Classes that already exist are ignored.
+func (c *ClassList) Add(names ...string) {
+	values := c.Values()
+
+	for _, name := range names {
+		if name != "" && !slices.Contains(values, name) {
+			values = append(values, name)
+		}
+	}
+
+	c.write(values)
+}
+
+// Remove removes the given classes from the Node. Classes that do not exist are ignored.
+// The class attribute is kept even if it has no class left.
+func (c *ClassList) Remove(names ...string) {
+	if _, ok := c.node.Attribute("class"); !ok {
+		return
+	}
+
+	values := slices.DeleteFunc(c.Values(), func(value string) bool {
+		return slices.Contains(names, value)
+	})
+
+	c.write(values)
+}
+
+// Toggle removes the given class if it exists, and adds it otherwise.
+// It returns true if the class exists after the call.
+func (c *ClassList) Toggle(name string) bool {
+	if c.Contains(name) {
+		c.Remove(name)
+
+		return false
+	}
+
+	c.Add(name)
+
+	return true
+}
+
+// write writes the given classes back to the class attribute of the Node.
+func (c *ClassList) write(values []string) {
+	c.node.SetAttribute("class", strings.Join(values, " "))
+}
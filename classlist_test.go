@@ -0,0 +1,46 @@
+package flattenhtml_test
+
+import (
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestClassList(t *testing.T) {
+	t.Parallel()
+
+	htmlNode := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{{Key: "class", Val: "  a b  a "}},
+	}
+
+	node := flattenhtml.NewNode(htmlNode)
+	classes := node.ClassList()
+
+	require.Equal(t, []string{"a", "b"}, classes.Values())
+	require.Equal(t, 2, classes.Len())
+	require.True(t, classes.Contains("a"))
+	require.False(t, classes.Contains("c"))
+
+	classes.Add("c", "a", "")
+	require.Equal(t, "a b c", htmlNode.Attr[0].Val)
+
+	classes.Remove("a", "missing")
+
+	val, _ := node.Attribute("class")
+	require.Equal(t, "b c", val)
+	require.Equal(t, "b c", htmlNode.Attr[0].Val)
+
+	require.False(t, classes.Toggle("b"))
+	require.True(t, classes.Toggle("d"))
+	require.Equal(t, []string{"c", "d"}, classes.Values())
+
+	empty := flattenhtml.NewNode(&html.Node{Type: html.ElementNode, Data: "p"})
+	empty.ClassList().Remove("a")
+
+	_, ok := empty.Attribute("class")
+	require.False(t, ok)
+}
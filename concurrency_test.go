@@ -0,0 +1,193 @@
+package flattenhtml_test
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestMultiCursor_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<div><p class="a">1</p><p class="b">2</p><p class="a">3</p></div>`,
+	))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	counts := make([]int, 10)
+	errs := make([]error, 20)
+
+	for i := range 10 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = mc.View(func() error {
+				for range mc.First().SelectNodes("p").Filter(flattenhtml.WithAttributeValueAs("class", "a")).All() {
+					counts[i]++
+				}
+
+				return nil
+			})
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			errs[10+i] = mc.Update(func() error {
+				node := mc.First().SelectNodes("div").First()
+				node.SetAttribute("data-seen", "true")
+
+				return mc.RegisterNewNode(node.AppendChild(flattenhtml.NodeTypeElement, "span", nil))
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range 10 {
+		require.NoError(t, errs[i])
+		require.NoError(t, errs[10+i])
+		require.Equal(t, 2, counts[i])
+	}
+
+	require.Equal(t, 10, mc.First().SelectNodes("span").Len())
+
+	mc.Freeze()
+	require.True(t, mc.IsFrozen())
+
+	require.ErrorIs(t, mc.Update(func() error { return nil }), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, mc.RegisterNewNode(mc.First().SelectNodes("p").First()), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, mc.First().RegisterNewNode(mc.First().SelectNodes("p").First()), flattenhtml.ErrFrozen)
+
+	seen := make([][]string, 10)
+
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for node := range mc.First().SelectNodes("p").All() {
+				seen[i] = append(seen[i], node.HTMLNode().FirstChild.Data)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range 10 {
+		require.Equal(t, []string{"1", "2", "3"}, seen[i])
+	}
+}
+
+func TestMultiCursor_FreezeNodes(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<div id="main" class="a"><p>1</p><p>2</p></div><form><input name="q" value="x"></form>`,
+	))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener(), flattenhtml.NewFormFlattener())
+	require.NoError(t, err)
+
+	forms, err := flattenhtml.SelectFlattenerOf[*flattenhtml.FormFlattener](mc, nil)
+	require.NoError(t, err)
+
+	detached := nm.Root().Clone(false)
+	detached.SetAttribute("id", "detached")
+
+	mc.Freeze()
+
+	cursor := mc.First()
+	div := cursor.SelectNodes("div").First()
+	p := cursor.SelectNodes("p").First()
+
+	require.ErrorIs(t, p.Remove(), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, p.Unwrap(), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, p.MoveTo(div, -1), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, p.InsertAfter(cursor.SelectNodes("p").Filter(func(node *flattenhtml.Node) bool {
+		return node.HTMLNode().FirstChild.Data == "2"
+	}).First()), flattenhtml.ErrFrozen)
+
+	_, err = p.Wrap("section", nil)
+	require.ErrorIs(t, err, flattenhtml.ErrFrozen)
+
+	_, err = p.ReplaceWithHTML("<span>3</span>")
+	require.ErrorIs(t, err, flattenhtml.ErrFrozen)
+
+	_, err = p.AppendSibling(flattenhtml.NodeTypeElement, "span", nil)
+	require.ErrorIs(t, err, flattenhtml.ErrFrozen)
+
+	require.Nil(t, div.AppendChild(flattenhtml.NodeTypeElement, "span", nil))
+	require.Nil(t, div.PrependChild(flattenhtml.NodeTypeText, "text", nil))
+
+	div.SetAttribute("id", "changed")
+	div.RemoveAttribute("class")
+	div.ClassList().Add("b")
+	div.Style().Set("color", "red")
+	div.SetData("seen", "true")
+	p.Empty()
+
+	// A node that is not a part of the frozen tree cannot be moved into it either.
+	require.ErrorIs(t, detached.InsertBefore(p), flattenhtml.ErrFrozen)
+
+	require.ErrorIs(t, forms.Forms()[0].Set("q", "y"), flattenhtml.ErrFrozen)
+	require.ErrorIs(t, forms.Forms()[0].Control("q").SetValue("y"), flattenhtml.ErrFrozen)
+
+	var out strings.Builder
+
+	require.NoError(t, nm.Render(&out))
+	require.Equal(
+		t,
+		`<html><head></head><body><div id="main" class="a"><p>1</p><p>2</p></div>`+
+			`<form><input name="q" value="x"/></form></body></html>`,
+		out.String(),
+	)
+
+	// Nodes outside the frozen tree can still be changed.
+	detached.SetAttribute("id", "changed")
+
+	value, _ := detached.Attribute("id")
+	require.Equal(t, "changed", value)
+}
+
+func TestMultiCursor_FreezeEmbeddedRoot(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := html.Parse(strings.NewReader(`<p>1</p>`))
+	require.NoError(t, err)
+
+	// The root is a field of a larger struct, rather than a separate allocation.
+	document := struct {
+		name string
+		root html.Node
+	}{name: "embedded", root: html.Node{Type: html.DocumentNode}}
+
+	for child := parsed.FirstChild; child != nil; child = parsed.FirstChild {
+		parsed.RemoveChild(child)
+		document.root.AppendChild(child)
+	}
+
+	nm := flattenhtml.NewNodeManager(&document.root)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	mc.Freeze()
+	runtime.GC()
+
+	require.ErrorIs(t, mc.First().SelectNodes("p").First().Remove(), flattenhtml.ErrFrozen)
+	require.Equal(t, "embedded", document.name)
+}
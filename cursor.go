@@ -1,29 +1,62 @@
 package flattenhtml
 
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/html"
+)
+
 // MultiCursor is a helper struct that holds all the configured flatteners.
 // It will usually be initiated by the NodeManager using the configured
 // flatteners which can be later filtered to a single flattener using
 // *MultiCursor.SelectFlattener method.
+//
+// MultiCursor is safe for concurrent reads as long as there is no concurrent
+// write. Writes (i.e., RegisterNewNode and all the mutations of Node) can be
+// guarded by MultiCursor.Update, while reads are guarded by MultiCursor.View.
+// Once frozen using MultiCursor.Freeze, the MultiCursor is an immutable snapshot
+// and it can be read from multiple goroutines without any guard.
 type MultiCursor struct {
 	flatteners []Flattener
+	names      []string
+	stats      *parseStats
+	// tree is the state of the parsed HTML tree. It is nil if the MultiCursor is
+	// created using NewMultiCursor.
+	tree   *treeState
+	mu     sync.RWMutex
+	frozen atomic.Bool
 }
 
 // Cursor is a helper struct that holds the selected flattener from the MultiCursor.
 // It allows the caller to perform different operations on the flattened document using
 // the selected flattener by *MultiCursor.SelectFlattener method.
 type Cursor struct {
-	flattener Flattener
+	flattener   Flattener
+	multiCursor *MultiCursor
 }
 
+// ErrFrozen is returned when a write operation is requested on a frozen MultiCursor.
+var ErrFrozen = errors.New("multi cursor is frozen and cannot be changed")
+
 // NewMultiCursor returns a new MultiCursor initiated by the NodeManager.
 // This holds all the configured flatteners that are used separately to
 // flatten the HTML tree.
 // To perform the variety of operations on the flattened documents, first you need
 // to select your desired flattener cursor using methods defined on MultiCursor.
-func NewMultiCursor(flatteners ...Flattener) *MultiCursor {
-	return &MultiCursor{
-		flatteners: flatteners,
+// The flatteners registered using Named can be selected by their names. If two
+// flatteners have the same name, ErrDuplicateName is returned.
+func NewMultiCursor(flatteners ...Flattener) (*MultiCursor, error) {
+	unwrapped, names, err := unwrapNamedFlatteners(flatteners)
+	if err != nil {
+		return nil, err
 	}
+
+	return &MultiCursor{
+		flatteners: unwrapped,
+		names:      names,
+	}, nil
 }
 
 // First returns the first Cursor from the MultiCursor initiated by the NodeManager.
@@ -35,13 +68,18 @@ func (m *MultiCursor) First() *Cursor {
 		return nil
 	}
 
-	return &Cursor{flattener: m.flatteners[0]}
+	return &Cursor{flattener: m.flatteners[0], multiCursor: m}
 }
 
 // SelectCursor returns a new Cursor with the selected flattener from the MultiCursor
-// initiated by the NodeManager.
+// initiated by the NodeManager. A flattener registered using Named is selected by
+// the type of its underlying flattener; use MultiCursor.Cursor to select it by name.
 // If the given flattener is not found in the MultiCursor, it returns ErrNoFlattener.
 func (m *MultiCursor) SelectCursor(flattener Flattener) (*Cursor, error) {
+	if named, ok := flattener.(*namedFlattener); ok {
+		flattener = named.Flattener
+	}
+
 	if flattener == nil {
 		return nil, ErrNoFlattener
 	}
@@ -60,25 +98,137 @@ func (m *MultiCursor) SelectCursor(flattener Flattener) (*Cursor, error) {
 		return nil, ErrNoFlattener
 	}
 
-	return &Cursor{flattener: newFlattener}, nil
+	return &Cursor{flattener: newFlattener, multiCursor: m}, nil
+}
+
+// Cursor returns a new Cursor with the flattener registered under the given name
+// using Named. If there is no flattener with the given name, it returns ErrNoFlattener.
+func (m *MultiCursor) Cursor(name string) (*Cursor, error) {
+	for i, flattenerName := range m.names {
+		if flattenerName != "" && flattenerName == name {
+			return &Cursor{flattener: m.flatteners[i], multiCursor: m}, nil
+		}
+	}
+
+	return nil, ErrNoFlattener
+}
+
+// Names returns the names of the flatteners registered using Named, in the order
+// they were given to NodeManager.Parse.
+func (m *MultiCursor) Names() []string {
+	names := make([]string, 0, len(m.names))
+
+	for _, name := range m.names {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// SelectKeyCursor returns a new Cursor with the KeyFlattener of the given name.
+// If there is no KeyFlattener with the given name, it returns ErrNoFlattener.
+func (m *MultiCursor) SelectKeyCursor(name string) (*Cursor, error) {
+	flattener, err := SelectFlattenerOf(m, func(flattener *KeyFlattener) bool {
+		return flattener.Name() == name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cursor{flattener: flattener, multiCursor: m}, nil
+}
+
+// SelectFlattenerOf returns the first flattener of the MultiCursor that has the type T
+// and satisfies the given match function. A nil match function accepts any flattener of
+// the type T. It gives access to the methods of the concrete flattener type, e.g.,
+// TextIndexFlattener.Search, without a type assertion.
+// If no flattener is found, it returns ErrNoFlattener.
+func SelectFlattenerOf[T Flattener](m *MultiCursor, match func(flattener T) bool) (T, error) {
+	for _, f := range m.flatteners {
+		if flattener, ok := f.(T); ok && (match == nil || match(flattener)) {
+			return flattener, nil
+		}
+	}
+
+	var zero T
+
+	return zero, ErrNoFlattener
 }
 
 // RegisterNewNode is used to add a newly and manually added nodes by the user to the cycle.
-// It calls flatten method of all it's flatteners by giving the Node's underlying html.Node.
+// It calls flatten method of all it's flatteners by giving the Node's underlying html.Node
+// and the html.Node of all its descendants.
 // New node can only be accessed by the NodeIterator and Cursor, if it is added to the cycle
 // using this method.
+// If the MultiCursor is frozen, it returns ErrFrozen. When the MultiCursor is shared
+// between goroutines, this method must be called inside MultiCursor.Update.
 func (m *MultiCursor) RegisterNewNode(node *Node) error {
 	if len(m.flatteners) == 0 {
 		return ErrNoFlattener
 	}
 
-	for _, f := range m.flatteners {
-		if err := f.Flatten(node.htmlNode); err != nil {
-			return err
-		}
+	if m.IsFrozen() {
+		return ErrFrozen
 	}
 
-	return nil
+	bindTree(node.tree, m.flatteners...)
+
+	return flattenSubtree(node.htmlNode, m.flatteners...)
+}
+
+// Freeze turns the MultiCursor into an immutable snapshot of the flattened document.
+// Afterward, RegisterNewNode and Update return ErrFrozen and all the read operations
+// (i.e., Cursor.SelectNodes, NodeIterator.Filter and NodeIterator.All) are safe to be
+// called from multiple goroutines without any guard.
+// If the MultiCursor is returned by NodeManager.Parse, its HTML tree is frozen as well,
+// so the write operations of the Node values of the tree, i.e., the nodes returned by
+// the flatteners and NodeManager.Root, return ErrFrozen, or do nothing if they do not
+// return an error. The Node values created using NewNode do not belong to any
+// NodeManager and are not frozen. Changing the *html.Node of a Node directly is not
+// prevented, and it is the responsibility of the caller to avoid it.
+// Freeze waits for the running Update and View calls to return.
+func (m *MultiCursor) Freeze() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.frozen.Store(true)
+
+	if m.tree != nil {
+		m.tree.frozen.Store(true)
+	}
+}
+
+// IsFrozen returns true if the MultiCursor is frozen using MultiCursor.Freeze.
+func (m *MultiCursor) IsFrozen() bool {
+	return m.frozen.Load()
+}
+
+// View calls the given function while holding the read lock of the MultiCursor.
+// Multiple View calls can run at the same time, but not at the same time as Update.
+// The function must not call MultiCursor.Update or Freeze.
+func (m *MultiCursor) View(fn func() error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return fn()
+}
+
+// Update calls the given function while holding the write lock of the MultiCursor.
+// It is the guarded write path for RegisterNewNode and the mutations of the nodes
+// (e.g., Node.SetAttribute or Node.Remove) when the MultiCursor is shared between
+// goroutines. If the MultiCursor is frozen, it returns ErrFrozen without calling
+// the function. The function must not call MultiCursor.View or Freeze.
+func (m *MultiCursor) Update(fn func() error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.frozen.Load() {
+		return ErrFrozen
+	}
+
+	return fn()
 }
 
 // SelectNodes returns a new NodeIterator that can iterates over the nodes that are selected
@@ -100,9 +250,92 @@ func (c *Cursor) Len() int {
 }
 
 // RegisterNewNode is used to add a newly and manually added nodes by the user to the cycle.
-// It calls flatten method of the cursor's flatteners by giving the Node's underlying html.Node.
+// It calls flatten method of the cursor's flatteners by giving the Node's underlying html.Node
+// and the html.Node of all its descendants.
 // New node can only be accessed by the NodeIterator and Cursor, if it is added to the cycle
 // using this method.
+// If the MultiCursor of the Cursor is frozen, it returns ErrFrozen.
 func (c *Cursor) RegisterNewNode(node *Node) error {
-	return c.flattener.Flatten(node.HTMLNode())
+	if c.multiCursor != nil && c.multiCursor.IsFrozen() {
+		return ErrFrozen
+	}
+
+	bindTree(node.tree, c.flattener)
+
+	return flattenSubtree(node.HTMLNode(), c.flattener)
+}
+
+// treeState is the state of an HTML tree that is owned by its NodeManager and shared
+// with its MultiCursor and the Node values of the tree.
+type treeState struct {
+	// frozen is set once the MultiCursor of the tree is frozen using MultiCursor.Freeze.
+	frozen atomic.Bool
+	// version is incremented by every change to the structure of the tree made using
+	// its Node values, so the flatteners that index the nodes by their position in the
+	// tree, e.g., PathFlattener, can find out that they are outdated.
+	version atomic.Uint64
+}
+
+// isFrozen checks whether the tree is frozen. A nil treeState belongs to the nodes
+// that are not a part of any NodeManager, which are never frozen.
+func (t *treeState) isFrozen() bool {
+	return t != nil && t.frozen.Load()
+}
+
+// treeBinder is implemented by the flatteners of this package, so the Node values
+// they create share the state of the tree they flatten.
+type treeBinder interface {
+	bindTree(tree *treeState)
+}
+
+// treeBinding is embedded in the flatteners of this package to implement treeBinder.
+// A flattener is bound to the first tree it flattens.
+type treeBinding struct {
+	tree *treeState
+}
+
+func (b *treeBinding) bindTree(tree *treeState) {
+	if b.tree == nil {
+		b.tree = tree
+	}
+}
+
+// treeVersion returns the version of the tree of the flattener, or zero if the
+// flattener is not bound to a tree yet.
+func (b *treeBinding) treeVersion() uint64 {
+	if b.tree == nil {
+		return 0
+	}
+
+	return b.tree.version.Load()
+}
+
+// newNode creates a new Node with the given *html.Node that shares the state of the
+// tree of the flattener.
+func (b *treeBinding) newNode(htmlNode *html.Node) *Node {
+	return newTreeNode(htmlNode, b.tree)
+}
+
+// bindTree binds the given flatteners to the given tree, if they are not bound yet.
+func bindTree(tree *treeState, flatteners ...Flattener) {
+	if tree == nil {
+		return
+	}
+
+	for _, flattener := range flatteners {
+		if binder, ok := flattener.(treeBinder); ok {
+			binder.bindTree(tree)
+		}
+	}
+}
+
+// checkFrozen returns ErrFrozen if any of the given nodes is a part of a frozen HTML tree.
+func checkFrozen(nodes ...*Node) error {
+	for _, node := range nodes {
+		if node.tree.isFrozen() {
+			return ErrFrozen
+		}
+	}
+
+	return nil
 }
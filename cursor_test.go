@@ -1,6 +1,7 @@
 package flattenhtml_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/seinshah/flattenhtml"
@@ -43,7 +44,8 @@ func TestMultiCursor_SelectCursor(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			mc := flattenhtml.NewMultiCursor(tc.flatteners...)
+			mc, err := flattenhtml.NewMultiCursor(tc.flatteners...)
+			require.NoError(t, err)
 
 			cu, err := mc.SelectCursor(tc.flattener)
 
@@ -94,9 +96,10 @@ func TestMultiCursor_RegisterNewNode(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			mc := flattenhtml.NewMultiCursor(tc.flatteners...)
+			mc, err := flattenhtml.NewMultiCursor(tc.flatteners...)
+			require.NoError(t, err)
 
-			err := mc.RegisterNewNode(flattenhtml.NewNode(&html.Node{}))
+			err = mc.RegisterNewNode(flattenhtml.NewNode(&html.Node{}))
 
 			if tc.wantErr {
 				require.Error(t, err)
@@ -146,7 +149,10 @@ func TestCursor(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			cu := flattenhtml.NewMultiCursor(tc.flattener).First()
+			mc, err := flattenhtml.NewMultiCursor(tc.flattener)
+			require.NoError(t, err)
+
+			cu := mc.First()
 
 			require.NotNil(t, cu)
 			require.NotNil(t, cu.SelectNodes(tc.key))
@@ -179,9 +185,10 @@ func TestCursor_RegisterNewNode(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			cursor := flattenhtml.NewMultiCursor(tc.flattener).First()
+			mc, err := flattenhtml.NewMultiCursor(tc.flattener)
+			require.NoError(t, err)
 
-			err := cursor.RegisterNewNode(flattenhtml.NewNode(&html.Node{}))
+			err = mc.First().RegisterNewNode(flattenhtml.NewNode(&html.Node{}))
 
 			if tc.wantErr {
 				require.Error(t, err)
@@ -198,3 +205,41 @@ func TestCursor_RegisterNewNode(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiCursor_RegisterNewNodeWithDescendants(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>text</p></div>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	newNodes, err := mc.First().SelectNodes("p").First().ReplaceWithHTML(`<section><p>new</p></section>`)
+	require.NoError(t, err)
+	require.Equal(t, 0, mc.First().SelectNodes("p").Len())
+
+	require.NoError(t, mc.RegisterNewNode(newNodes[0]))
+	require.Equal(t, 1, mc.First().SelectNodes("section").Len())
+	require.Equal(t, 1, mc.First().SelectNodes("p").Len())
+}
+
+func TestSelectFlattenerOf(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>text</p>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener(), flattenhtml.NewTextIndexFlattener())
+	require.NoError(t, err)
+
+	textIndex, err := flattenhtml.SelectFlattenerOf[*flattenhtml.TextIndexFlattener](mc, nil)
+	require.NoError(t, err)
+	require.Len(t, textIndex.Search("text"), 1)
+
+	_, err = flattenhtml.SelectFlattenerOf(mc, func(k *flattenhtml.KeyFlattener) bool { return true })
+	require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+
+	_, err = mc.SelectKeyCursor("missing")
+	require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+}
@@ -0,0 +1,178 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DataAttributeFlattener is a Flattener that flattens the HTML tree by the custom
+// data attributes (data-*) of the elements. Each element is categorized by the name
+// of its data attributes without the data- prefix (i.e., testid) and by the name
+// and value pairs (i.e., testid=checkout-button). Therefore, you can access all
+// elements with data-testid="checkout-button" using Cursor.SelectNodes("testid=checkout-button").
+//
+// An element is indexed once, even if it is flattened again, and flattening it again
+// (e.g., using MultiCursor.RegisterNewNode or Reindex) moves it from the keys it no
+// longer has to its new keys. Until then, the elements whose data attributes are
+// changed, e.g., using Node.SetData, are still returned for their old keys.
+type DataAttributeFlattener struct {
+	treeBinding
+
+	flattened map[string]*NodeIterator
+	// nodes holds the flattened elements along with their keys.
+	nodes map[*html.Node]*keyedNode
+}
+
+var (
+	_ Flattener        = (*DataAttributeFlattener)(nil)
+	_ FlattenerFactory = (*DataAttributeFlattener)(nil)
+	_ KeyLister        = (*DataAttributeFlattener)(nil)
+	_ Serializable     = (*DataAttributeFlattener)(nil)
+)
+
+// NewDataAttributeFlattener creates a new DataAttributeFlattener.
+func NewDataAttributeFlattener() *DataAttributeFlattener {
+	return &DataAttributeFlattener{
+		flattened: make(map[string]*NodeIterator),
+		nodes:     make(map[*html.Node]*keyedNode),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It categorizes the elements with data attributes by
+// the attribute names and the name and value pairs. This method does not
+// return an error.
+func (d *DataAttributeFlattener) Flatten(node *html.Node) error {
+	if node.Type != html.ElementNode {
+		return nil
+	}
+
+	keys := make([]string, 0)
+
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" || !strings.HasPrefix(attr.Key, dataAttributePrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(attr.Key, dataAttributePrefix)
+		keys = append(keys, name, name+"="+attr.Val)
+	}
+
+	keyed, ok := d.nodes[node]
+	if !ok {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		keyed = &keyedNode{node: d.newNode(node)}
+	}
+
+	indexKeys(d.flattened, d.nodes, keyed, keys)
+
+	return nil
+}
+
+// Reindex flattens the given element again, so it is added to the keys of its
+// current data attributes and removed from the keys it no longer has.
+func (d *DataAttributeFlattener) Reindex(node *Node) {
+	_ = d.Flatten(node.htmlNode)
+}
+
+// GetNodesByKey returns the elements that have the given data attribute name
+// (i.e., testid) or the given name and value pair (i.e., testid=checkout-button).
+func (d *DataAttributeFlattener) GetNodesByKey(key string) *NodeIterator {
+	return d.flattened[key]
+}
+
+func (d *DataAttributeFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*DataAttributeFlattener)
+
+	return ok
+}
+
+// Len for DataAttributeFlattener gives you the number of data attribute names
+// and name and value pairs in the HTML tree.
+func (d *DataAttributeFlattener) Len() int {
+	return len(d.flattened)
+}
+
+// Keys returns the keys of the DataAttributeFlattener in the sorted order.
+func (d *DataAttributeFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(d.flattened)
+}
+
+// NewFlattener returns a new and empty DataAttributeFlattener.
+func (d *DataAttributeFlattener) NewFlattener() Flattener {
+	return NewDataAttributeFlattener()
+}
+
+// Index returns the flattened nodes of the DataAttributeFlattener grouped by their keys.
+func (d *DataAttributeFlattener) Index() map[string]*NodeIterator {
+	return d.flattened
+}
+
+// RestoreIndex replaces the flattened nodes of the DataAttributeFlattener with the given index.
+// This method does not return an error.
+func (d *DataAttributeFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	d.flattened = index
+	d.nodes = keyedNodes(index)
+
+	return nil
+}
+
+// indexKeys replaces the keys of the flattened element in the given index with the
+// given keys, so the element is added to its new keys and removed from the keys it
+// no longer has. The nodes map holds the keys of each flattened element.
+func indexKeys(
+	flattened map[string]*NodeIterator, nodes map[*html.Node]*keyedNode, keyed *keyedNode, keys []string,
+) {
+	unique := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if !slices.Contains(unique, key) {
+			unique = append(unique, key)
+		}
+	}
+
+	for _, key := range keyed.keys {
+		if slices.Contains(unique, key) {
+			continue
+		}
+
+		iterator := flattened[key]
+		if iterator == nil {
+			continue
+		}
+
+		iterator.nodes = slices.DeleteFunc(iterator.nodes, func(node *Node) bool {
+			return node.htmlNode == keyed.node.htmlNode
+		})
+
+		if len(iterator.nodes) == 0 {
+			delete(flattened, key)
+		}
+	}
+
+	for _, key := range unique {
+		if slices.Contains(keyed.keys, key) {
+			continue
+		}
+
+		if _, ok := flattened[key]; !ok {
+			flattened[key] = NewNodeIterator()
+		}
+
+		flattened[key].Add(keyed.node)
+	}
+
+	keyed.keys = unique
+
+	if len(unique) == 0 {
+		delete(nodes, keyed.node.htmlNode)
+	} else {
+		nodes[keyed.node.htmlNode] = keyed
+	}
+}
@@ -0,0 +1,67 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataAttributeFlattener(t *testing.T) {
+	t.Parallel()
+
+	rawHTML := `<div data-component="cart"><button data-testid="checkout-button">Pay</button>` +
+		`<a data-testid="back" data-component="link">Back</a><p class="x">text</p></div>`
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(rawHTML))
+	require.NoError(t, err)
+
+	flattener := flattenhtml.NewDataAttributeFlattener()
+
+	mc, err := nm.Parse(flattener)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.DataAttributeFlattener{})
+	require.NoError(t, err)
+
+	// testid, testid=checkout-button, testid=back, component, component=cart, component=link
+	require.Equal(t, 6, cursor.Len())
+	require.Equal(t, 2, cursor.SelectNodes("testid").Len())
+	require.Equal(t, "button", cursor.SelectNodes("testid=checkout-button").First().TagName())
+	require.Equal(t, 2, cursor.SelectNodes("component").Len())
+	require.Equal(t, 0, cursor.SelectNodes("class").Len())
+	require.Nil(t, flattener.GetNodesByKey("missing"))
+	require.False(t, flattener.IsMyType(flattenhtml.NewTagFlattener()))
+	require.IsType(t, &flattenhtml.DataAttributeFlattener{}, flattener.NewFlattener())
+}
+
+func TestDataAttributeFlattener_Reindex(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<button data-testid="a">Pay</button>`))
+	require.NoError(t, err)
+
+	flattener := flattenhtml.NewDataAttributeFlattener()
+
+	mc, err := nm.Parse(flattener)
+	require.NoError(t, err)
+
+	button := flattener.GetNodesByKey("testid=a").First()
+	require.NotNil(t, button)
+
+	// The changed element stays under its old value until it is flattened again.
+	button.SetData("testid", "b")
+	require.Equal(t, 1, flattener.GetNodesByKey("testid=a").Len())
+	require.Nil(t, flattener.GetNodesByKey("testid=b"))
+
+	// Flattening it again moves it to the new key without duplicates.
+	require.NoError(t, mc.RegisterNewNode(button))
+	require.Nil(t, flattener.GetNodesByKey("testid=a"))
+	require.Equal(t, 1, flattener.GetNodesByKey("testid=b").Len())
+	require.Equal(t, 1, flattener.GetNodesByKey("testid").Len())
+
+	button.RemoveData("testid")
+	flattener.Reindex(button)
+	require.Equal(t, 0, flattener.Len())
+}
@@ -0,0 +1,86 @@
+package flattenhtml
+
+import (
+	"strings"
+)
+
+// dataAttributePrefix is the prefix of the custom data attributes.
+const dataAttributePrefix = "data-"
+
+// Dataset returns the custom data attributes (data-*) of the Node as a map of
+// camel-cased names to their values, the same as the dataset property of DOM.
+// For example, data-test-id="x" is returned as "testId": "x".
+// The returned map is a copy and changing it does not affect the Node.
+// Use Node.SetData and Node.RemoveData to change the data attributes.
+func (n *Node) Dataset() map[string]string {
+	dataset := make(map[string]string)
+
+	for _, attr := range n.htmlNode.Attr {
+		if attr.Namespace != "" || !strings.HasPrefix(attr.Key, dataAttributePrefix) {
+			continue
+		}
+
+		dataset[dataAttributeToCamel(attr.Key)] = attr.Val
+	}
+
+	return dataset
+}
+
+// Data returns the value of the data attribute with the given camel-cased name.
+// The second return value is a boolean that indicates whether the attribute is found.
+func (n *Node) Data(name string) (string, bool) {
+	return n.Attribute(dataCamelToAttribute(name))
+}
+
+// SetData sets the value of the data attribute with the given camel-cased name
+// using Node.SetAttribute. For example, SetData("testId", "x") sets data-test-id.
+func (n *Node) SetData(name, value string) {
+	n.SetAttribute(dataCamelToAttribute(name), value)
+}
+
+// RemoveData removes the data attribute with the given camel-cased name
+// using Node.RemoveAttribute.
+func (n *Node) RemoveData(name string) {
+	n.RemoveAttribute(dataCamelToAttribute(name))
+}
+
+// dataAttributeToCamel converts a data attribute name to its camel-cased name,
+// e.g., data-test-id to testId.
+func dataAttributeToCamel(key string) string {
+	name := strings.TrimPrefix(key, dataAttributePrefix)
+	builder := strings.Builder{}
+
+	for i := 0; i < len(name); i++ {
+		if name[i] == '-' && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z' {
+			builder.WriteByte(name[i+1] - 'a' + 'A')
+
+			i++
+
+			continue
+		}
+
+		builder.WriteByte(name[i])
+	}
+
+	return builder.String()
+}
+
+// dataCamelToAttribute converts a camel-cased name to its data attribute name,
+// e.g., testId to data-test-id.
+func dataCamelToAttribute(name string) string {
+	builder := strings.Builder{}
+	builder.WriteString(dataAttributePrefix)
+
+	for i := 0; i < len(name); i++ {
+		if name[i] >= 'A' && name[i] <= 'Z' {
+			builder.WriteByte('-')
+			builder.WriteByte(name[i] - 'A' + 'a')
+
+			continue
+		}
+
+		builder.WriteByte(name[i])
+	}
+
+	return builder.String()
+}
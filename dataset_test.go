@@ -0,0 +1,41 @@
+package flattenhtml_test
+
+import (
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestNode_Dataset(t *testing.T) {
+	t.Parallel()
+
+	node := flattenhtml.NewNode(&html.Node{
+		Type: html.ElementNode,
+		Data: "button",
+		Attr: []html.Attribute{
+			{Key: "data-testid", Val: "checkout"},
+			{Key: "data-component-name", Val: "cart"},
+			{Key: "class", Val: "btn"},
+		},
+	})
+
+	require.Equal(t, map[string]string{"testid": "checkout", "componentName": "cart"}, node.Dataset())
+
+	val, ok := node.Data("componentName")
+	require.True(t, ok)
+	require.Equal(t, "cart", val)
+
+	node.SetData("trackId", "42")
+
+	val, ok = node.Attribute("data-track-id")
+	require.True(t, ok)
+	require.Equal(t, "42", val)
+
+	node.RemoveData("testid")
+
+	_, ok = node.Data("testid")
+	require.False(t, ok)
+	require.Len(t, node.HTMLNode().Attr, 3)
+}
@@ -0,0 +1,800 @@
+package flattenhtml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChangeType is the type of a single Change of an EditScript.
+type ChangeType int
+
+// DiffOption is a function that configures the Diff.
+type DiffOption func(options *diffOptions)
+
+// Change is a single edit of an EditScript. Path and Location address the node in
+// the old document, while NewPath and NewLocation address the node in the new one.
+// Depending on the Type, only one side might be set.
+type Change struct {
+	Type ChangeType
+	// Path is the child index path of the node in the old document.
+	Path []int
+	// NewPath is the child index path of the node in the new document.
+	NewPath []int
+	// Location is a human-readable address of the node in the old document.
+	Location string
+	// NewLocation is a human-readable address of the node in the new document.
+	NewLocation string
+	// Node is the node in the old document.
+	Node *Node
+	// NewNode is the node in the new document.
+	NewNode *Node
+	// Key is the attribute key of a ChangeAttribute.
+	Key string
+	// OldValue is the old value of the attribute or text.
+	OldValue string
+	// NewValue is the new value of the attribute or text.
+	NewValue string
+}
+
+// EditScript is the structured result of Diff. It lists the changes that turn the
+// old document into the new one and can be applied to the old document as a patch.
+type EditScript struct {
+	Changes []Change
+	options diffOptions
+}
+
+// diffOptions holds the configurations of the Diff.
+type diffOptions struct {
+	ignoreWhitespace     bool
+	ignoreAttributeOrder bool
+	keyFunc              func(node *Node) string
+}
+
+const (
+	// ChangeInserted is a node that only exists in the new document.
+	ChangeInserted ChangeType = iota
+	// ChangeRemoved is a node that only exists in the old document.
+	ChangeRemoved
+	// ChangeMoved is a node that exists in both documents at different positions.
+	ChangeMoved
+	// ChangeAttribute is an attribute that is added, removed or changed. An added
+	// attribute has an empty OldValue and a removed one has an empty NewValue.
+	ChangeAttribute
+	// ChangeAttributeOrder is an element whose attributes are the same but in a different order.
+	ChangeAttributeOrder
+	// ChangeText is a text or comment node whose content is changed.
+	ChangeText
+)
+
+// diffSnippetLen is the maximum length of the HTML snippets in the EditScript report.
+const diffSnippetLen = 60
+
+// ErrPatchConflict is returned when an EditScript cannot be applied to a document,
+// because the document does not have the structure that the EditScript expects.
+var ErrPatchConflict = errors.New("edit script does not match the document")
+
+// String returns a human-readable name of the ChangeType.
+func (c ChangeType) String() string {
+	switch c {
+	case ChangeInserted:
+		return "inserted"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeMoved:
+		return "moved"
+	case ChangeAttribute:
+		return "attribute"
+	case ChangeAttributeOrder:
+		return "attribute-order"
+	case ChangeText:
+		return "text"
+	default:
+		return "unknown"
+	}
+}
+
+// WithDiffIgnoreWhitespace ignores the whitespace-only text nodes and compares
+// the text nodes with their whitespace collapsed and trimmed.
+func WithDiffIgnoreWhitespace() DiffOption {
+	return func(options *diffOptions) {
+		options.ignoreWhitespace = true
+	}
+}
+
+// WithDiffIgnoreAttributeOrder ignores the elements whose attributes are the same
+// but in a different order.
+func WithDiffIgnoreAttributeOrder() DiffOption {
+	return func(options *diffOptions) {
+		options.ignoreAttributeOrder = true
+	}
+}
+
+// WithDiffMatchByID matches the elements of the two documents by their id attribute.
+// Matched elements are reported as moved instead of being removed and inserted.
+func WithDiffMatchByID() DiffOption {
+	return WithDiffKeyFunc(func(node *Node) string {
+		id, _ := node.Attribute("id")
+
+		return id
+	})
+}
+
+// WithDiffKeyFunc matches the nodes of the two documents by the key that the given
+// function returns. An empty key means that the node has no key and is matched by
+// its position and tag name.
+func WithDiffKeyFunc(fn func(node *Node) string) DiffOption {
+	return func(options *diffOptions) {
+		options.keyFunc = fn
+	}
+}
+
+// Diff compares two documents and returns the EditScript that turns the document
+// of a into the document of b. Children are matched by their key (if any) and then
+// by their type and tag name in order. Unmatched nodes with the same content in both
+// documents are reported as moved.
+func Diff(a, b *NodeManager, opts ...DiffOption) *EditScript {
+	script := &EditScript{
+		Changes: make([]Change, 0),
+	}
+
+	for _, opt := range opts {
+		opt(&script.options)
+	}
+
+	differ := &differ{options: script.options}
+	differ.children(a.root, b.root, nil, nil)
+
+	script.Changes = differ.changes
+
+	return script
+}
+
+// Len returns the number of changes of the EditScript.
+func (e *EditScript) Len() int {
+	return len(e.Changes)
+}
+
+// String returns a human-readable report of the changes, one change per line.
+func (e *EditScript) String() string {
+	builder := strings.Builder{}
+
+	for _, change := range e.Changes {
+		switch change.Type {
+		case ChangeInserted:
+			fmt.Fprintf(&builder, "+ inserted %s: %s\n", change.NewLocation, htmlSnippet(change.NewNode.htmlNode))
+		case ChangeRemoved:
+			fmt.Fprintf(&builder, "- removed %s: %s\n", change.Location, htmlSnippet(change.Node.htmlNode))
+		case ChangeMoved:
+			fmt.Fprintf(&builder, "~ moved %s -> %s\n", change.Location, change.NewLocation)
+		case ChangeAttribute:
+			fmt.Fprintf(&builder, "~ attribute %s of %s: %q -> %q\n",
+				change.Key, change.Location, change.OldValue, change.NewValue)
+		case ChangeAttributeOrder:
+			fmt.Fprintf(&builder, "~ attribute order of %s: %s -> %s\n",
+				change.Location, change.OldValue, change.NewValue)
+		case ChangeText:
+			fmt.Fprintf(&builder, "~ text %s: %q -> %q\n", change.Location, change.OldValue, change.NewValue)
+		}
+	}
+
+	return builder.String()
+}
+
+// Apply applies the EditScript to the given document as a patch. The document must
+// have the same structure as the old document of the Diff, i.e., each changed node
+// must exist at its path with the same type and tag name; otherwise, ErrPatchConflict
+// is returned and the document is not changed. Inserted nodes are copied from the new
+// document, so the new document is not changed. The NodeIterators of the patched
+// document are not updated, so the document should be parsed again to query the
+// patched nodes.
+// If the document is frozen using MultiCursor.Freeze, ErrFrozen is returned.
+func (e *EditScript) Apply(nm *NodeManager) error {
+	if err := checkFrozen(nm.Root()); err != nil {
+		return err
+	}
+
+	// The changes are applied to a copy of the document first, so a conflict found
+	// in the middle of the patch does not leave the document partially patched.
+	if err := e.apply(cloneHTMLNode(nm.root, true)); err != nil {
+		return err
+	}
+
+	defer nm.tree.version.Add(1)
+
+	return e.apply(nm.root)
+}
+
+// apply applies the changes of the EditScript to the tree of the given root.
+func (e *EditScript) apply(root *html.Node) error {
+	resolved := make([]*html.Node, len(e.Changes))
+
+	// All old paths are resolved before any change to the structure of the tree.
+	for i, change := range e.Changes {
+		if change.Type == ChangeInserted {
+			continue
+		}
+
+		node := e.resolve(root, change.Path)
+		if node == nil {
+			return fmt.Errorf("%w: no node at %s", ErrPatchConflict, change.Location)
+		}
+
+		if change.Node != nil && !sameKind(node, change.Node.htmlNode) {
+			return fmt.Errorf("%w: the node at %s is %s", ErrPatchConflict, change.Location, locationName(node))
+		}
+
+		resolved[i] = node
+	}
+
+	for i, change := range e.Changes {
+		if err := applyValueChange(resolved[i], change); err != nil {
+			return err
+		}
+	}
+
+	for i, change := range e.Changes {
+		if change.Type == ChangeRemoved || change.Type == ChangeMoved {
+			if resolved[i].Parent == nil {
+				return fmt.Errorf("%w: %s has no parent", ErrPatchConflict, change.Location)
+			}
+
+			resolved[i].Parent.RemoveChild(resolved[i])
+		}
+	}
+
+	// Inserted and moved nodes are placed in the order of the new document, so
+	// the preceding siblings and the ancestors of each node are already in place.
+	placed := make([]int, 0)
+
+	for i, change := range e.Changes {
+		if change.Type == ChangeInserted || change.Type == ChangeMoved {
+			placed = append(placed, i)
+		}
+	}
+
+	slices.SortStableFunc(placed, func(i, j int) int {
+		return slices.Compare(e.Changes[i].NewPath, e.Changes[j].NewPath)
+	})
+
+	for _, i := range placed {
+		change := e.Changes[i]
+		node := resolved[i]
+		if change.Type == ChangeInserted {
+			node = cloneHTMLNode(change.NewNode.htmlNode, true)
+		}
+
+		if err := e.insert(root, change.NewPath, node); err != nil {
+			return fmt.Errorf("%w: cannot insert at %s", err, change.NewLocation)
+		}
+	}
+
+	return nil
+}
+
+// sameKind checks whether the given nodes have the same type and, for elements,
+// the same tag name.
+func sameKind(node, expected *html.Node) bool {
+	return node.Type == expected.Type && (node.Type != html.ElementNode || node.Data == expected.Data)
+}
+
+// resolve returns the node at the given child index path, or nil if it does not exist.
+func (e *EditScript) resolve(root *html.Node, path []int) *html.Node {
+	node := root
+
+	for _, index := range path {
+		children := e.options.children(node)
+		if index < 0 || index >= len(children) {
+			return nil
+		}
+
+		node = children[index]
+	}
+
+	return node
+}
+
+// insert inserts the node at the given child index path.
+func (e *EditScript) insert(root *html.Node, path []int, node *html.Node) error {
+	if len(path) == 0 {
+		return ErrPatchConflict
+	}
+
+	parent := e.resolve(root, path[:len(path)-1])
+	if parent == nil {
+		return ErrPatchConflict
+	}
+
+	children := e.options.children(parent)
+	index := path[len(path)-1]
+
+	switch {
+	case index < len(children):
+		parent.InsertBefore(node, children[index])
+	case index == len(children):
+		parent.AppendChild(node)
+	default:
+		return ErrPatchConflict
+	}
+
+	return nil
+}
+
+// applyValueChange applies the attribute and text changes to the given node.
+func applyValueChange(node *html.Node, change Change) error {
+	switch change.Type {
+	case ChangeAttribute:
+		wrapper := NewNode(node)
+
+		if _, ok := change.NewNode.Attribute(change.Key); ok {
+			wrapper.SetAttribute(change.Key, change.NewValue)
+		} else {
+			wrapper.RemoveAttribute(change.Key)
+		}
+	case ChangeAttributeOrder:
+		node.Attr = append([]html.Attribute(nil), change.NewNode.htmlNode.Attr...)
+	case ChangeText:
+		if node.Type != change.NewNode.htmlNode.Type {
+			return fmt.Errorf("%w: %s is not a text node", ErrPatchConflict, change.Location)
+		}
+
+		node.Data = change.NewNode.htmlNode.Data
+	}
+
+	return nil
+}
+
+// differ holds the state of a single Diff.
+type differ struct {
+	options diffOptions
+	changes []Change
+	// hashes caches the hash of the compared subtrees for the move detection.
+	hashes map[*html.Node]uint64
+}
+
+// children compares the children of two matched nodes.
+func (d *differ) children(a, b *html.Node, aPath, bPath []int) {
+	aChildren := d.options.children(a)
+	bChildren := d.options.children(b)
+	matches := d.match(aChildren, bChildren)
+
+	aMatched := make(map[int]int, len(matches))
+	bMatched := make(map[int]bool, len(matches))
+
+	for _, match := range matches {
+		aMatched[match.a] = match.b
+		bMatched[match.b] = true
+	}
+
+	// The pairs that are matched by content, and not by their order.
+	removed := make([]int, 0)
+
+	for i := range aChildren {
+		if _, ok := aMatched[i]; !ok {
+			removed = append(removed, i)
+		}
+	}
+
+	inserted := make([]int, 0)
+
+	for i := range bChildren {
+		if !bMatched[i] {
+			inserted = append(inserted, i)
+		}
+	}
+
+	moved := make(map[int]int)
+
+	for _, j := range inserted {
+		for k, i := range removed {
+			if i >= 0 && d.sameSubtree(aChildren[i], bChildren[j]) {
+				moved[j] = i
+				removed[k] = -1
+
+				break
+			}
+		}
+	}
+
+	for _, i := range removed {
+		if i >= 0 {
+			d.add(ChangeRemoved, aChildren[i], nil, childPath(aPath, i), nil)
+		}
+	}
+
+	for j := range bChildren {
+		if i, ok := moved[j]; ok {
+			d.add(ChangeMoved, aChildren[i], bChildren[j], childPath(aPath, i), childPath(bPath, j))
+
+			continue
+		}
+
+		if !bMatched[j] {
+			d.add(ChangeInserted, nil, bChildren[j], nil, childPath(bPath, j))
+		}
+	}
+
+	for _, match := range matches {
+		aChild, bChild := aChildren[match.a], bChildren[match.b]
+		aChildPath, bChildPath := childPath(aPath, match.a), childPath(bPath, match.b)
+
+		if match.moved {
+			d.add(ChangeMoved, aChild, bChild, aChildPath, bChildPath)
+		}
+
+		d.node(aChild, bChild, aChildPath, bChildPath)
+	}
+}
+
+// node compares two matched nodes and their descendants.
+func (d *differ) node(a, b *html.Node, aPath, bPath []int) {
+	switch a.Type {
+	case html.TextNode, html.CommentNode:
+		aText, bText := a.Data, b.Data
+
+		if d.options.ignoreWhitespace {
+			aText, bText = strings.TrimSpace(collapseSpaces(aText)), strings.TrimSpace(collapseSpaces(bText))
+		}
+
+		if aText != bText {
+			d.add(ChangeText, a, b, aPath, bPath)
+			d.changes[len(d.changes)-1].OldValue = a.Data
+			d.changes[len(d.changes)-1].NewValue = b.Data
+		}
+
+		return
+	case html.ElementNode:
+		d.attributes(a, b, aPath, bPath)
+	}
+
+	d.children(a, b, aPath, bPath)
+}
+
+// attributes compares the attributes of two matched elements. The order of the
+// attributes is compared after the changes are applied, i.e., with the removed
+// attributes dropped and the added ones appended, so the order change is reported
+// along with the value changes whenever they do not reproduce the new order.
+func (d *differ) attributes(a, b *html.Node, aPath, bPath []int) {
+	aAttrs := attributeMap(a)
+	bAttrs := attributeMap(b)
+	order := make([]string, 0, len(b.Attr))
+
+	for _, attr := range a.Attr {
+		newValue, ok := bAttrs[attr.Key]
+		if ok {
+			order = append(order, attr.Key)
+		}
+
+		if ok && newValue == attr.Val {
+			continue
+		}
+
+		d.add(ChangeAttribute, a, b, aPath, bPath)
+		d.changes[len(d.changes)-1].Key = attr.Key
+		d.changes[len(d.changes)-1].OldValue = attr.Val
+		d.changes[len(d.changes)-1].NewValue = newValue
+	}
+
+	for _, attr := range b.Attr {
+		if _, ok := aAttrs[attr.Key]; ok {
+			continue
+		}
+
+		order = append(order, attr.Key)
+
+		d.add(ChangeAttribute, a, b, aPath, bPath)
+		d.changes[len(d.changes)-1].Key = attr.Key
+		d.changes[len(d.changes)-1].NewValue = attr.Val
+	}
+
+	if d.options.ignoreAttributeOrder || strings.Join(order, ",") == attributeKeys(b) {
+		return
+	}
+
+	d.add(ChangeAttributeOrder, a, b, aPath, bPath)
+	d.changes[len(d.changes)-1].OldValue = attributeKeys(a)
+	d.changes[len(d.changes)-1].NewValue = attributeKeys(b)
+}
+
+// sameSubtree checks whether two nodes have the same content. The subtrees are
+// compared by their hash, which is computed once per node, and only the nodes with
+// the same hash are rendered to rule out a collision.
+func (d *differ) sameSubtree(a, b *html.Node) bool {
+	return d.hash(a) == d.hash(b) && renderHTML(a) == renderHTML(b)
+}
+
+// hash returns the hash of the node and its descendants.
+func (d *differ) hash(node *html.Node) uint64 {
+	if sum, ok := d.hashes[node]; ok {
+		return sum
+	}
+
+	hasher := fnv.New64a()
+
+	fmt.Fprintf(hasher, "%d\x00%s\x00%s\x00", node.Type, node.Namespace, node.Data)
+
+	for _, attr := range node.Attr {
+		fmt.Fprintf(hasher, "%s\x00%s\x00%s\x00", attr.Namespace, attr.Key, attr.Val)
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		fmt.Fprintf(hasher, "%x\x00", d.hash(child))
+	}
+
+	sum := hasher.Sum64()
+
+	if d.hashes == nil {
+		d.hashes = make(map[*html.Node]uint64)
+	}
+
+	d.hashes[node] = sum
+
+	return sum
+}
+
+// add appends a new change to the result of the Diff.
+func (d *differ) add(changeType ChangeType, a, b *html.Node, aPath, bPath []int) {
+	change := Change{
+		Type:    changeType,
+		Path:    aPath,
+		NewPath: bPath,
+	}
+
+	if a != nil {
+		change.Node = NewNode(a)
+		change.Location = nodeLocation(a)
+	}
+
+	if b != nil {
+		change.NewNode = NewNode(b)
+		change.NewLocation = nodeLocation(b)
+	}
+
+	d.changes = append(d.changes, change)
+}
+
+// childMatch is a pair of matched children of two nodes. If moved is true, the pair
+// is matched by its key while being out of order.
+type childMatch struct {
+	a, b  int
+	moved bool
+}
+
+// match matches the children of two nodes. Keyed children are matched by their key,
+// and the rest are matched by the longest common subsequence of their signatures.
+func (d *differ) match(aChildren, bChildren []*html.Node) []childMatch {
+	aKeys := d.keys(aChildren)
+	bKeys := d.keys(bChildren)
+	keyed := make(map[int]int)
+	bKeyIndex := make(map[string]int)
+
+	for j, key := range bKeys {
+		if _, ok := bKeyIndex[key]; key != "" && !ok {
+			bKeyIndex[key] = j
+		}
+	}
+
+	for i, key := range aKeys {
+		if j, ok := bKeyIndex[key]; ok && key != "" && diffSignature(aChildren[i]) == diffSignature(bChildren[j]) {
+			keyed[i] = j
+
+			delete(bKeyIndex, key)
+		}
+	}
+
+	keyedB := make(map[int]bool, len(keyed))
+
+	for _, j := range keyed {
+		keyedB[j] = true
+	}
+
+	equal := func(i, j int) bool {
+		if k, ok := keyed[i]; ok {
+			return k == j
+		}
+
+		if keyedB[j] || aKeys[i] != "" || bKeys[j] != "" {
+			return false
+		}
+
+		return diffSignature(aChildren[i]) == diffSignature(bChildren[j])
+	}
+
+	matches := longestCommonSubsequence(len(aChildren), len(bChildren), equal)
+	inOrder := make(map[int]bool, len(matches))
+
+	for _, match := range matches {
+		inOrder[match.a] = true
+	}
+
+	for i := range aChildren {
+		if j, ok := keyed[i]; ok && !inOrder[i] {
+			matches = append(matches, childMatch{a: i, b: j, moved: true})
+		}
+	}
+
+	return matches
+}
+
+// keys returns the key of each node using the configured key function.
+func (d *differ) keys(nodes []*html.Node) []string {
+	keys := make([]string, len(nodes))
+
+	if d.options.keyFunc == nil {
+		return keys
+	}
+
+	for i, node := range nodes {
+		if node.Type == html.ElementNode {
+			keys[i] = d.options.keyFunc(NewNode(node))
+		}
+	}
+
+	return keys
+}
+
+// children returns the children of the node that take part in the Diff.
+func (o diffOptions) children(node *html.Node) []*html.Node {
+	children := make([]*html.Node, 0)
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if o.ignoreWhitespace && child.Type == html.TextNode && strings.TrimSpace(child.Data) == "" {
+			continue
+		}
+
+		children = append(children, child)
+	}
+
+	return children
+}
+
+// longestCommonSubsequence returns the matched pairs of the longest common
+// subsequence of two sequences with the given lengths.
+func longestCommonSubsequence(aLen, bLen int, equal func(i, j int) bool) []childMatch {
+	table := make([][]int, aLen+1)
+
+	for i := range table {
+		table[i] = make([]int, bLen+1)
+	}
+
+	for i := aLen - 1; i >= 0; i-- {
+		for j := bLen - 1; j >= 0; j-- {
+			if equal(i, j) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	matches := make([]childMatch, 0, table[0][0])
+
+	for i, j := 0, 0; i < aLen && j < bLen; {
+		switch {
+		case equal(i, j):
+			matches = append(matches, childMatch{a: i, b: j})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+// diffSignature returns the type and tag name of the node which must be equal
+// for two nodes to be matched. Text and comment nodes are matched by their type only,
+// so the change of their content is reported as ChangeText.
+func diffSignature(node *html.Node) string {
+	if node.Type == html.TextNode || node.Type == html.CommentNode {
+		return strconv.Itoa(int(node.Type))
+	}
+
+	return strconv.Itoa(int(node.Type)) + ":" + node.Data
+}
+
+// childPath returns a copy of the parent path with the given index appended.
+func childPath(parent []int, index int) []int {
+	path := make([]int, len(parent), len(parent)+1)
+	copy(path, parent)
+
+	return append(path, index)
+}
+
+// nodeLocation returns a XPath-like address of the node, e.g., /html[1]/body[1]/p[2].
+func nodeLocation(node *html.Node) string {
+	segments := make([]string, 0)
+
+	for ; node != nil && node.Type != html.DocumentNode; node = node.Parent {
+		name := locationName(node)
+		position := 1
+
+		for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+			if sibling.Type == node.Type && (node.Type != html.ElementNode || sibling.Data == node.Data) {
+				position++
+			}
+		}
+
+		segments = append(segments, name+"["+strconv.Itoa(position)+"]")
+	}
+
+	builder := strings.Builder{}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		builder.WriteString("/" + segments[i])
+	}
+
+	if builder.Len() == 0 {
+		return "/"
+	}
+
+	return builder.String()
+}
+
+// locationName returns the name of the node in its location, i.e., the tag name of
+// the elements and text(), comment() or doctype() for the other nodes.
+func locationName(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return "text()"
+	case html.CommentNode:
+		return "comment()"
+	case html.DoctypeNode:
+		return "doctype()"
+	default:
+		return node.Data
+	}
+}
+
+// attributeMap returns the attributes of the node as a map.
+func attributeMap(node *html.Node) map[string]string {
+	attrs := make(map[string]string, len(node.Attr))
+
+	for _, attr := range node.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+
+	return attrs
+}
+
+// attributeKeys returns the attribute keys of the node in their order.
+func attributeKeys(node *html.Node) string {
+	keys := make([]string, 0, len(node.Attr))
+
+	for _, attr := range node.Attr {
+		keys = append(keys, attr.Key)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// renderHTML renders the node and its descendants to a string.
+func renderHTML(node *html.Node) string {
+	buffer := bytes.Buffer{}
+
+	if err := html.Render(&buffer, node); err != nil {
+		return ""
+	}
+
+	return buffer.String()
+}
+
+// htmlSnippet returns the rendered node shortened to be used in reports.
+func htmlSnippet(node *html.Node) string {
+	snippet := renderHTML(node)
+
+	if len(snippet) > diffSnippetLen {
+		snippet = snippet[:diffSnippetLen] + "..."
+	}
+
+	return snippet
+}
@@ -0,0 +1,194 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		old      string
+		new      string
+		options  []flattenhtml.DiffOption
+		expected []flattenhtml.ChangeType
+	}{
+		{
+			name:     "identical documents",
+			old:      `<div id="a"><p>text</p></div>`,
+			new:      `<div id="a"><p>text</p></div>`,
+			expected: []flattenhtml.ChangeType{},
+		},
+		{
+			name:     "inserted and removed nodes",
+			old:      `<div><p>one</p><span>two</span></div>`,
+			new:      `<div><p>one</p><a>three</a></div>`,
+			expected: []flattenhtml.ChangeType{flattenhtml.ChangeRemoved, flattenhtml.ChangeInserted},
+		},
+		{
+			name: "changed attributes and text",
+			old:  `<p class="a" title="t">old</p>`,
+			new:  `<p class="b" lang="en">new</p>`,
+			expected: []flattenhtml.ChangeType{
+				flattenhtml.ChangeAttribute, flattenhtml.ChangeAttribute, flattenhtml.ChangeAttribute, flattenhtml.ChangeText,
+			},
+		},
+		{
+			name:     "moved nodes by content",
+			old:      `<ul><li>one</li><li>two</li><b>x</b></ul>`,
+			new:      `<ul><b>x</b><li>one</li><li>two</li></ul>`,
+			expected: []flattenhtml.ChangeType{flattenhtml.ChangeMoved},
+		},
+		{
+			name:     "moved nodes by id",
+			old:      `<div id="first">1</div><div id="second">2</div>`,
+			new:      `<div id="second">2</div><div id="first">1!</div>`,
+			options:  []flattenhtml.DiffOption{flattenhtml.WithDiffMatchByID()},
+			expected: []flattenhtml.ChangeType{flattenhtml.ChangeMoved, flattenhtml.ChangeText},
+		},
+		{
+			name:     "attribute order",
+			old:      `<p class="a" id="b"></p>`,
+			new:      `<p id="b" class="a"></p>`,
+			expected: []flattenhtml.ChangeType{flattenhtml.ChangeAttributeOrder},
+		},
+		{
+			name: "changed values and attribute order",
+			old:  `<p class="a" id="b" title="t"></p>`,
+			new:  `<p id="c" lang="en" class="a"></p>`,
+			expected: []flattenhtml.ChangeType{
+				flattenhtml.ChangeAttribute, flattenhtml.ChangeAttribute, flattenhtml.ChangeAttribute,
+				flattenhtml.ChangeAttributeOrder,
+			},
+		},
+		{
+			name:     "ignored attribute order",
+			old:      `<p class="a" id="b"></p>`,
+			new:      `<p id="b" class="a"></p>`,
+			options:  []flattenhtml.DiffOption{flattenhtml.WithDiffIgnoreAttributeOrder()},
+			expected: []flattenhtml.ChangeType{},
+		},
+		{
+			name:     "ignored whitespace",
+			old:      "<div>\n  <p>a  b</p>\n</div>",
+			new:      `<div><p>a b</p></div>`,
+			options:  []flattenhtml.DiffOption{flattenhtml.WithDiffIgnoreWhitespace()},
+			expected: []flattenhtml.ChangeType{},
+		},
+		{
+			name:     "ignored leading and trailing whitespace",
+			old:      "<p> a <b>b</b>\n</p>",
+			new:      `<p>a<b>b</b></p>`,
+			options:  []flattenhtml.DiffOption{flattenhtml.WithDiffIgnoreWhitespace()},
+			expected: []flattenhtml.ChangeType{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			oldNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.old))
+			require.NoError(t, err)
+
+			newNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.new))
+			require.NoError(t, err)
+
+			script := flattenhtml.Diff(oldNM, newNM, tc.options...)
+
+			types := make([]flattenhtml.ChangeType, 0)
+
+			for _, change := range script.Changes {
+				types = append(types, change.Type)
+			}
+
+			require.Equal(t, tc.expected, types, script.String())
+
+			// Applying the script as a patch must result in the new document.
+			require.NoError(t, script.Apply(oldNM))
+			require.Equal(t, 0, flattenhtml.Diff(oldNM, newNM, tc.options...).Len())
+
+			if len(tc.options) == 0 {
+				patched, expected := bytes.Buffer{}, bytes.Buffer{}
+
+				require.NoError(t, oldNM.Render(&patched))
+				require.NoError(t, newNM.Render(&expected))
+				require.Equal(t, expected.String(), patched.String())
+			}
+		})
+	}
+}
+
+func TestEditScript_String(t *testing.T) {
+	t.Parallel()
+
+	oldNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p class="a">old</p>`))
+	require.NoError(t, err)
+
+	newNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p class="b">old</p><hr>`))
+	require.NoError(t, err)
+
+	report := flattenhtml.Diff(oldNM, newNM).String()
+
+	require.Equal(t,
+		"+ inserted /html[1]/body[1]/hr[1]: <hr/>\n"+
+			"~ attribute class of /html[1]/body[1]/p[1]: \"a\" -> \"b\"\n",
+		report,
+	)
+}
+
+func TestEditScript_Apply(t *testing.T) {
+	t.Parallel()
+
+	oldNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>a</p><p>b</p></div>`))
+	require.NoError(t, err)
+
+	newNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>b</p><section><p>c</p></section></div>`))
+	require.NoError(t, err)
+
+	script := flattenhtml.Diff(oldNM, newNM)
+
+	require.NoError(t, script.Apply(oldNM))
+
+	rendered := bytes.Buffer{}
+
+	require.NoError(t, oldNM.Render(&rendered))
+	require.Contains(t, rendered.String(), `<div><p>b</p><section><p>c</p></section></div>`)
+
+	conflicting, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(``))
+	require.NoError(t, err)
+
+	err = script.Apply(conflicting)
+	require.ErrorIs(t, err, flattenhtml.ErrPatchConflict)
+}
+
+func TestEditScript_Apply_Atomic(t *testing.T) {
+	t.Parallel()
+
+	oldNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p class="a">x</p><p>y</p>`))
+	require.NoError(t, err)
+
+	newNM, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p class="b">x</p>`))
+	require.NoError(t, err)
+
+	script := flattenhtml.Diff(oldNM, newNM)
+
+	// The second paragraph is a div in the target, so the removal conflicts and
+	// the attribute change of the first paragraph is not applied either.
+	target, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p class="a">x</p><div>y</div>`))
+	require.NoError(t, err)
+
+	err = script.Apply(target)
+	require.ErrorIs(t, err, flattenhtml.ErrPatchConflict)
+
+	rendered := bytes.Buffer{}
+
+	require.NoError(t, target.Render(&rendered))
+	require.Contains(t, rendered.String(), `<p class="a">x</p><div>y</div>`)
+}
@@ -12,9 +12,11 @@
 // to first flatten all the nodes based on their tag name and then do continues tag
 // lookup without the need for constantly traversing the tree.
 //
-// TagFlattener is currently the only built-in flattener of this package. However,
-// all flatteners implement flattenhtml.Flattener interface and you can easily
-// implement your own flattener.
+// TagFlattener, DataAttributeFlattener, TextIndexFlattener, PathFlattener,
+// AncestryFlattener, FormFlattener, TableFlattener, RoleFlattener and Linter are the
+// built-in flatteners of this package.
+// However, all flatteners implement flattenhtml.Flattener interface and you can easily
+// implement your own flattener, or build one from a function using NewKeyFlattener.
 //
 // When you use the following statement to initialize the NodeManager, parsed HTML
 // tree will be traversed once and for any further lookups, the flattener data is
@@ -35,6 +37,12 @@
 //
 //	tagFlattenerCursor := mc.First()
 //
+// Alternatively, flatteners can be registered under names using Named and selected
+// by their names, regardless of their order:
+//
+//	mc, err := nm.Parse(flattenhtml.Named("tags", flattenhtml.NewTagFlattener()))
+//	tagFlattenerCursor, err := mc.Cursor("tags")
+//
 // Now, you can get nodes of the same tag name using the following statement:
 //
 //	nodes := tagFlattenerCursor.SelectNodes("div")
@@ -43,6 +51,74 @@
 // the nodes that are selected by the given key. In this case, all the nodes that
 // have "div" tag name.
 //
+// # Concurrency
+//
+// A parsed document can be shared between goroutines. NodeIterator.All gives each
+// caller its own iteration state, and SelectNodes, Filter, FilterOr and FilterAnd
+// do not change the NodeIterator. Reads are safe as long as nothing is written at
+// the same time, so writes (RegisterNewNode and the mutations of Node) should be
+// guarded by MultiCursor.Update, and reads by MultiCursor.View. Alternatively, call
+// MultiCursor.Freeze once the document is ready, to turn it into an immutable
+// snapshot that can be queried without any guard. The mutations of the nodes of a
+// frozen document return ErrFrozen:
+//
+//	mc.Freeze()
+//
+//	for node := range mc.First().SelectNodes("p").All() {
+//		// ...
+//	}
+//
+// When many expensive flatteners are used, NewNodeManager and its variants accept
+// WithConcurrentFlattening to run each flattener of Parse in its own goroutine:
+//
+//	nm, err := flattenhtml.NewNodeManagerFromReader(r, flattenhtml.WithConcurrentFlattening(64))
+//
+// To process untrusted documents, ParseContext stops as soon as the context is done,
+// and the limits configured by WithMaxNodes, WithMaxDepth, WithMaxAttributes and
+// WithMaxTextBytes abort the parsing with a *LimitError that carries the location
+// of the node that exceeded the limit. The input itself can be limited using
+// WithMaxInputBytes, and NewNodeManagerFromReaderContext stops reading it as soon
+// as the context is done, so a large input is rejected before its tree is built.
+//
+// The keys of a flattener can be listed using Cursor.Keys and Cursor.Counts, and
+// MultiCursor.Stats reports the shape of the HTML tree along with the time and
+// memory spent in each flattener, if the NodeManager is created using WithParseStats.
+//
+// Many documents can be processed on a bounded pool of workers using Batch, which
+// parses each document with fresh flatteners created by FlattenerFactory values.
+//
+// A parsed NodeManager can be saved using NodeManager.SaveSnapshot and loaded back
+// using NewNodeManagerFromSnapshot, which restores the indexes of the flatteners
+// without flattening the HTML tree again. Custom flatteners can take part by
+// implementing the Serializable interface, and the flatteners that do not implement
+// it are rebuilt by flattening the loaded HTML tree.
+//
+// The forms of a document are modeled by FormFlattener.Forms. A Form reads and fills
+// the values of its controls, checks their required and pattern constraints, and
+// serializes them the same as a browser, e.g., to submit it to a test server:
+//
+//	form := forms.Form("login")
+//	err := form.Set("user", "alice")
+//	req, err := form.NewRequest(ctx, pageURL, form.DefaultSubmitter())
+//
+// The tables of a document are modeled by TableFlattener.Tables. A Table expands the
+// rowspan and colspan of its cells into a grid, with the <thead> rows first and the
+// <tfoot> rows last, and exports it using Table.Rows, Table.Maps and Table.WriteCSV.
+//
+// A Linter checks a document against a set of LintRule values while it is parsed,
+// e.g., the AccessibilityRules of NewAccessibilityLinter. The rules query the tag,
+// attribute and id indexes of the Linter, so they do not traverse the tree again:
+//
+//	linter := flattenhtml.NewAccessibilityLinter()
+//	_, err := nm.Parse(linter)
+//	for _, finding := range linter.Findings() { ... }
+//
+// Node.Role and Node.AccessibleName compute the WAI-ARIA role and accessible name of
+// an element, so the elements can be located the same as assistive technologies see
+// them, e.g., using RoleFlattener:
+//
+//	save := cursor.SelectNodes("button").Filter(flattenhtml.WithAccessibleName("Save"))
+//
 // Note that the underlying engine for parsing the HTML is [golang.org/x/net/html]
 // package and all the fact about standardizing the HTML tree applies to this package.
 //
@@ -1,5 +1,7 @@
 package flattenhtml
 
+import "strings"
+
 // WithTag is a function that filters Node based on their tag name.
 // If the node's tag name is the same is the given tag, it will be included in
 // the final output.
@@ -33,3 +35,59 @@ func WithAttributeValueAs(key, value string) FilterOption {
 		return true
 	}
 }
+
+// WithAncestor returns a FilterOption that filters nodes by the given ancestor, using
+// the numbers of the given AncestryFlattener. The Node will be included in the final
+// output if it is a descendant of the given ancestor, e.g., to keep the <a> elements
+// of the TagFlattener that are inside a <nav> element.
+func WithAncestor(ancestry *AncestryFlattener, ancestor *Node) FilterOption {
+	return func(node *Node) bool {
+		ancestry.mu.Lock()
+		defer ancestry.mu.Unlock()
+
+		ancestry.number()
+
+		return ancestry.isAncestor(ancestor.htmlNode, node.htmlNode)
+	}
+}
+
+// WithDescendant returns a FilterOption that filters nodes by the given descendant, using
+// the numbers of the given AncestryFlattener. The Node will be included in the final
+// output if it is an ancestor of the given descendant and the descendant is not removed.
+func WithDescendant(ancestry *AncestryFlattener, descendant *Node) FilterOption {
+	return func(node *Node) bool {
+		ancestry.mu.Lock()
+		defer ancestry.mu.Unlock()
+
+		ancestry.number()
+
+		return ancestry.isAncestor(node.htmlNode, descendant.htmlNode)
+	}
+}
+
+// WithDepth returns a FilterOption that filters nodes by their depth, as recorded by the
+// given AncestryFlattener. The Node will be included in the final output if it is at the
+// given depth, where the document node has a depth of zero.
+func WithDepth(ancestry *AncestryFlattener, depth int) FilterOption {
+	return func(node *Node) bool {
+		ancestry.mu.Lock()
+		defer ancestry.mu.Unlock()
+
+		ancestry.number()
+
+		nodeDepth, ok := ancestry.depth(node.htmlNode)
+
+		return ok && nodeDepth == depth
+	}
+}
+
+// WithAccessibleName returns a FilterOption that filters nodes by their accessible name.
+// The Node will be included in the final output if its Node.AccessibleName is equal to
+// the given name, after collapsing the whitespace of the given name.
+func WithAccessibleName(name string) FilterOption {
+	name = strings.TrimSpace(collapseSpaces(name))
+
+	return func(node *Node) bool {
+		return node.AccessibleName() == name
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/seinshah/flattenhtml"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 func TestWithTag(t *testing.T) {
@@ -129,3 +130,44 @@ func TestWithAttributeValueAs(t *testing.T) {
 		})
 	}
 }
+
+func TestWithAccessibleName(t *testing.T) {
+	t.Parallel()
+
+	button := &html.Node{Type: html.ElementNode, Data: "button", DataAtom: atom.Button}
+	button.AppendChild(&html.Node{Type: html.TextNode, Data: "  Save\n draft "})
+
+	sampleNode := flattenhtml.NewNode(button)
+
+	testCases := []struct {
+		name     string
+		label    string
+		expected bool
+	}{
+		{
+			name:     "different name",
+			label:    "Save",
+			expected: false,
+		},
+		{
+			name:     "same name",
+			label:    "Save draft",
+			expected: true,
+		},
+		{
+			name:     "same name with extra whitespace",
+			label:    " Save   draft",
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := flattenhtml.WithAccessibleName(tc.label)(sampleNode)
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,870 @@
+package flattenhtml
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Form is the model of a <form> element and its controls, returned by
+// FormFlattener.Forms. The values are read from and written to the HTML tree, i.e.,
+// the value, checked and selected attributes and the text of the <textarea>
+// elements, so the filled form is also visible when the NodeManager is rendered.
+type Form struct {
+	flattener *FormFlattener
+	node      *Node
+	controls  []*FormControl
+}
+
+// FormControl is an <input>, <select>, <textarea> or <button> element of a Form.
+type FormControl struct {
+	form *Form
+	node *Node
+}
+
+// FormEntry is a single name and value pair that is submitted by a Form. File is
+// true for the <input type="file"> elements, whose value is the file name.
+type FormEntry struct {
+	Name  string
+	Value string
+	File  bool
+}
+
+const (
+	// FormEncodingURLEncoded is the default encoding type of the forms.
+	FormEncodingURLEncoded = "application/x-www-form-urlencoded"
+	// FormEncodingMultipart is the encoding type of the forms that upload files.
+	FormEncodingMultipart = "multipart/form-data"
+	// FormEncodingTextPlain is the encoding type that submits one entry per line.
+	FormEncodingTextPlain = "text/plain"
+)
+
+var (
+	// ErrNoFormControl is returned when the Form has no control with the given name.
+	ErrNoFormControl = errors.New("form has no control with the given name")
+
+	// ErrInvalidFormValue is returned when the given value cannot be set to the form
+	// control, e.g., a <select> element has no option with the value.
+	ErrInvalidFormValue = errors.New("value is not valid for the form control")
+
+	// ErrValueMissing is returned by the validation when a required form control
+	// has no value.
+	ErrValueMissing = errors.New("required form control has no value")
+
+	// ErrPatternMismatch is returned by the validation when the value of a form
+	// control does not match its pattern attribute.
+	ErrPatternMismatch = errors.New("form control value does not match the pattern")
+)
+
+// Node returns the <form> element of the Form.
+func (f *Form) Node() *Node {
+	return f.node
+}
+
+// Action returns the action attribute of the Form as it is written in the HTML.
+func (f *Form) Action() string {
+	action, _ := htmlAttribute(f.node.htmlNode, "action")
+
+	return action
+}
+
+// Method returns the HTTP method of the Form, which is either GET or POST.
+func (f *Form) Method() string {
+	return formMethod(f.node.htmlNode)
+}
+
+// Enctype returns the encoding type of the Form, i.e., one of FormEncodingURLEncoded,
+// FormEncodingMultipart and FormEncodingTextPlain.
+func (f *Form) Enctype() string {
+	return formEnctype(f.node.htmlNode)
+}
+
+// Controls returns the controls of the Form in the tree order.
+func (f *Form) Controls() []*FormControl {
+	return f.controls
+}
+
+// Control returns the first control of the Form with the given name. If there is
+// no such control, it returns nil.
+func (f *Form) Control(name string) *FormControl {
+	for _, control := range f.controls {
+		if control.Name() == name {
+			return control
+		}
+	}
+
+	return nil
+}
+
+// Set fills the controls of the Form with the given name:
+//   - The checkboxes and radio buttons are checked if their value is one of the given
+//     values, and unchecked otherwise.
+//   - The options of the <select> elements are selected if their value is one of the
+//     given values, and deselected otherwise.
+//   - The rest of the controls receive the given values in order.
+//
+// If there is no control with the given name, ErrNoFormControl is returned. If any of
+// the values is not used, ErrInvalidFormValue is returned. The values are checked
+// before changing any control, so the Form is not changed if an error is returned.
+func (f *Form) Set(name string, values ...string) error {
+	if err := checkFrozen(f.node); err != nil {
+		return err
+	}
+
+	controls := slices.DeleteFunc(slices.Clone(f.controls), func(control *FormControl) bool {
+		return control.Name() != name
+	})
+
+	if len(controls) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoFormControl, name)
+	}
+
+	used := make([]bool, len(values))
+	assigned := make(map[*FormControl]string)
+	next := 0
+
+	for _, control := range controls {
+		switch control.Type() {
+		case "checkbox", "radio":
+			if index := slices.Index(values, control.Value()); index >= 0 {
+				used[index] = true
+			}
+		case "select-one", "select-multiple":
+			for i, value := range values {
+				if control.hasOption(value) {
+					used[i] = true
+				}
+			}
+		default:
+			if next < len(values) {
+				assigned[control] = values[next]
+				used[next] = true
+				next++
+			}
+		}
+	}
+
+	if index := slices.Index(used, false); index >= 0 {
+		return fmt.Errorf("%w: %q for %s", ErrInvalidFormValue, values[index], name)
+	}
+
+	for _, control := range controls {
+		switch control.Type() {
+		case "checkbox", "radio":
+			control.setChecked(slices.Contains(values, control.Value()))
+		case "select-one", "select-multiple":
+			control.selectOptions(values)
+		default:
+			if value, ok := assigned[control]; ok {
+				if err := control.SetValue(value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the required and pattern constraints of the controls of the Form.
+// It returns the errors of all invalid controls joined together.
+func (f *Form) Validate() error {
+	errs := make([]error, 0)
+
+	for _, control := range f.controls {
+		if err := control.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DefaultSubmitter returns the first submit button of the Form, which is used by the
+// browsers when the form is submitted implicitly, e.g., by pressing Enter. If the Form
+// has no submit button, it returns nil.
+func (f *Form) DefaultSubmitter() *FormControl {
+	for _, control := range f.controls {
+		if control.IsSubmitter() {
+			return control
+		}
+	}
+
+	return nil
+}
+
+// Entries returns the name and value pairs that a browser submits for the Form, in
+// the tree order of the controls. The disabled controls, the controls with no name,
+// the unchecked checkboxes and radio buttons, and the buttons other than the given
+// submitter are skipped. The submitter can be nil, e.g., to submit the form without
+// clicking a button.
+func (f *Form) Entries(submitter *FormControl) []FormEntry {
+	entries := make([]FormEntry, 0, len(f.controls))
+
+	for _, control := range f.controls {
+		entries = control.appendEntries(entries, submitter)
+	}
+
+	for i := range entries {
+		entries[i].Name = normalizeNewlines(entries[i].Name)
+		entries[i].Value = normalizeNewlines(entries[i].Value)
+	}
+
+	return entries
+}
+
+// Values returns the Entries of the Form as url.Values.
+func (f *Form) Values(submitter *FormControl) url.Values {
+	values := make(url.Values)
+
+	for _, entry := range f.Entries(submitter) {
+		values.Add(entry.Name, entry.Value)
+	}
+
+	return values
+}
+
+// Encode returns the Entries of the Form encoded as application/x-www-form-urlencoded.
+// Unlike url.Values.Encode, the entries keep the tree order of the controls.
+func (f *Form) Encode(submitter *FormControl) string {
+	pairs := make([]string, 0)
+
+	for _, entry := range f.Entries(submitter) {
+		pairs = append(pairs, url.QueryEscape(entry.Name)+"="+url.QueryEscape(entry.Value))
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// WriteMultipart writes the Entries of the Form to the given writer encoded as
+// multipart/form-data and returns the content type, including the boundary. The
+// file inputs are written as empty files with their file name.
+func (f *Form) WriteMultipart(w io.Writer, submitter *FormControl) (string, error) {
+	writer := multipart.NewWriter(w)
+
+	for _, entry := range f.Entries(submitter) {
+		var (
+			part io.Writer
+			err  error
+		)
+
+		if entry.File {
+			part, err = writer.CreateFormFile(entry.Name, entry.Value)
+		} else {
+			part, err = writer.CreateFormField(entry.Name)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if !entry.File {
+			if _, err = io.WriteString(part, entry.Value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return writer.FormDataContentType(), nil
+}
+
+// NewRequest creates the *http.Request that a browser sends to submit the Form using
+// the given submitter, which can be nil. The action of the Form is resolved against
+// the given base URL, e.g., the URL of the page. The formaction, formmethod and
+// formenctype attributes of the submitter override the attributes of the Form.
+func (f *Form) NewRequest(ctx context.Context, base string, submitter *FormControl) (*http.Request, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	action, method, enctype := f.Action(), f.Method(), f.Enctype()
+
+	if submitter != nil && submitter.IsSubmitter() {
+		if value, ok := htmlAttribute(submitter.node.htmlNode, "formaction"); ok {
+			action = value
+		}
+
+		if _, ok := htmlAttribute(submitter.node.htmlNode, "formmethod"); ok {
+			method = formMethod(submitter.node.htmlNode)
+		}
+
+		if _, ok := htmlAttribute(submitter.node.htmlNode, "formenctype"); ok {
+			enctype = formEnctype(submitter.node.htmlNode)
+		}
+	}
+
+	actionURL, err := baseURL.Parse(strings.TrimSpace(action))
+	if err != nil {
+		return nil, err
+	}
+
+	if method == http.MethodGet {
+		actionURL.RawQuery = f.Encode(submitter)
+
+		return http.NewRequestWithContext(ctx, method, actionURL.String(), nil)
+	}
+
+	body := &bytes.Buffer{}
+	contentType := enctype
+
+	switch enctype {
+	case FormEncodingMultipart:
+		if contentType, err = f.WriteMultipart(body, submitter); err != nil {
+			return nil, err
+		}
+	case FormEncodingTextPlain:
+		for _, entry := range f.Entries(submitter) {
+			body.WriteString(entry.Name + "=" + entry.Value + "\r\n")
+		}
+	default:
+		body.WriteString(f.Encode(submitter))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, actionURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	return req, nil
+}
+
+// Node returns the element of the FormControl.
+func (c *FormControl) Node() *Node {
+	return c.node
+}
+
+// Form returns the Form that owns the FormControl.
+func (c *FormControl) Form() *Form {
+	return c.form
+}
+
+// Name returns the name attribute of the FormControl.
+func (c *FormControl) Name() string {
+	name, _ := htmlAttribute(c.node.htmlNode, "name")
+
+	return name
+}
+
+// Type returns the type of the FormControl the same as the type property of the DOM,
+// e.g., text, checkbox, select-one, select-multiple, textarea or submit.
+func (c *FormControl) Type() string {
+	node := c.node.htmlNode
+	value, _ := htmlAttribute(node, "type")
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch node.DataAtom {
+	case atom.Select:
+		if _, ok := htmlAttribute(node, "multiple"); ok {
+			return "select-multiple"
+		}
+
+		return "select-one"
+	case atom.Textarea:
+		return "textarea"
+	case atom.Button:
+		if value == "reset" || value == "button" {
+			return value
+		}
+
+		return "submit"
+	}
+
+	switch value {
+	case "hidden", "text", "search", "tel", "url", "email", "password", "date", "month", "week", "time",
+		"datetime-local", "number", "range", "color", "checkbox", "radio", "file", "submit", "image",
+		"reset", "button":
+		return value
+	default:
+		return "text"
+	}
+}
+
+// Value returns the current value of the FormControl. It is the text of a <textarea>,
+// the value of the first selected option of a <select>, and the value attribute of
+// the rest of the controls. The checkboxes and radio buttons with no value attribute
+// have the "on" value.
+func (c *FormControl) Value() string {
+	if values := c.Values(); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Values returns the values of the selected options of a <select>, or the Value of
+// the rest of the controls as a single item.
+func (c *FormControl) Values() []string {
+	node := c.node.htmlNode
+
+	switch node.DataAtom {
+	case atom.Select:
+		values := make([]string, 0)
+
+		for _, option := range c.selectedOptions() {
+			values = append(values, optionValue(option))
+		}
+
+		return values
+	case atom.Textarea:
+		return []string{textContent(node)}
+	}
+
+	value, ok := htmlAttribute(node, "value")
+
+	if !ok && (c.Type() == "checkbox" || c.Type() == "radio") {
+		value = "on"
+	}
+
+	return []string{value}
+}
+
+// SetValue sets the value of the FormControl. For a <select>, the option with the
+// given value is selected, and ErrInvalidFormValue is returned if there is no such
+// option. For a <textarea>, its text is replaced. For the rest of the controls, the
+// value attribute is set.
+func (c *FormControl) SetValue(value string) error {
+	if err := checkFrozen(c.node); err != nil {
+		return err
+	}
+
+	switch c.node.htmlNode.DataAtom {
+	case atom.Select:
+		if !c.hasOption(value) {
+			return fmt.Errorf("%w: %q for %s", ErrInvalidFormValue, value, c.Name())
+		}
+
+		c.selectOptions([]string{value})
+	case atom.Textarea:
+		c.node.Empty()
+		c.node.AppendChild(NodeTypeText, value, nil)
+	default:
+		c.node.SetAttribute("value", value)
+	}
+
+	return nil
+}
+
+// Checked returns true if the FormControl has the checked attribute.
+func (c *FormControl) Checked() bool {
+	_, ok := htmlAttribute(c.node.htmlNode, "checked")
+
+	return ok
+}
+
+// SetChecked checks or unchecks a checkbox or radio button. Checking a radio button
+// unchecks the other radio buttons of the same group. For the rest of the controls,
+// ErrInvalidFormValue is returned.
+func (c *FormControl) SetChecked(checked bool) error {
+	if err := checkFrozen(c.node); err != nil {
+		return err
+	}
+
+	if c.Type() != "checkbox" && c.Type() != "radio" {
+		return fmt.Errorf("%w: %s cannot be checked", ErrInvalidFormValue, c.Type())
+	}
+
+	c.setChecked(checked)
+
+	return nil
+}
+
+// Required returns true if the FormControl has the required attribute.
+func (c *FormControl) Required() bool {
+	_, ok := htmlAttribute(c.node.htmlNode, "required")
+
+	return ok
+}
+
+// Pattern returns the pattern attribute of the FormControl.
+func (c *FormControl) Pattern() string {
+	pattern, _ := htmlAttribute(c.node.htmlNode, "pattern")
+
+	return pattern
+}
+
+// Disabled returns true if the FormControl has the disabled attribute, or it is inside
+// a disabled <fieldset> but not inside its first <legend>.
+func (c *FormControl) Disabled() bool {
+	if _, ok := htmlAttribute(c.node.htmlNode, "disabled"); ok {
+		return true
+	}
+
+	child := c.node.htmlNode
+
+	for parent := child.Parent; parent != nil; child, parent = parent, parent.Parent {
+		if parent.DataAtom != atom.Fieldset {
+			continue
+		}
+
+		if _, ok := htmlAttribute(parent, "disabled"); ok && child != firstLegend(parent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSubmitter returns true if the FormControl is a submit button, i.e., a <button>
+// of the submit type or an <input> of the submit or image type.
+func (c *FormControl) IsSubmitter() bool {
+	switch c.Type() {
+	case "submit":
+		return true
+	case "image":
+		return c.node.htmlNode.DataAtom == atom.Input
+	default:
+		return false
+	}
+}
+
+// Validate checks the required and pattern constraints of the FormControl. It returns
+// an error wrapping ErrValueMissing or ErrPatternMismatch. The disabled and read-only
+// controls, and the buttons and hidden inputs are not validated, the same as browsers.
+func (c *FormControl) Validate() error {
+	if !c.validatable() {
+		return nil
+	}
+
+	if c.Required() && c.missing() {
+		return fmt.Errorf("%w: %s", ErrValueMissing, c.Name())
+	}
+
+	pattern, ok := htmlAttribute(c.node.htmlNode, "pattern")
+	if !ok || c.node.htmlNode.DataAtom != atom.Input || c.Value() == "" {
+		return nil
+	}
+
+	switch c.Type() {
+	case "text", "search", "tel", "url", "email", "password":
+	default:
+		return nil
+	}
+
+	// An invalid pattern is ignored, the same as browsers.
+	expression, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil
+	}
+
+	if !expression.MatchString(c.Value()) {
+		return fmt.Errorf("%w: %s", ErrPatternMismatch, c.Name())
+	}
+
+	return nil
+}
+
+// validatable checks whether the constraints of the FormControl are checked.
+func (c *FormControl) validatable() bool {
+	switch c.Type() {
+	case "hidden", "submit", "image", "reset", "button":
+		return false
+	}
+
+	if _, ok := htmlAttribute(c.node.htmlNode, "readonly"); ok && c.node.htmlNode.DataAtom != atom.Select {
+		return false
+	}
+
+	return !c.Disabled()
+}
+
+// missing checks whether the FormControl has no value for the required constraint.
+func (c *FormControl) missing() bool {
+	switch c.Type() {
+	case "checkbox":
+		return !c.Checked()
+	case "radio":
+		for _, control := range c.group() {
+			if control.Checked() {
+				return false
+			}
+		}
+
+		return true
+	case "select-one", "select-multiple":
+		values := c.Values()
+
+		return len(values) == 0 || (len(values) == 1 && values[0] == "")
+	default:
+		return c.Value() == ""
+	}
+}
+
+// group returns the radio buttons of the same Form with the same name, including
+// the FormControl itself.
+func (c *FormControl) group() []*FormControl {
+	if c.form == nil || c.Name() == "" {
+		return []*FormControl{c}
+	}
+
+	group := make([]*FormControl, 0)
+
+	for _, control := range c.form.controls {
+		if control.Type() == "radio" && control.Name() == c.Name() {
+			group = append(group, control)
+		}
+	}
+
+	return group
+}
+
+// setChecked writes the checked attribute and unchecks the rest of the radio group.
+func (c *FormControl) setChecked(checked bool) {
+	if !checked {
+		c.node.RemoveAttribute("checked")
+
+		return
+	}
+
+	if c.Type() == "radio" {
+		for _, control := range c.group() {
+			if control != c {
+				control.node.RemoveAttribute("checked")
+			}
+		}
+	}
+
+	c.node.SetAttribute("checked", "")
+}
+
+// options returns the <option> elements of a <select>, including the ones inside
+// its <optgroup> elements.
+func (c *FormControl) options() []*html.Node {
+	options := make([]*html.Node, 0)
+
+	for child := c.node.htmlNode.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Option:
+			options = append(options, child)
+		case atom.Optgroup:
+			for option := child.FirstChild; option != nil; option = option.NextSibling {
+				if option.DataAtom == atom.Option {
+					options = append(options, option)
+				}
+			}
+		}
+	}
+
+	return options
+}
+
+// selectedOptions returns the selected options of a <select>. If no option of a
+// single <select> is selected, its first enabled option is selected, the same as
+// browsers.
+func (c *FormControl) selectedOptions() []*html.Node {
+	options := c.options()
+
+	selected := slices.DeleteFunc(slices.Clone(options), func(option *html.Node) bool {
+		_, ok := htmlAttribute(option, "selected")
+
+		return !ok
+	})
+
+	if c.Type() == "select-multiple" {
+		return selected
+	}
+
+	if len(selected) > 0 {
+		return selected[len(selected)-1:]
+	}
+
+	for _, option := range options {
+		if !optionDisabled(option) {
+			return []*html.Node{option}
+		}
+	}
+
+	return nil
+}
+
+// hasOption checks whether a <select> has an option with the given value.
+func (c *FormControl) hasOption(value string) bool {
+	return slices.ContainsFunc(c.options(), func(option *html.Node) bool {
+		return optionValue(option) == value
+	})
+}
+
+// selectOptions selects the options of a <select> whose value is one of the given
+// values and deselects the rest. A single <select> selects at most one option.
+func (c *FormControl) selectOptions(values []string) {
+	multiple := c.Type() == "select-multiple"
+	selected := false
+
+	for _, option := range c.options() {
+		wrapper := c.form.wrap(option)
+
+		if slices.Contains(values, optionValue(option)) && (multiple || !selected) {
+			wrapper.SetAttribute("selected", "")
+
+			selected = true
+		} else {
+			wrapper.RemoveAttribute("selected")
+		}
+	}
+}
+
+// appendEntries appends the entries of the FormControl to the given entries.
+func (c *FormControl) appendEntries(entries []FormEntry, submitter *FormControl) []FormEntry {
+	if c.Disabled() || hasAncestor(c.node.htmlNode, atom.Datalist) {
+		return entries
+	}
+
+	name, controlType := c.Name(), c.Type()
+
+	switch controlType {
+	case "submit", "image", "reset", "button":
+		if submitter == nil || submitter.node.htmlNode != c.node.htmlNode || !c.IsSubmitter() {
+			return entries
+		}
+
+		if controlType == "image" {
+			prefix := ""
+			if name != "" {
+				prefix = name + "."
+			}
+
+			return append(entries, FormEntry{Name: prefix + "x", Value: "0"}, FormEntry{Name: prefix + "y", Value: "0"})
+		}
+	case "checkbox", "radio":
+		if !c.Checked() {
+			return entries
+		}
+	}
+
+	if name == "" {
+		return entries
+	}
+
+	switch controlType {
+	case "select-one", "select-multiple":
+		for _, option := range c.selectedOptions() {
+			if !optionDisabled(option) {
+				entries = append(entries, FormEntry{Name: name, Value: optionValue(option)})
+			}
+		}
+
+		return entries
+	case "file":
+		return append(entries, FormEntry{Name: name, Value: c.Value(), File: true})
+	case "hidden":
+		if strings.EqualFold(name, "_charset_") {
+			return append(entries, FormEntry{Name: name, Value: "UTF-8"})
+		}
+	}
+
+	return append(entries, FormEntry{Name: name, Value: c.Value()})
+}
+
+// wrap returns the shared wrapper of the given node, so the attributes written by
+// the Form stay in sync with the nodes of the FormFlattener.
+func (f *Form) wrap(node *html.Node) *Node {
+	return f.flattener.wrap(node)
+}
+
+// formMethod returns the HTTP method of the method or formmethod attribute of the
+// given element.
+func formMethod(node *html.Node) string {
+	key := "method"
+	if node.DataAtom != atom.Form {
+		key = "formmethod"
+	}
+
+	value, _ := htmlAttribute(node, key)
+
+	if strings.EqualFold(strings.TrimSpace(value), "post") {
+		return http.MethodPost
+	}
+
+	return http.MethodGet
+}
+
+// formEnctype returns the encoding type of the enctype or formenctype attribute of
+// the given element.
+func formEnctype(node *html.Node) string {
+	key := "enctype"
+	if node.DataAtom != atom.Form {
+		key = "formenctype"
+	}
+
+	value, _ := htmlAttribute(node, key)
+
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case FormEncodingMultipart, FormEncodingTextPlain:
+		return value
+	default:
+		return FormEncodingURLEncoded
+	}
+}
+
+// optionValue returns the value attribute of an <option>, or its text with the
+// whitespace collapsed if it has no value attribute.
+func optionValue(option *html.Node) string {
+	if value, ok := htmlAttribute(option, "value"); ok {
+		return value
+	}
+
+	return strings.TrimSpace(collapseSpaces(textContent(option)))
+}
+
+// optionDisabled checks whether an <option> or its <optgroup> is disabled.
+func optionDisabled(option *html.Node) bool {
+	if _, ok := htmlAttribute(option, "disabled"); ok {
+		return true
+	}
+
+	if option.Parent != nil && option.Parent.DataAtom == atom.Optgroup {
+		_, ok := htmlAttribute(option.Parent, "disabled")
+
+		return ok
+	}
+
+	return false
+}
+
+// firstLegend returns the first <legend> child of the given <fieldset>, or nil.
+func firstLegend(fieldset *html.Node) *html.Node {
+	for child := fieldset.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Legend {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// hasAncestor checks whether the given node has an ancestor with the given tag.
+func hasAncestor(node *html.Node, tag atom.Atom) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.DataAtom == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeNewlines replaces every line break in the given value with CRLF, the
+// same as browsers do before submitting the forms.
+func normalizeNewlines(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+
+	return strings.ReplaceAll(value, "\n", "\r\n")
+}
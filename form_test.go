@@ -0,0 +1,233 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const formHTML = `
+<form id="signup" action="/submit?old=1" method="post">
+	<input name="user" value="bob" required pattern="[a-z]+">
+	<input name="hidden" type="hidden" value="h">
+	<input name="_charset_" type="hidden">
+	<input name="agree" type="checkbox" required>
+	<input name="color" type="radio" value="red" checked>
+	<input name="color" type="radio" value="blue">
+	<select name="size"><option value="s">Small</option><option value="m" selected>Medium</option></select>
+	<select name="tags" multiple><option selected>go</option><optgroup disabled><option selected>js</option></optgroup><option>rust</option></select>
+	<textarea name="bio">line 1
+line 2</textarea>
+	<fieldset disabled><legend><input name="legend" value="l"></legend><input name="off" value="x"></fieldset>
+	<input name="skip" value="x" disabled>
+	<input value="no name">
+	<datalist><input name="inlist" value="x"></datalist>
+	<button name="action" value="save">Save</button>
+	<button name="action" value="draft" formmethod="get" formaction="/draft">Draft</button>
+	<input type="image" name="pos">
+	<button type="reset" name="reset">Reset</button>
+</form>
+<input name="extra" value="e" form="signup">`
+
+func parseForm(t *testing.T) *flattenhtml.Form {
+	t.Helper()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(formHTML))
+	require.NoError(t, err)
+
+	forms := flattenhtml.NewFormFlattener()
+
+	_, err = nm.Parse(forms)
+	require.NoError(t, err)
+
+	form := forms.Form("signup")
+	require.NotNil(t, form)
+
+	return form
+}
+
+func TestForm_Read(t *testing.T) {
+	t.Parallel()
+
+	form := parseForm(t)
+
+	require.Equal(t, http.MethodPost, form.Method())
+	require.Equal(t, flattenhtml.FormEncodingURLEncoded, form.Enctype())
+	require.Equal(t, "/submit?old=1", form.Action())
+
+	user := form.Control("user")
+	require.Equal(t, "text", user.Type())
+	require.Equal(t, "bob", user.Value())
+	require.True(t, user.Required())
+	require.Equal(t, "[a-z]+", user.Pattern())
+	require.Same(t, form, user.Form())
+
+	require.Equal(t, "on", form.Control("agree").Value())
+	require.False(t, form.Control("agree").Checked())
+	require.True(t, form.Control("color").Checked())
+	require.Equal(t, "select-one", form.Control("size").Type())
+	require.Equal(t, "m", form.Control("size").Value())
+	require.Equal(t, []string{"go", "js"}, form.Control("tags").Values())
+	require.Equal(t, "line 1\nline 2", form.Control("bio").Value())
+	require.True(t, form.Control("off").Disabled())
+	require.False(t, form.Control("legend").Disabled())
+	require.Equal(t, "submit", form.Control("action").Type())
+	require.Equal(t, "save", form.DefaultSubmitter().Value())
+	require.Equal(t, "e", form.Control("extra").Value())
+
+	entries := form.Entries(nil)
+	require.Equal(t, []flattenhtml.FormEntry{
+		{Name: "user", Value: "bob"},
+		{Name: "hidden", Value: "h"},
+		{Name: "_charset_", Value: "UTF-8"},
+		{Name: "color", Value: "red"},
+		{Name: "size", Value: "m"},
+		{Name: "tags", Value: "go"},
+		{Name: "bio", Value: "line 1\r\nline 2"},
+		{Name: "legend", Value: "l"},
+		{Name: "extra", Value: "e"},
+	}, entries)
+
+	image := form.Controls()[len(form.Controls())-3]
+	require.True(t, image.IsSubmitter())
+
+	values := form.Values(image)
+	require.Equal(t, "0", values.Get("pos.x"))
+	require.Equal(t, "0", values.Get("pos.y"))
+	require.Empty(t, form.Values(form.Control("reset")).Get("reset"))
+}
+
+func TestForm_Fill(t *testing.T) {
+	t.Parallel()
+
+	form := parseForm(t)
+
+	require.NoError(t, form.Set("user", "alice"))
+	require.NoError(t, form.Set("agree", "on"))
+	require.NoError(t, form.Set("color", "blue"))
+	require.NoError(t, form.Set("size", "s"))
+	require.NoError(t, form.Set("tags", "rust", "go"))
+	require.NoError(t, form.Set("bio", "hello"))
+
+	require.ErrorIs(t, form.Set("missing", "x"), flattenhtml.ErrNoFormControl)
+	require.ErrorIs(t, form.Set("size", "xl"), flattenhtml.ErrInvalidFormValue)
+	require.ErrorIs(t, form.Set("user", "a", "b"), flattenhtml.ErrInvalidFormValue)
+	require.ErrorIs(t, form.Control("user").SetChecked(true), flattenhtml.ErrInvalidFormValue)
+
+	// The failed calls do not change the Form.
+	values := form.Values(nil)
+	require.Equal(t, "alice", values.Get("user"))
+	require.Equal(t, "on", values.Get("agree"))
+	require.Equal(t, []string{"blue"}, values["color"])
+	require.Equal(t, "s", values.Get("size"))
+	require.Equal(t, []string{"go", "rust"}, values["tags"])
+	require.Equal(t, "hello", values.Get("bio"))
+
+	// The filled values are written to the HTML tree.
+	rendered := &bytes.Buffer{}
+	require.NoError(t, html.Render(rendered, form.Node().HTMLNode()))
+	require.Contains(t, rendered.String(), `value="blue" checked=""`)
+	require.Contains(t, rendered.String(), `<textarea name="bio">hello</textarea>`)
+}
+
+func TestForm_Validate(t *testing.T) {
+	t.Parallel()
+
+	form := parseForm(t)
+
+	err := form.Validate()
+	require.ErrorIs(t, err, flattenhtml.ErrValueMissing)
+	require.NotErrorIs(t, err, flattenhtml.ErrPatternMismatch)
+
+	require.NoError(t, form.Control("agree").SetChecked(true))
+	require.NoError(t, form.Validate())
+
+	require.NoError(t, form.Control("user").SetValue("Bob1"))
+	require.ErrorIs(t, form.Validate(), flattenhtml.ErrPatternMismatch)
+
+	require.NoError(t, form.Control("user").SetValue(""))
+
+	err = form.Control("user").Validate()
+	require.ErrorIs(t, err, flattenhtml.ErrValueMissing)
+	require.True(t, errors.Is(form.Validate(), flattenhtml.ErrValueMissing))
+}
+
+func TestForm_NewRequest(t *testing.T) {
+	t.Parallel()
+
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		requests <- r
+		bodies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+
+	form := parseForm(t)
+	ctx := context.Background()
+
+	submit := func(req *http.Request) (*http.Request, string) {
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		return <-requests, <-bodies
+	}
+
+	req, err := form.NewRequest(ctx, server.URL+"/page", form.DefaultSubmitter())
+	require.NoError(t, err)
+
+	received, body := submit(req)
+	require.Equal(t, http.MethodPost, received.Method)
+	require.Equal(t, "/submit", received.URL.Path)
+	require.Equal(t, "old=1", received.URL.RawQuery)
+	require.Equal(t, flattenhtml.FormEncodingURLEncoded, received.Header.Get("Content-Type"))
+	require.Equal(t, "user=bob&hidden=h&_charset_=UTF-8&color=red&size=m&tags=go&bio=line+1%0D%0Aline+2&"+
+		"legend=l&action=save&extra=e", body)
+
+	draft := form.Controls()[len(form.Controls())-4]
+
+	req, err = form.NewRequest(ctx, server.URL+"/page", draft)
+	require.NoError(t, err)
+
+	received, _ = submit(req)
+	require.Equal(t, http.MethodGet, received.Method)
+	require.Equal(t, "/draft", received.URL.Path)
+
+	query, err := url.ParseQuery(received.URL.RawQuery)
+	require.NoError(t, err)
+	require.Equal(t, "draft", query.Get("action"))
+
+	form.Node().SetAttribute("enctype", "multipart/form-data")
+
+	req, err = form.NewRequest(ctx, server.URL, nil)
+	require.NoError(t, err)
+
+	received, body = submit(req)
+
+	mediaType, params, err := mime.ParseMediaType(received.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, flattenhtml.FormEncodingMultipart, mediaType)
+
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	parsed, err := reader.ReadForm(1 << 20)
+	require.NoError(t, err)
+	require.Equal(t, []string{"bob"}, parsed.Value["user"])
+	require.Equal(t, []string{"line 1\r\nline 2"}, parsed.Value["bio"])
+	require.NotContains(t, parsed.Value, "action")
+}
@@ -0,0 +1,285 @@
+package flattenhtml
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// FormFlattener is a Flattener that flattens the <form> elements by their id and
+// name attributes, and collects the form controls (i.e., <input>, <select>,
+// <textarea> and <button>) of the HTML tree. Forms returns the Form model of each
+// form, which reads and fills the values of its controls and serializes them the
+// same as a browser would submit the form.
+type FormFlattener struct {
+	treeBinding
+
+	flattened map[string]*NodeIterator
+	forms     []*Node
+	controls  []*Node
+	// nodes holds the wrapper of each flattened node, so the forms, controls and
+	// options share the same Node values between the calls to Forms.
+	nodes map[*html.Node]*Node
+	// last is the last flattened node, used to detect whether the nodes are
+	// flattened in the tree order.
+	last *html.Node
+	// sorted is false if a node is flattened out of the tree order, e.g., using
+	// MultiCursor.RegisterNewNode, so the controls are sorted before being used.
+	sorted bool
+	order  treeOrder
+}
+
+var (
+	_ Flattener        = (*FormFlattener)(nil)
+	_ FlattenerFactory = (*FormFlattener)(nil)
+	_ KeyLister        = (*FormFlattener)(nil)
+)
+
+// NewFormFlattener creates a new FormFlattener.
+func NewFormFlattener() *FormFlattener {
+	return &FormFlattener{
+		flattened: make(map[string]*NodeIterator),
+		forms:     make([]*Node, 0),
+		controls:  make([]*Node, 0),
+		nodes:     make(map[*html.Node]*Node),
+		sorted:    true,
+		order:     make(treeOrder),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It indexes the <form> elements by their id and name and
+// collects the form controls and the <option> elements. This method does not
+// return an error.
+func (f *FormFlattener) Flatten(node *html.Node) error {
+	if f.last != nil && node != nextInTreeOrder(f.last) {
+		f.sorted = false
+	}
+
+	f.last = node
+	f.order.addRoot(node)
+
+	if node.Type != html.ElementNode || f.nodes[node] != nil {
+		return nil
+	}
+
+	switch node.DataAtom {
+	case atom.Form:
+		wrapper := f.wrap(node)
+		f.forms = append(f.forms, wrapper)
+
+		for _, key := range []string{"id", "name"} {
+			if value, ok := htmlAttribute(node, key); ok && value != "" {
+				if _, ok := f.flattened[value]; !ok {
+					f.flattened[value] = NewNodeIterator()
+				}
+
+				f.flattened[value].Add(wrapper)
+			}
+		}
+	case atom.Input, atom.Select, atom.Textarea, atom.Button:
+		f.controls = append(f.controls, f.wrap(node))
+	case atom.Option:
+		f.wrap(node)
+	}
+
+	return nil
+}
+
+// GetNodesByKey returns the <form> elements with the given id or name.
+func (f *FormFlattener) GetNodesByKey(key string) *NodeIterator {
+	return f.flattened[key]
+}
+
+func (f *FormFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*FormFlattener)
+
+	return ok
+}
+
+// Len for FormFlattener gives you the number of distinct form ids and names in the HTML tree.
+func (f *FormFlattener) Len() int {
+	return len(f.flattened)
+}
+
+// Keys returns the keys of the FormFlattener in the sorted order.
+func (f *FormFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(f.flattened)
+}
+
+// NewFlattener returns a new and empty FormFlattener.
+func (f *FormFlattener) NewFlattener() Flattener {
+	return NewFormFlattener()
+}
+
+// Forms returns the Form model of the flattened forms in the tree order. Each form
+// owns the controls that are inside it, and the controls that refer to its id
+// using the form attribute. The removed forms and controls are skipped.
+func (f *FormFlattener) Forms() []*Form {
+	if !f.sorted {
+		slices.SortStableFunc(f.forms, f.order.compare)
+		slices.SortStableFunc(f.controls, f.order.compare)
+
+		f.sorted = true
+	}
+
+	forms := make([]*Form, 0, len(f.forms))
+	owners := make(map[*html.Node]*Form, len(f.forms))
+	ids := make(map[string]*Form)
+
+	for _, node := range f.forms {
+		if node.IsRemoved() {
+			continue
+		}
+
+		form := &Form{flattener: f, node: node, controls: make([]*FormControl, 0)}
+		forms = append(forms, form)
+		owners[node.htmlNode] = form
+
+		if id, ok := htmlAttribute(node.htmlNode, "id"); ok {
+			if _, exists := ids[id]; !exists {
+				ids[id] = form
+			}
+		}
+	}
+
+	for _, node := range f.controls {
+		if node.IsRemoved() {
+			continue
+		}
+
+		var owner *Form
+
+		if id, ok := htmlAttribute(node.htmlNode, "form"); ok {
+			owner = ids[id]
+		} else {
+			for parent := node.htmlNode.Parent; parent != nil && owner == nil; parent = parent.Parent {
+				owner = owners[parent]
+			}
+		}
+
+		if owner != nil {
+			owner.controls = append(owner.controls, &FormControl{form: owner, node: node})
+		}
+	}
+
+	return forms
+}
+
+// Form returns the Form model of the first form with the given id or name. If there
+// is no such form, it returns nil.
+func (f *FormFlattener) Form(key string) *Form {
+	nodes := f.flattened[key]
+	if nodes == nil {
+		return nil
+	}
+
+	first := nodes.First()
+	if first == nil {
+		return nil
+	}
+
+	for _, form := range f.Forms() {
+		if form.node.htmlNode == first.htmlNode {
+			return form
+		}
+	}
+
+	return nil
+}
+
+// wrap returns the wrapper of the given node, creating and recording it if needed.
+func (f *FormFlattener) wrap(node *html.Node) *Node {
+	wrapper, ok := f.nodes[node]
+	if !ok {
+		wrapper = f.newNode(node)
+		f.nodes[node] = wrapper
+	}
+
+	return wrapper
+}
+
+// nextInTreeOrder returns the node that comes after the given node in the tree
+// order, i.e., the order that NodeManager.Parse visits the nodes.
+func nextInTreeOrder(node *html.Node) *html.Node {
+	if node.FirstChild != nil {
+		return node.FirstChild
+	}
+
+	for ; node != nil; node = node.Parent {
+		if node.NextSibling != nil {
+			return node.NextSibling
+		}
+	}
+
+	return nil
+}
+
+// treeOrder orders the nodes of multiple trees. It holds the rank of each root, in
+// the order the roots are flattened, and the roots that are not flattened, e.g., of
+// the detached trees, are ranked once they are first compared.
+type treeOrder map[*html.Node]int
+
+// addRoot ranks the given node after the known roots, if it is a root that is not
+// ranked yet.
+func (o treeOrder) addRoot(node *html.Node) {
+	if node.Parent != nil {
+		return
+	}
+
+	if _, ok := o[node]; !ok {
+		o[node] = len(o)
+	}
+}
+
+// compare compares the position of the given nodes in the tree order. The nodes of
+// different trees are ordered by the rank of their roots.
+func (o treeOrder) compare(a, b *Node) int {
+	aAncestors, bAncestors := ancestorChain(a.htmlNode), ancestorChain(b.htmlNode)
+
+	if aAncestors[0] != bAncestors[0] {
+		o.addRoot(aAncestors[0])
+		o.addRoot(bAncestors[0])
+
+		return cmp.Compare(o[aAncestors[0]], o[bAncestors[0]])
+	}
+
+	i := 0
+	for i < len(aAncestors) && i < len(bAncestors) && aAncestors[i] == bAncestors[i] {
+		i++
+	}
+
+	switch {
+	case i == len(aAncestors) && i == len(bAncestors):
+		return 0
+	case i == len(aAncestors):
+		return -1
+	case i == len(bAncestors):
+		return 1
+	}
+
+	for sibling := aAncestors[i].NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == bAncestors[i] {
+			return -1
+		}
+	}
+
+	return 1
+}
+
+// ancestorChain returns the ancestors of the given node from the top-most one down
+// to the node itself.
+func ancestorChain(node *html.Node) []*html.Node {
+	chain := make([]*html.Node, 0)
+
+	for ; node != nil; node = node.Parent {
+		chain = append(chain, node)
+	}
+
+	slices.Reverse(chain)
+
+	return chain
+}
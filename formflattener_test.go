@@ -0,0 +1,110 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestFormFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`
+		<form id="login" name="auth"><input name="user"><button>Go</button></form>
+		<input name="remember" type="checkbox" form="login">
+		<input name="orphan">
+		<form id="search"><select name="q"><option>a</option></select></form>
+		<input name="elsewhere" form="missing">`,
+	))
+	require.NoError(t, err)
+
+	forms := flattenhtml.NewFormFlattener()
+
+	mc, err := nm.Parse(forms)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.FormFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth", "login", "search"}, slices.Collect(cursor.Keys()))
+	require.Same(t, cursor.SelectNodes("auth").First(), cursor.SelectNodes("login").First())
+
+	all := forms.Forms()
+	require.Len(t, all, 2)
+
+	login := forms.Form("auth")
+	require.NotNil(t, login)
+	require.Same(t, all[0].Node(), login.Node())
+
+	names := make([]string, 0)
+	for _, control := range login.Controls() {
+		names = append(names, control.Name())
+	}
+
+	require.Equal(t, []string{"user", "", "remember"}, names)
+	require.Len(t, forms.Form("search").Controls(), 1)
+	require.Nil(t, forms.Form("missing"))
+
+	// Controls that are registered later keep the tree order.
+	user := login.Control("user").Node()
+	added, err := user.AppendSibling(flattenhtml.NodeTypeElement, "input", map[string]string{"name": "pass"})
+	require.NoError(t, err)
+	require.NoError(t, mc.RegisterNewNode(added))
+
+	names = names[:0]
+	for _, control := range forms.Form("login").Controls() {
+		names = append(names, control.Name())
+	}
+
+	require.Equal(t, []string{"user", "pass", "", "remember"}, names)
+
+	// Removed controls are not a part of the form anymore.
+	require.NoError(t, added.Remove())
+	require.Len(t, forms.Form("login").Controls(), 3)
+
+	fresh, ok := forms.NewFlattener().(*flattenhtml.FormFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(forms))
+	require.False(t, fresh.IsMyType(flattenhtml.NewTagFlattener()))
+}
+
+func TestFormFlattener_MultipleTrees(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<form id="first"></form>`))
+	require.NoError(t, err)
+
+	forms := flattenhtml.NewFormFlattener()
+
+	mc, err := nm.Parse(forms)
+	require.NoError(t, err)
+
+	// The form of a detached tree is ordered after the forms of the document.
+	detached := flattenhtml.NewNode(&html.Node{
+		Type:     html.ElementNode,
+		Data:     "form",
+		DataAtom: atom.Form,
+		Attr:     []html.Attribute{{Key: "id", Val: "detached"}},
+	})
+	require.NoError(t, mc.RegisterNewNode(detached))
+
+	added, err := forms.Form("first").Node().AppendSibling(
+		flattenhtml.NodeTypeElement, "form", map[string]string{"id": "second"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, mc.RegisterNewNode(added))
+
+	ids := make([]string, 0)
+
+	for _, form := range forms.Forms() {
+		id, _ := form.Node().Attribute("id")
+		ids = append(ids, id)
+	}
+
+	require.Equal(t, []string{"first", "second", "detached"}, ids)
+}
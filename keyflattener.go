@@ -0,0 +1,205 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+
+	"golang.org/x/net/html"
+)
+
+// KeyFunc returns the keys that the given element should be flattened by.
+// Returning no keys leaves the element out of the index.
+type KeyFunc func(node *Node) []string
+
+// KeyFlattener is a generic Flattener that flattens the elements of the HTML tree
+// by the keys returned from a KeyFunc. It takes care of the boilerplate of the
+// flatteners, so a new index only needs a name and a KeyFunc:
+//
+//	roles := flattenhtml.NewKeyFlattener("roles", func(node *flattenhtml.Node) []string {
+//		role, _ := node.Attribute("role")
+//
+//		return strings.Fields(role)
+//	})
+//
+// An element can have multiple keys and it is added once to each of them, even if
+// the KeyFunc returns a key more than once or the element is flattened again.
+// Since KeyFlattener values are only of the same type if they have the same name,
+// multiple KeyFlattener values can be used together and each of them can be
+// selected using MultiCursor.SelectKeyCursor or SelectFlattenerOf.
+type KeyFlattener struct {
+	treeBinding
+
+	name      string
+	fn        KeyFunc
+	flattened map[string]*NodeIterator
+	// nodes holds the flattened elements along with their keys.
+	nodes map[*html.Node]*keyedNode
+}
+
+// keyedNode is an element flattened by the KeyFlattener along with its keys.
+type keyedNode struct {
+	node *Node
+	keys []string
+}
+
+var (
+	_ Flattener        = (*KeyFlattener)(nil)
+	_ FlattenerFactory = (*KeyFlattener)(nil)
+	_ KeyLister        = (*KeyFlattener)(nil)
+	_ Serializable     = (*KeyFlattener)(nil)
+)
+
+// NewKeyFlattener creates a new KeyFlattener with the given name and KeyFunc.
+func NewKeyFlattener(name string, fn KeyFunc) *KeyFlattener {
+	return &KeyFlattener{
+		name:      name,
+		fn:        fn,
+		flattened: make(map[string]*NodeIterator),
+		nodes:     make(map[*html.Node]*keyedNode),
+	}
+}
+
+// Name returns the name of the KeyFlattener.
+func (k *KeyFlattener) Name() string {
+	return k.name
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It categorizes the elements by the keys returned from
+// the KeyFunc. This method does not return an error.
+func (k *KeyFlattener) Flatten(node *html.Node) error {
+	k.reindex(node)
+
+	return nil
+}
+
+// Reindex calls the KeyFunc for the given node again and updates the index, so the
+// node is added to its new keys and removed from the keys it no longer has. It is
+// useful after changing the node in a way that affects its keys, e.g., by changing
+// its attributes.
+func (k *KeyFlattener) Reindex(node *Node) {
+	k.reindex(node.htmlNode)
+}
+
+func (k *KeyFlattener) GetNodesByKey(key string) *NodeIterator {
+	return k.flattened[key]
+}
+
+// IsMyType checks whether the given flattener is a KeyFlattener with the same name.
+func (k *KeyFlattener) IsMyType(flattener Flattener) bool {
+	other, ok := flattener.(*KeyFlattener)
+
+	return ok && other.name == k.name
+}
+
+// Len for KeyFlattener gives you the number of distinct keys in the HTML tree.
+func (k *KeyFlattener) Len() int {
+	return len(k.flattened)
+}
+
+// Keys returns the keys of the KeyFlattener in the sorted order.
+func (k *KeyFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(k.flattened)
+}
+
+// NewFlattener returns a new and empty KeyFlattener with the same name and KeyFunc.
+func (k *KeyFlattener) NewFlattener() Flattener {
+	return NewKeyFlattener(k.name, k.fn)
+}
+
+// Index returns the flattened elements of the KeyFlattener grouped by their keys.
+func (k *KeyFlattener) Index() map[string]*NodeIterator {
+	return k.flattened
+}
+
+// RestoreIndex replaces the flattened elements of the KeyFlattener with the given index.
+// This method does not return an error.
+func (k *KeyFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	k.flattened = index
+	k.nodes = keyedNodes(index)
+
+	return nil
+}
+
+// reindex flattens the element by the keys returned from the KeyFunc.
+func (k *KeyFlattener) reindex(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	fresh := k.newNode(node)
+
+	keyed, ok := k.nodes[node]
+	if !ok {
+		keyed = &keyedNode{node: fresh}
+	}
+
+	// The KeyFunc receives a fresh Node, since the attributes of the indexed Node
+	// might be changed using another Node of the same element.
+	k.index(keyed, k.fn(fresh))
+}
+
+// index replaces the keys of the flattened element with the given keys.
+func (k *KeyFlattener) index(keyed *keyedNode, keys []string) {
+	unique := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if !slices.Contains(unique, key) {
+			unique = append(unique, key)
+		}
+	}
+
+	for _, key := range keyed.keys {
+		if slices.Contains(unique, key) {
+			continue
+		}
+
+		nodes := k.flattened[key]
+		nodes.nodes = slices.DeleteFunc(nodes.nodes, func(node *Node) bool {
+			return node == keyed.node
+		})
+
+		if len(nodes.nodes) == 0 {
+			delete(k.flattened, key)
+		}
+	}
+
+	for _, key := range unique {
+		if slices.Contains(keyed.keys, key) {
+			continue
+		}
+
+		if _, ok := k.flattened[key]; !ok {
+			k.flattened[key] = NewNodeIterator()
+		}
+
+		k.flattened[key].Add(keyed.node)
+	}
+
+	keyed.keys = unique
+
+	if len(unique) == 0 {
+		delete(k.nodes, keyed.node.htmlNode)
+	} else {
+		k.nodes[keyed.node.htmlNode] = keyed
+	}
+}
+
+// keyedNodes returns the elements of the given index along with their keys.
+func keyedNodes(index map[string]*NodeIterator) map[*html.Node]*keyedNode {
+	nodes := make(map[*html.Node]*keyedNode)
+
+	for key := range sortedKeys(index) {
+		for _, node := range index[key].nodes {
+			keyed, ok := nodes[node.htmlNode]
+			if !ok {
+				keyed = &keyedNode{node: node}
+				nodes[node.htmlNode] = keyed
+			}
+
+			keyed.keys = append(keyed.keys, key)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,71 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<nav role="navigation"></nav><div role="button tab button"></div><p lang="en">a</p><span lang="fr">b</span>`,
+	))
+	require.NoError(t, err)
+
+	roles := flattenhtml.NewKeyFlattener("roles", func(node *flattenhtml.Node) []string {
+		role, _ := node.Attribute("role")
+
+		return strings.Fields(role)
+	})
+
+	languages := flattenhtml.NewKeyFlattener("languages", func(node *flattenhtml.Node) []string {
+		if lang, ok := node.Attribute("lang"); ok {
+			return []string{lang}
+		}
+
+		return nil
+	})
+
+	mc, err := nm.Parse(roles, languages)
+	require.NoError(t, err)
+
+	roleCursor, err := mc.SelectKeyCursor("roles")
+	require.NoError(t, err)
+	require.Equal(t, 3, roleCursor.Len())
+	require.Equal(t, 1, roleCursor.SelectNodes("button").Len())
+	require.Equal(t, "div", roleCursor.SelectNodes("tab").First().TagName())
+
+	languageCursor, err := mc.SelectCursor(flattenhtml.NewKeyFlattener("languages", nil))
+	require.NoError(t, err)
+	require.Equal(t, "span", languageCursor.SelectNodes("fr").First().TagName())
+
+	selected, err := flattenhtml.SelectFlattenerOf(mc, func(k *flattenhtml.KeyFlattener) bool {
+		return k.Name() == "languages"
+	})
+	require.NoError(t, err)
+	require.Same(t, languages, selected)
+
+	// Flattening the same element again does not duplicate it.
+	div := roleCursor.SelectNodes("tab").First()
+	require.NoError(t, mc.RegisterNewNode(div))
+	require.Equal(t, 1, roleCursor.SelectNodes("button").Len())
+
+	// Reindex moves the element to its new keys.
+	div.SetAttribute("role", "dialog")
+	roles.Reindex(div)
+	require.Equal(t, 0, roleCursor.SelectNodes("button").Len())
+	require.Equal(t, 0, roleCursor.SelectNodes("tab").Len())
+	require.Same(t, div.HTMLNode(), roleCursor.SelectNodes("dialog").First().HTMLNode())
+	require.Equal(t, 2, roleCursor.Len())
+
+	clone, ok := roles.NewFlattener().(*flattenhtml.KeyFlattener)
+	require.True(t, ok)
+	require.Equal(t, "roles", clone.Name())
+	require.Equal(t, 0, clone.Len())
+	require.True(t, clone.IsMyType(roles))
+	require.False(t, clone.IsMyType(languages))
+}
@@ -0,0 +1,230 @@
+package flattenhtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+)
+
+// Limit describes which of the parsing limits of the NodeManager is exceeded.
+type Limit int
+
+const (
+	// LimitNodes is the maximum number of nodes in the HTML tree.
+	LimitNodes Limit = iota + 1
+	// LimitDepth is the maximum depth of the HTML tree. The document node has a
+	// depth of zero and the <html> element has a depth of one.
+	LimitDepth
+	// LimitAttributes is the maximum number of attributes of a single element.
+	LimitAttributes
+	// LimitTextBytes is the maximum number of bytes of all the text nodes in
+	// the HTML tree together.
+	LimitTextBytes
+	// LimitInputBytes is the maximum number of bytes that are read from the input
+	// of NewNodeManagerFromReader and NewNodeManagerFromURL.
+	LimitInputBytes
+)
+
+// ErrLimitExceeded is returned, wrapped in a *LimitError, when the HTML tree
+// exceeds one of the limits configured for the NodeManager.
+var ErrLimitExceeded = errors.New("parse limit exceeded")
+
+// LimitError is returned by NodeManager.Parse and NodeManager.ParseContext when
+// the HTML tree exceeds one of the configured limits. It carries the position
+// that the traversal has reached when the limit is exceeded.
+// It is also returned by NewNodeManagerFromReader when the input exceeds the limit
+// of WithMaxInputBytes, before the HTML tree is built. In this case, only Limit and
+// Max are set.
+type LimitError struct {
+	// Limit is the exceeded limit.
+	Limit Limit
+	// Max is the configured value of the exceeded limit.
+	Max int
+	// Location is the XPath-like location of the node that exceeded the limit,
+	// e.g., /html[1]/body[1]/div[3].
+	Location string
+	// Depth is the depth of the node that exceeded the limit.
+	Depth int
+	// Nodes is the number of nodes that were visited, including the node that
+	// exceeded the limit.
+	Nodes int
+}
+
+// String returns a human-readable name of the Limit.
+func (l Limit) String() string {
+	switch l {
+	case LimitNodes:
+		return "nodes"
+	case LimitDepth:
+		return "depth"
+	case LimitAttributes:
+		return "attributes"
+	case LimitTextBytes:
+		return "text bytes"
+	case LimitInputBytes:
+		return "input bytes"
+	default:
+		return "unknown"
+	}
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	if e.Limit == LimitInputBytes {
+		return fmt.Sprintf("%s: maximum %s of %d", ErrLimitExceeded, e.Limit, e.Max)
+	}
+
+	return fmt.Sprintf("%s: maximum %s of %d at %s (depth %d, %d nodes visited)",
+		ErrLimitExceeded, e.Limit, e.Max, e.Location, e.Depth, e.Nodes)
+}
+
+// Unwrap allows errors.Is to match the LimitError with ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// WithMaxNodes limits the number of nodes in the HTML tree that Parse traverses.
+// A zero value means no limit.
+func WithMaxNodes(maxNodes int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.maxNodes = max(maxNodes, 0)
+	}
+}
+
+// WithMaxDepth limits the depth of the HTML tree that Parse traverses.
+// A zero value means no limit.
+func WithMaxDepth(maxDepth int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.maxDepth = max(maxDepth, 0)
+	}
+}
+
+// WithMaxAttributes limits the number of attributes of each element that Parse
+// traverses. A zero value means no limit.
+func WithMaxAttributes(maxAttributes int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.maxAttributes = max(maxAttributes, 0)
+	}
+}
+
+// WithMaxTextBytes limits the total number of bytes of the text nodes that Parse
+// traverses. A zero value means no limit.
+func WithMaxTextBytes(maxTextBytes int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.maxTextBytes = max(maxTextBytes, 0)
+	}
+}
+
+// WithMaxInputBytes limits the number of bytes that NewNodeManagerFromReader and
+// NewNodeManagerFromURL read from the input. The reading stops as soon as the input
+// exceeds the limit, so the HTML tree of a large input is never built.
+// A zero value means no limit.
+func WithMaxInputBytes(maxInputBytes int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.maxInputBytes = max(maxInputBytes, 0)
+	}
+}
+
+// inputReader reads the input of the HTML tree, checking the context and the
+// configured limit of the input bytes before each read.
+type inputReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	maxBytes int
+	read     int
+}
+
+// Read implements the io.Reader interface. It returns the error of the context once
+// the context is done and a *LimitError once the input exceeds the limit.
+func (r *inputReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// A single byte more than the limit is enough to find out that it is exceeded.
+	if r.maxBytes > 0 && len(p) > r.maxBytes-r.read+1 {
+		p = p[:r.maxBytes-r.read+1]
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += n
+
+	if r.maxBytes > 0 && r.read > r.maxBytes {
+		return n, &LimitError{Limit: LimitInputBytes, Max: r.maxBytes}
+	}
+
+	return n, err
+}
+
+// treeWalker traverses the HTML tree for Parse, checking the context and the
+// configured limits before visiting each node.
+type treeWalker struct {
+	ctx       context.Context
+	config    nodeManagerConfig
+	visit     func(node *html.Node) error
+	stats     *parseStats
+	nodes     int
+	textBytes int
+}
+
+// walk visits the node, its descendants and its next siblings in depth-first order.
+func (w *treeWalker) walk(node *html.Node, depth int) error {
+	for ; node != nil; node = node.NextSibling {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := w.check(node, depth); err != nil {
+			return err
+		}
+
+		if w.stats != nil {
+			w.stats.observe(node, depth)
+		}
+
+		if err := w.visit(node); err != nil {
+			return err
+		}
+
+		if err := w.walk(node.FirstChild, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// check counts the node and returns a *LimitError if it exceeds any of the limits.
+func (w *treeWalker) check(node *html.Node, depth int) error {
+	w.nodes++
+
+	if node.Type == html.TextNode {
+		w.textBytes += len(node.Data)
+	}
+
+	limit, maxValue := Limit(0), 0
+
+	switch {
+	case w.config.maxNodes > 0 && w.nodes > w.config.maxNodes:
+		limit, maxValue = LimitNodes, w.config.maxNodes
+	case w.config.maxDepth > 0 && depth > w.config.maxDepth:
+		limit, maxValue = LimitDepth, w.config.maxDepth
+	case w.config.maxAttributes > 0 && len(node.Attr) > w.config.maxAttributes:
+		limit, maxValue = LimitAttributes, w.config.maxAttributes
+	case w.config.maxTextBytes > 0 && w.textBytes > w.config.maxTextBytes:
+		limit, maxValue = LimitTextBytes, w.config.maxTextBytes
+	default:
+		return nil
+	}
+
+	return &LimitError{
+		Limit:    limit,
+		Max:      maxValue,
+		Location: nodeLocation(node),
+		Depth:    depth,
+		Nodes:    w.nodes,
+	}
+}
@@ -0,0 +1,153 @@
+package flattenhtml_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeManager_ParseLimits(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := `<html><head></head><body><div id="a" class="b"><p>hello</p><p>world</p></div></body></html>`
+
+	testCases := []struct {
+		name     string
+		options  []flattenhtml.NodeManagerOption
+		expected *flattenhtml.LimitError
+	}{
+		{
+			name:    "within limits",
+			options: []flattenhtml.NodeManagerOption{flattenhtml.WithMaxNodes(9), flattenhtml.WithMaxDepth(5)},
+		},
+		{
+			name:    "max nodes",
+			options: []flattenhtml.NodeManagerOption{flattenhtml.WithMaxNodes(4)},
+			expected: &flattenhtml.LimitError{
+				Limit: flattenhtml.LimitNodes, Max: 4, Location: "/html[1]/body[1]/div[1]", Depth: 3, Nodes: 5,
+			},
+		},
+		{
+			name:    "max depth",
+			options: []flattenhtml.NodeManagerOption{flattenhtml.WithMaxDepth(4)},
+			expected: &flattenhtml.LimitError{
+				Limit: flattenhtml.LimitDepth, Max: 4, Location: "/html[1]/body[1]/div[1]/p[1]/text()[1]",
+				Depth: 5, Nodes: 7,
+			},
+		},
+		{
+			name:    "max attributes",
+			options: []flattenhtml.NodeManagerOption{flattenhtml.WithMaxAttributes(1)},
+			expected: &flattenhtml.LimitError{
+				Limit: flattenhtml.LimitAttributes, Max: 1, Location: "/html[1]/body[1]/div[1]", Depth: 3, Nodes: 5,
+			},
+		},
+		{
+			name:    "max text bytes",
+			options: []flattenhtml.NodeManagerOption{flattenhtml.WithMaxTextBytes(8)},
+			expected: &flattenhtml.LimitError{
+				Limit: flattenhtml.LimitTextBytes, Max: 8, Location: "/html[1]/body[1]/div[1]/p[2]/text()[1]",
+				Depth: 5, Nodes: 9,
+			},
+		},
+		{
+			name: "concurrent flattening",
+			options: []flattenhtml.NodeManagerOption{
+				flattenhtml.WithMaxNodes(4), flattenhtml.WithConcurrentFlattening(0),
+			},
+			expected: &flattenhtml.LimitError{
+				Limit: flattenhtml.LimitNodes, Max: 4, Location: "/html[1]/body[1]/div[1]", Depth: 3, Nodes: 5,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML), tc.options...)
+			require.NoError(t, err)
+
+			mc, err := nm.Parse(flattenhtml.NewTagFlattener(), &sampleFlattener{})
+
+			if tc.expected == nil {
+				require.NoError(t, err)
+				require.NotNil(t, mc)
+
+				return
+			}
+
+			require.ErrorIs(t, err, flattenhtml.ErrLimitExceeded)
+			require.Nil(t, mc)
+
+			var limitErr *flattenhtml.LimitError
+
+			require.True(t, errors.As(err, &limitErr))
+			require.Equal(t, tc.expected, limitErr)
+		})
+	}
+}
+
+func TestNodeManager_ParseContext(t *testing.T) {
+	t.Parallel()
+
+	for _, concurrent := range []bool{false, true} {
+		options := make([]flattenhtml.NodeManagerOption, 0)
+
+		if concurrent {
+			options = append(options, flattenhtml.WithConcurrentFlattening(0))
+		}
+
+		nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>a</p><p>b</p>`), options...)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mc, err := nm.ParseContext(ctx, flattenhtml.NewTagFlattener(), &sampleFlattener{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, mc)
+
+		mc, err = nm.ParseContext(context.Background(), flattenhtml.NewTagFlattener(), &sampleFlattener{})
+		require.NoError(t, err)
+		require.NotNil(t, mc)
+	}
+}
+
+func TestNewNodeManagerFromReader_MaxInputBytes(t *testing.T) {
+	t.Parallel()
+
+	body := `<p>a</p><p>b</p>`
+
+	_, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(body), flattenhtml.WithMaxInputBytes(len(body)))
+	require.NoError(t, err)
+
+	_, err = flattenhtml.NewNodeManagerFromReader(strings.NewReader(body), flattenhtml.WithMaxInputBytes(len(body)-1))
+	require.ErrorIs(t, err, flattenhtml.ErrLimitExceeded)
+
+	var limitErr *flattenhtml.LimitError
+
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, flattenhtml.LimitInputBytes, limitErr.Limit)
+	require.Equal(t, len(body)-1, limitErr.Max)
+	require.Equal(t, "parse limit exceeded: maximum input bytes of 15", limitErr.Error())
+}
+
+func TestNewNodeManagerFromReaderContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReaderContext(ctx, strings.NewReader(`<p>a</p>`))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, nm)
+
+	nm, err = flattenhtml.NewNodeManagerFromReaderContext(context.Background(), strings.NewReader(`<p>a</p>`))
+	require.NoError(t, err)
+	require.NotNil(t, nm)
+}
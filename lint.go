@@ -0,0 +1,328 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// LintReport is the callback that a LintRule calls for each node that breaks the rule.
+type LintReport func(node *Node, message string)
+
+// LintRule is a single check of a Linter. The Check function runs once after the
+// document is flattened and reports the nodes that break the rule. It can query the
+// elements of the document using the LintIndex, so the rules do not need to traverse
+// the HTML tree themselves.
+type LintRule struct {
+	// ID is the identifier of the rule, e.g., "image-alt". The findings of the rule
+	// can be selected using it as the key of the Linter.
+	ID string
+	// Check checks the document and calls report for each node that breaks the rule.
+	Check func(index *LintIndex, report LintReport)
+}
+
+// LintFinding is a node that breaks a LintRule.
+type LintFinding struct {
+	Rule    string
+	Node    *Node
+	Message string
+}
+
+// LintIndex is the index of the elements of a document, built by the Linter in the
+// same traversal that flattens the document. All its methods return the elements in
+// the tree order and skip the removed ones.
+type LintIndex struct {
+	elements   *NodeIterator
+	tags       map[string]*NodeIterator
+	attributes map[string]*NodeIterator
+	ids        map[string]*NodeIterator
+	// The keyed maps hold the keys of each element in tags, attributes and ids, so
+	// the keys of an element are updated when it is flattened again.
+	tagKeys       map[*html.Node]*keyedNode
+	attributeKeys map[*html.Node]*keyedNode
+	idKeys        map[*html.Node]*keyedNode
+}
+
+// Linter is a Flattener that checks the flattened document against a set of
+// LintRule values, e.g., the accessibility rules of AccessibilityRules. It collects
+// the elements during NodeManager.Parse and indexes them by their tag, attributes
+// and id, and the rules query the index afterward, so all the rules together cost a
+// single traversal of the HTML tree. The nodes that break a rule can be selected using
+// the rule ID as the key, and Findings returns all of them along with the messages.
+// The index is updated as the elements are flattened, and the rules run on the first
+// query after the nodes are flattened or the structure of the HTML tree is changed
+// using Node. The elements whose attributes are changed should be registered again
+// using MultiCursor.RegisterNewNode to index and check them again.
+type Linter struct {
+	treeBinding
+
+	rules []LintRule
+	// mu guards the lazy checking and the findings, so the read methods can be called
+	// concurrently.
+	mu    sync.Mutex
+	nodes map[*html.Node]*Node
+	index *LintIndex
+	// last is the last flattened node, used to detect whether the nodes are
+	// flattened in the tree order.
+	last   *html.Node
+	sorted bool
+	order  treeOrder
+	dirty  bool
+	// version is the version of the HTML trees at the last check.
+	version  uint64
+	findings []LintFinding
+	byRule   map[string]*NodeIterator
+}
+
+var (
+	_ Flattener        = (*Linter)(nil)
+	_ FlattenerFactory = (*Linter)(nil)
+	_ KeyLister        = (*Linter)(nil)
+)
+
+// NewLinter creates a new Linter that checks the given rules.
+func NewLinter(rules ...LintRule) *Linter {
+	return &Linter{
+		rules:    rules,
+		nodes:    make(map[*html.Node]*Node),
+		index:    newLintIndex(),
+		sorted:   true,
+		order:    make(treeOrder),
+		findings: make([]LintFinding, 0),
+		byRule:   make(map[string]*NodeIterator),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It collects the elements of the HTML tree for the rules.
+// This method does not return an error.
+func (l *Linter) Flatten(node *html.Node) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.last != nil && node != nextInTreeOrder(l.last) {
+		l.sorted = false
+	}
+
+	l.last = node
+	l.order.addRoot(node)
+	l.dirty = true
+
+	if node.Type != html.ElementNode {
+		return nil
+	}
+
+	if l.nodes[node] == nil {
+		l.nodes[node] = l.newNode(node)
+		l.index.elements.Add(l.nodes[node])
+	}
+
+	l.index.add(l.nodes[node])
+
+	return nil
+}
+
+// GetNodesByKey returns the nodes that break the rule with the given ID.
+func (l *Linter) GetNodesByKey(key string) *NodeIterator {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.check()
+
+	return l.byRule[key]
+}
+
+func (l *Linter) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*Linter)
+
+	return ok
+}
+
+// Len for Linter gives you the number of rules that have at least one finding.
+func (l *Linter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.check()
+
+	return len(l.byRule)
+}
+
+// Keys returns the IDs of the rules that have at least one finding, in the sorted order.
+func (l *Linter) Keys() iter.Seq[string] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.check()
+
+	return sortedKeys(l.byRule)
+}
+
+// NewFlattener returns a new and empty Linter with the same rules.
+func (l *Linter) NewFlattener() Flattener {
+	return NewLinter(l.rules...)
+}
+
+// Findings returns the findings of all the rules, in the order of the rules and then
+// in the tree order of the nodes. The findings of the removed nodes are skipped.
+func (l *Linter) Findings() []LintFinding {
+	l.mu.Lock()
+	l.check()
+	findings := slices.Clone(l.findings)
+	l.mu.Unlock()
+
+	return slices.DeleteFunc(findings, func(finding LintFinding) bool {
+		return finding.Node.IsRemoved()
+	})
+}
+
+// check runs the rules if any node is flattened, or the structure of the HTML tree
+// is changed, after the last check. It must be called with mu held. The findings are
+// replaced rather than changed in place, so the NodeIterator values that are returned
+// before are not changed.
+func (l *Linter) check() {
+	version := l.treeVersion()
+	if !l.dirty && l.version == version {
+		return
+	}
+
+	// The moved nodes change the tree order as well as the flattened ones.
+	if !l.sorted || l.version != version {
+		l.index.sort(l.order)
+
+		l.sorted = true
+	}
+
+	findings := make([]LintFinding, 0, len(l.findings))
+	byRule := make(map[string]*NodeIterator, len(l.byRule))
+
+	for _, rule := range l.rules {
+		start := len(findings)
+
+		rule.Check(l.index, func(node *Node, message string) {
+			if wrapper, ok := l.nodes[node.htmlNode]; ok {
+				node = wrapper
+			}
+
+			findings = append(findings, LintFinding{Rule: rule.ID, Node: node, Message: message})
+		})
+
+		slices.SortStableFunc(findings[start:], func(a, b LintFinding) int {
+			return l.order.compare(a.Node, b.Node)
+		})
+
+		for _, finding := range findings[start:] {
+			if _, ok := byRule[rule.ID]; !ok {
+				byRule[rule.ID] = NewNodeIterator()
+			}
+
+			byRule[rule.ID].Add(finding.Node)
+		}
+	}
+
+	l.findings = findings
+	l.byRule = byRule
+	l.dirty = false
+	l.version = version
+}
+
+// newLintIndex creates a new and empty LintIndex.
+func newLintIndex() *LintIndex {
+	return &LintIndex{
+		elements:      NewNodeIterator(),
+		tags:          make(map[string]*NodeIterator),
+		attributes:    make(map[string]*NodeIterator),
+		ids:           make(map[string]*NodeIterator),
+		tagKeys:       make(map[*html.Node]*keyedNode),
+		attributeKeys: make(map[*html.Node]*keyedNode),
+		idKeys:        make(map[*html.Node]*keyedNode),
+	}
+}
+
+// add indexes the element by its tag, attributes and id. If the element is already
+// indexed, it is removed from the keys it no longer has.
+func (i *LintIndex) add(node *Node) {
+	attributes := make([]string, 0, len(node.htmlNode.Attr))
+	ids := make([]string, 0, 1)
+
+	for _, attr := range node.htmlNode.Attr {
+		if attr.Namespace != "" {
+			continue
+		}
+
+		attributes = append(attributes, attr.Key)
+
+		if attr.Key == "id" && attr.Val != "" {
+			ids = append(ids, attr.Val)
+		}
+	}
+
+	lintIndexKeys(i.tags, i.tagKeys, node, []string{node.htmlNode.Data})
+	lintIndexKeys(i.attributes, i.attributeKeys, node, attributes)
+	lintIndexKeys(i.ids, i.idKeys, node, ids)
+}
+
+// sort sorts the elements of all the keys in the given tree order.
+func (i *LintIndex) sort(order treeOrder) {
+	slices.SortStableFunc(i.elements.nodes, order.compare)
+
+	for _, index := range []map[string]*NodeIterator{i.tags, i.attributes, i.ids} {
+		for _, nodes := range index {
+			slices.SortStableFunc(nodes.nodes, order.compare)
+		}
+	}
+}
+
+// lintIndexKeys replaces the keys of the element in the given index with the given keys.
+func lintIndexKeys(index map[string]*NodeIterator, keys map[*html.Node]*keyedNode, node *Node, values []string) {
+	keyed, ok := keys[node.htmlNode]
+	if !ok {
+		keyed = &keyedNode{node: node}
+	}
+
+	indexKeys(index, keys, keyed, values)
+}
+
+// Elements returns all the elements of the document.
+func (i *LintIndex) Elements() *NodeIterator {
+	return i.elements
+}
+
+// Tag returns the elements with the given tag name.
+func (i *LintIndex) Tag(name string) *NodeIterator {
+	return lintIndexNodes(i.tags, name)
+}
+
+// Attribute returns the elements that have the given attribute.
+func (i *LintIndex) Attribute(key string) *NodeIterator {
+	return lintIndexNodes(i.attributes, key)
+}
+
+// Attributes returns the keys of all the attributes of the document in the sorted order.
+func (i *LintIndex) Attributes() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for key := range sortedKeys(i.attributes) {
+			// The attributes that only the removed elements have are skipped.
+			if i.attributes[key].Len() > 0 && !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ID returns the elements with the given id. More than one element means that the
+// id is duplicated.
+func (i *LintIndex) ID(id string) *NodeIterator {
+	return lintIndexNodes(i.ids, id)
+}
+
+// lintIndexNodes returns the nodes of the given key, or an empty NodeIterator.
+func lintIndexNodes(index map[string]*NodeIterator, key string) *NodeIterator {
+	if nodes, ok := index[key]; ok {
+		return nodes
+	}
+
+	return NewNodeIterator()
+}
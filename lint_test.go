@@ -0,0 +1,154 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinter(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`
+		<div id="a" class="box"></div>
+		<p id="b">text</p>
+		<div id="c"></div>`,
+	))
+	require.NoError(t, err)
+
+	checks := 0
+	noDiv := flattenhtml.LintRule{
+		ID: "no-div",
+		Check: func(index *flattenhtml.LintIndex, report flattenhtml.LintReport) {
+			checks++
+
+			for node := range index.Tag("div").All() {
+				report(node, "div is not allowed")
+			}
+		},
+	}
+	noClass := flattenhtml.LintRule{
+		ID: "no-class",
+		Check: func(index *flattenhtml.LintIndex, report flattenhtml.LintReport) {
+			for node := range index.Attribute("class").All() {
+				report(node, "class is not allowed")
+			}
+
+			require.Equal(t, 0, index.Tag("span").Len())
+			require.Equal(t, 1, index.ID("b").Len())
+			require.Contains(t, slices.Collect(index.Attributes()), "id")
+		},
+	}
+	unused := flattenhtml.LintRule{
+		ID:    "unused",
+		Check: func(*flattenhtml.LintIndex, flattenhtml.LintReport) {},
+	}
+
+	linter := flattenhtml.NewLinter(noDiv, noClass, unused)
+
+	mc, err := nm.Parse(linter)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.Linter{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"no-class", "no-div"}, slices.Collect(cursor.Keys()))
+	require.Equal(t, 2, cursor.Len())
+	require.Equal(t, 2, cursor.SelectNodes("no-div").Len())
+	require.Equal(t, 0, cursor.SelectNodes("unused").Len())
+
+	findings := linter.Findings()
+	require.Len(t, findings, 3)
+	require.Equal(t, "no-div", findings[0].Rule)
+	require.Equal(t, "div is not allowed", findings[0].Message)
+	require.Equal(t, "a", findings[0].Node.Attributes()["id"])
+	require.Equal(t, "c", findings[1].Node.Attributes()["id"])
+	require.Equal(t, "no-class", findings[2].Rule)
+
+	// The rules run once until a node is flattened again.
+	linter.Findings()
+	require.Equal(t, 1, checks)
+
+	added, err := findings[0].Node.PrependSibling(flattenhtml.NodeTypeElement, "div", map[string]string{"id": "d"})
+	require.NoError(t, err)
+	require.NoError(t, mc.RegisterNewNode(added))
+
+	findings = linter.Findings()
+	require.Equal(t, 2, checks)
+	require.Len(t, findings, 4)
+	require.Equal(t, "d", findings[0].Node.Attributes()["id"])
+
+	require.NoError(t, findings[2].Node.Remove())
+	require.Len(t, linter.Findings(), 3)
+	require.Equal(t, 2, cursor.SelectNodes("no-div").Len())
+
+	// The moved nodes are checked again in their new order without registering them.
+	box := findings[1].Node
+	require.NoError(t, box.InsertBefore(added))
+	require.Equal(t, "a", cursor.SelectNodes("no-div").First().Attributes()["id"])
+	require.Equal(t, 4, checks)
+
+	// The keys of an element are updated once it is registered again.
+	box.RemoveAttribute("class")
+	require.NoError(t, mc.RegisterNewNode(box))
+	require.Equal(t, 0, cursor.SelectNodes("no-class").Len())
+	require.Equal(t, []string{"no-div"}, slices.Collect(cursor.Keys()))
+
+	fresh, ok := linter.NewFlattener().(*flattenhtml.Linter)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(linter))
+	require.False(t, fresh.IsMyType(flattenhtml.NewTagFlattener()))
+}
+
+func TestLinter_ConcurrentCheck(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div id="a"></div><p id="b"></p>`))
+	require.NoError(t, err)
+
+	linter := flattenhtml.NewLinter(flattenhtml.LintRule{
+		ID: "no-div",
+		Check: func(index *flattenhtml.LintIndex, report flattenhtml.LintReport) {
+			for node := range index.Tag("div").All() {
+				report(node, "div is not allowed")
+			}
+		},
+	})
+
+	mc, err := nm.Parse(linter)
+	require.NoError(t, err)
+
+	divs := linter.GetNodesByKey("no-div")
+	require.Equal(t, 1, divs.Len())
+
+	added, err := divs.First().AppendSibling(flattenhtml.NodeTypeElement, "div", nil)
+	require.NoError(t, err)
+	require.NoError(t, mc.RegisterNewNode(added))
+
+	// The rules run again by the first of the concurrent queries.
+	wg := sync.WaitGroup{}
+	found := make([]int, 8)
+
+	for i := range found {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			found[i] = linter.GetNodesByKey("no-div").Len() + len(linter.Findings()) + linter.Len()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, count := range found {
+		require.Equal(t, 5, count)
+	}
+
+	// The previous results are not changed by the new check.
+	require.Equal(t, 1, divs.Len())
+}
@@ -0,0 +1,691 @@
+package flattenhtml
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MarkdownLinkStyle decides how links are written in the Markdown output.
+type MarkdownLinkStyle int
+
+// MarkdownOption is a function that configures the HTML to Markdown conversion.
+type MarkdownOption func(options *markdownOptions)
+
+const (
+	// MarkdownLinkInline writes links as [text](url "title").
+	MarkdownLinkInline MarkdownLinkStyle = iota
+	// MarkdownLinkReferenced writes links as [text][1] and appends the
+	// link reference definitions to the end of the document.
+	MarkdownLinkReferenced
+)
+
+// markdownOptions holds the configurations of the HTML to Markdown conversion.
+type markdownOptions struct {
+	linkStyle   MarkdownLinkStyle
+	dropUnknown bool
+}
+
+// markdownConverter converts a HTML tree to CommonMark with GFM tables and
+// strikethrough. It holds the state of a single conversion.
+type markdownConverter struct {
+	options    markdownOptions
+	references []string
+	refIndex   map[string]int
+	// lineBreak replaces the hard line break of <br> inside the constructs that
+	// cannot span multiple lines, i.e., the headings and the table cells.
+	lineBreak string
+}
+
+// markdownBlockTags are the elements that are rendered as a Markdown block and
+// break the inline content around them.
+var markdownBlockTags = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"p": true, "pre": true, "blockquote": true, "ul": true, "ol": true,
+	"hr": true, "table": true, "dl": true, "dt": true, "dd": true,
+}
+
+// markdownContainerTags are block elements with no Markdown equivalent.
+// Their children are converted as if they were direct children of their parent.
+var markdownContainerTags = map[string]bool{
+	"html": true, "body": true, "div": true, "section": true, "article": true,
+	"main": true, "header": true, "footer": true, "nav": true, "aside": true,
+	"figure": true, "figcaption": true, "address": true, "details": true,
+	"summary": true, "form": true, "fieldset": true, "center": true,
+}
+
+// markdownInlineTags are inline elements that are known to the converter.
+var markdownInlineTags = map[string]bool{
+	"a": true, "img": true, "em": true, "i": true, "strong": true, "b": true,
+	"code": true, "kbd": true, "samp": true, "tt": true, "del": true, "s": true,
+	"strike": true, "br": true, "span": true, "abbr": true, "small": true,
+	"sub": true, "sup": true, "u": true, "mark": true, "time": true,
+	"label": true, "cite": true, "q": true, "var": true, "dfn": true,
+	"ins": true, "font": true, "button": true, "legend": true,
+}
+
+// markdownSkippedTags are elements whose content never appears in the Markdown output.
+var markdownSkippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"noscript": true, "title": true, "meta": true, "link": true,
+}
+
+// WithMarkdownLinkStyle sets the style of the links in the Markdown output.
+// The default style is MarkdownLinkInline.
+func WithMarkdownLinkStyle(style MarkdownLinkStyle) MarkdownOption {
+	return func(options *markdownOptions) {
+		options.linkStyle = style
+	}
+}
+
+// WithMarkdownDropUnknown drops the elements that have no Markdown equivalent
+// (e.g., custom elements, svg or iframe) along with their content.
+// By default, the content of unknown elements is kept.
+func WithMarkdownDropUnknown() MarkdownOption {
+	return func(options *markdownOptions) {
+		options.dropUnknown = true
+	}
+}
+
+// RenderMarkdown renders the HTML tree as CommonMark to the given writer.
+// Tables and strikethrough are written using the GitHub Flavored Markdown syntax.
+func (n *NodeManager) RenderMarkdown(w io.Writer, opts ...MarkdownOption) error {
+	return renderMarkdown(w, []*html.Node{n.root}, opts)
+}
+
+// RenderMarkdown renders the Node and its descendants as CommonMark to the given writer.
+// Tables and strikethrough are written using the GitHub Flavored Markdown syntax.
+func (n *Node) RenderMarkdown(w io.Writer, opts ...MarkdownOption) error {
+	return renderMarkdown(w, []*html.Node{n.htmlNode}, opts)
+}
+
+// renderMarkdown converts the given nodes to Markdown and writes the result to w.
+func renderMarkdown(w io.Writer, nodes []*html.Node, opts []MarkdownOption) error {
+	converter := &markdownConverter{
+		refIndex: make(map[string]int),
+	}
+
+	for _, opt := range opts {
+		opt(&converter.options)
+	}
+
+	output := converter.blocks(nodes)
+
+	if len(converter.references) > 0 {
+		output += "\n\n" + strings.Join(converter.references, "\n")
+	}
+
+	if output != "" {
+		output += "\n"
+	}
+
+	_, err := io.WriteString(w, output)
+
+	return err
+}
+
+// blocks converts the given sibling nodes to Markdown blocks separated by an empty line.
+func (c *markdownConverter) blocks(nodes []*html.Node) string {
+	return strings.Join(c.collectBlocks(nodes, nil), "\n\n")
+}
+
+// collectBlocks converts the given sibling nodes and appends the resulting blocks
+// to the given slice. The inline content between block elements forms a paragraph.
+func (c *markdownConverter) collectBlocks(nodes []*html.Node, blocks []string) []string {
+	inline := strings.Builder{}
+
+	flush := func() {
+		if paragraph := strings.TrimSpace(inline.String()); paragraph != "" {
+			blocks = append(blocks, escapeMarkdownLineStart(paragraph))
+		}
+
+		inline.Reset()
+	}
+
+	for _, node := range nodes {
+		switch {
+		case node.Type == html.DocumentNode:
+			flush()
+
+			blocks = c.collectBlocks(childNodes(node), blocks)
+		case node.Type != html.ElementNode:
+			inline.WriteString(c.inline(node))
+		case markdownSkippedTags[node.Data]:
+			continue
+		case markdownContainerTags[node.Data]:
+			flush()
+
+			blocks = c.collectBlocks(childNodes(node), blocks)
+		case markdownBlockTags[node.Data]:
+			flush()
+
+			if block := c.block(node); block != "" {
+				blocks = append(blocks, block)
+			}
+		case markdownInlineTags[node.Data]:
+			inline.WriteString(c.inline(node))
+		case c.options.dropUnknown:
+			continue
+		default:
+			flush()
+
+			blocks = c.collectBlocks(childNodes(node), blocks)
+		}
+	}
+
+	flush()
+
+	return blocks
+}
+
+// block converts a single block element to Markdown.
+func (c *markdownConverter) block(node *html.Node) string {
+	switch node.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(node.Data[1] - '0')
+		previousBreak := c.lineBreak
+		c.lineBreak = " "
+		text := strings.TrimSpace(collapseSpaces(c.inlineChildren(node)))
+		c.lineBreak = previousBreak
+
+		if text == "" {
+			return ""
+		}
+
+		return strings.Repeat("#", level) + " " + text
+	case "p", "dt":
+		return escapeMarkdownLineStart(strings.TrimSpace(c.inlineChildren(node)))
+	case "dd":
+		return prefixLines(c.blocks(childNodes(node)), "    ", "    ")
+	case "pre":
+		return c.codeBlock(node)
+	case "blockquote":
+		return prefixLines(c.blocks(childNodes(node)), "> ", "> ")
+	case "ul", "ol":
+		return c.list(node)
+	case "hr":
+		return "---"
+	case "table":
+		return c.table(node)
+	case "dl":
+		return c.blocks(childNodes(node))
+	}
+
+	return ""
+}
+
+// codeBlock converts a <pre> element to a fenced code block. The language of the
+// code block is taken from the language-* or lang-* class of <pre> or its <code> child.
+func (c *markdownConverter) codeBlock(node *html.Node) string {
+	language := codeLanguage(node)
+
+	if child := node.FirstChild; child != nil && child.Type == html.ElementNode && child.Data == "code" {
+		if language == "" {
+			language = codeLanguage(child)
+		}
+	}
+
+	code := strings.TrimSuffix(textContent(node), "\n")
+	fence := "```"
+
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// list converts <ul> and <ol> elements to Markdown lists. Nested lists are
+// indented based on the width of their parent item's marker.
+func (c *markdownConverter) list(node *html.Node) string {
+	ordered := node.Data == "ol"
+	number := 1
+
+	if start, ok := htmlAttribute(node, "start"); ok && ordered {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(start)); err == nil {
+			number = parsed
+		}
+	}
+
+	items := make([]string, 0)
+
+	for _, child := range childNodes(node) {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		items = append(items, prefixLines(c.listItem(child), marker, strings.Repeat(" ", len(marker))))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// listItem converts the content of a <li> element. Its blocks are separated by an
+// empty line, so multiple paragraphs stay apart, while the nested lists follow the
+// text before them on the next line to keep the list tight.
+func (c *markdownConverter) listItem(node *html.Node) string {
+	content := ""
+	pending := make([]*html.Node, 0)
+
+	add := func(text, separator string) {
+		if text == "" {
+			return
+		}
+
+		if content != "" {
+			content += separator
+		}
+
+		content += text
+	}
+
+	for _, child := range childNodes(node) {
+		if child.Type == html.ElementNode && (child.Data == "ul" || child.Data == "ol") {
+			add(c.blocks(pending), "\n\n")
+			add(c.list(child), "\n")
+
+			pending = pending[:0]
+
+			continue
+		}
+
+		pending = append(pending, child)
+	}
+
+	add(c.blocks(pending), "\n\n")
+
+	return content
+}
+
+// table converts a <table> element to a GFM table. The first row is used as the
+// header row and all rows are padded to the same number of columns. Pipes in the
+// cells are already escaped by escapeMarkdown.
+func (c *markdownConverter) table(node *html.Node) string {
+	rows := make([][]string, 0)
+	columns := 0
+
+	previousBreak := c.lineBreak
+	c.lineBreak = "<br>"
+
+	defer func() { c.lineBreak = previousBreak }()
+
+	// The rows of the nested tables are a part of their cell, not of this table.
+	for _, group := range tableRowGroups(node) {
+		for _, tr := range group.rows {
+			row := make([]string, 0)
+
+			for _, cell := range childNodes(tr) {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					row = append(row, strings.TrimSpace(c.inlineChildren(cell)))
+				}
+			}
+
+			columns = max(columns, len(row))
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 || columns == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// inlineTable converts a <table> element that appears in an inline context, e.g., a
+// table nested in a table cell, to its text. The cells are separated by a space and
+// the rows by a line break.
+func (c *markdownConverter) inlineTable(node *html.Node) string {
+	lineBreak := c.lineBreak
+	if lineBreak == "" {
+		lineBreak = " "
+	}
+
+	rows := make([]string, 0)
+
+	for _, group := range tableRowGroups(node) {
+		for _, tr := range group.rows {
+			cells := make([]string, 0)
+
+			for _, cell := range childNodes(tr) {
+				if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+					continue
+				}
+
+				if text := strings.TrimSpace(c.inlineChildren(cell)); text != "" {
+					cells = append(cells, text)
+				}
+			}
+
+			if len(cells) > 0 {
+				rows = append(rows, strings.Join(cells, " "))
+			}
+		}
+	}
+
+	return strings.Join(rows, lineBreak)
+}
+
+// inlineChildren converts all children of the given node to inline Markdown.
+func (c *markdownConverter) inlineChildren(node *html.Node) string {
+	builder := strings.Builder{}
+
+	for _, child := range childNodes(node) {
+		builder.WriteString(c.inline(child))
+	}
+
+	return builder.String()
+}
+
+// inline converts a node to inline Markdown. Block elements that appear in an
+// inline context are converted to their text.
+func (c *markdownConverter) inline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseSpaces(node.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if markdownSkippedTags[node.Data] {
+		return ""
+	}
+
+	switch node.Data {
+	case "br":
+		if c.lineBreak != "" {
+			return c.lineBreak
+		}
+
+		return "\\\n"
+	case "em", "i", "cite", "dfn", "var":
+		return wrapInline(c.inlineChildren(node), "*")
+	case "strong", "b":
+		return wrapInline(c.inlineChildren(node), "**")
+	case "del", "s", "strike":
+		return wrapInline(c.inlineChildren(node), "~~")
+	case "code", "kbd", "samp", "tt":
+		return inlineCode(textContent(node))
+	case "a":
+		return c.link(node)
+	case "img":
+		return c.image(node)
+	case "table":
+		return c.inlineTable(node)
+	}
+
+	if c.options.dropUnknown && !markdownInlineTags[node.Data] &&
+		!markdownBlockTags[node.Data] && !markdownContainerTags[node.Data] {
+		return ""
+	}
+
+	return c.inlineChildren(node)
+}
+
+// link converts an <a> element to a Markdown link based on the configured link style.
+// Anchors without href are converted to their text.
+func (c *markdownConverter) link(node *html.Node) string {
+	text := strings.TrimSpace(c.inlineChildren(node))
+	href, ok := htmlAttribute(node, "href")
+
+	if !ok {
+		return text
+	}
+
+	destination := markdownDestination(href)
+
+	if title, ok := htmlAttribute(node, "title"); ok && title != "" {
+		destination += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+
+	if c.options.linkStyle == MarkdownLinkReferenced {
+		return "[" + text + "][" + strconv.Itoa(c.reference(destination)) + "]"
+	}
+
+	return "[" + text + "](" + destination + ")"
+}
+
+// image converts an <img> element to a Markdown image.
+func (c *markdownConverter) image(node *html.Node) string {
+	src, ok := htmlAttribute(node, "src")
+	if !ok {
+		return ""
+	}
+
+	alt, _ := htmlAttribute(node, "alt")
+	destination := markdownDestination(src)
+
+	if title, ok := htmlAttribute(node, "title"); ok && title != "" {
+		destination += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+
+	if c.options.linkStyle == MarkdownLinkReferenced {
+		return "![" + escapeMarkdown(alt) + "][" + strconv.Itoa(c.reference(destination)) + "]"
+	}
+
+	return "![" + escapeMarkdown(alt) + "](" + destination + ")"
+}
+
+// reference returns the number of the link reference definition of the given
+// destination, registering a new definition if it does not exist yet.
+func (c *markdownConverter) reference(destination string) int {
+	if index, ok := c.refIndex[destination]; ok {
+		return index
+	}
+
+	c.references = append(c.references, "["+strconv.Itoa(len(c.references)+1)+"]: "+destination)
+	c.refIndex[destination] = len(c.references)
+
+	return len(c.references)
+}
+
+// childNodes returns the direct children of the given node.
+func childNodes(node *html.Node) []*html.Node {
+	children := make([]*html.Node, 0)
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+
+	return children
+}
+
+// textContent returns the concatenated content of all text nodes under the given node.
+func textContent(node *html.Node) string {
+	builder := strings.Builder{}
+
+	walkTree(node.FirstChild, func(child *html.Node) {
+		if child.Type == html.TextNode {
+			builder.WriteString(child.Data)
+		}
+	})
+
+	return builder.String()
+}
+
+// htmlAttribute returns the value of the given attribute of a *html.Node.
+func htmlAttribute(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val, true
+		}
+	}
+
+	return "", false
+}
+
+// codeLanguage extracts the language of a code block from the language-* or lang-* class.
+func codeLanguage(node *html.Node) string {
+	class, _ := htmlAttribute(node, "class")
+
+	for _, name := range strings.Fields(class) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(name, prefix) {
+				return strings.TrimPrefix(name, prefix)
+			}
+		}
+	}
+
+	return ""
+}
+
+// collapseSpaces replaces each run of HTML whitespace with a single space.
+func collapseSpaces(text string) string {
+	builder := strings.Builder{}
+	space := false
+
+	for i := range len(text) {
+		if isHTMLSpace(text[i]) {
+			space = true
+
+			continue
+		}
+
+		if space {
+			builder.WriteByte(' ')
+
+			space = false
+		}
+
+		builder.WriteByte(text[i])
+	}
+
+	if space {
+		builder.WriteByte(' ')
+	}
+
+	return builder.String()
+}
+
+// escapeMarkdown escapes the Markdown metacharacters that could change the
+// meaning of the given inline text.
+func escapeMarkdown(text string) string {
+	builder := strings.Builder{}
+
+	for _, r := range text {
+		if strings.ContainsRune("\\`*_[]<>~|", r) {
+			builder.WriteByte('\\')
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+// escapeMarkdownLineStart escapes the characters that would turn a paragraph into
+// another block (i.e., heading, list item or thematic break) when they appear at
+// the beginning of it.
+func escapeMarkdownLineStart(text string) string {
+	if text == "" {
+		return text
+	}
+
+	switch text[0] {
+	case '#', '+', '-', '=':
+		return `\` + text
+	}
+
+	digits := 0
+
+	for digits < len(text) && text[digits] >= '0' && text[digits] <= '9' {
+		digits++
+	}
+
+	if digits > 0 && digits < len(text) && (text[digits] == '.' || text[digits] == ')') {
+		return text[:digits] + `\` + text[digits:]
+	}
+
+	return text
+}
+
+// wrapInline wraps the given text with the given delimiter and moves the leading
+// and trailing spaces outside the delimiters, so the emphasis stays valid.
+func wrapInline(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+
+	return leading + delimiter + trimmed + delimiter + trailing
+}
+
+// inlineCode wraps the given code with enough backticks to hold the backticks in it.
+func inlineCode(code string) string {
+	code = collapseSpaces(code)
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	fence := "`"
+
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return fence + " " + code + " " + fence
+	}
+
+	return fence + code + fence
+}
+
+// markdownDestination returns a link destination that is safe to use in Markdown.
+func markdownDestination(destination string) string {
+	destination = strings.TrimSpace(destination)
+
+	if strings.ContainsAny(destination, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(destination) + ">"
+	}
+
+	return destination
+}
+
+// prefixLines adds the first prefix to the first line of the text and the rest
+// prefix to the other lines. Empty lines are only prefixed by the non-space part.
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		prefix := rest
+
+		if i == 0 {
+			prefix = first
+		}
+
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+
+		lines[i] = prefix + line
+	}
+
+	return strings.Join(lines, "\n")
+}
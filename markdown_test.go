@@ -0,0 +1,123 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeManager_RenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     string
+		options  []flattenhtml.MarkdownOption
+		expected string
+	}{
+		{
+			name:     "headings and emphasis",
+			body:     `<h1>Title</h1><p>Some <em>nice</em> and <strong>bold </strong>text.</p>`,
+			expected: "# Title\n\nSome *nice* and **bold** text.\n",
+		},
+		{
+			name:     "escaping metacharacters",
+			body:     `<p>1. use *stars* and [brackets]</p><p># not a heading</p>`,
+			expected: "1\\. use \\*stars\\* and \\[brackets\\]\n\n\\# not a heading\n",
+		},
+		{
+			name:     "inline links and images",
+			body:     `<p><a href="/a" title="A">link</a> <img src="i.png" alt="pic"></p>`,
+			expected: "[link](/a \"A\") ![pic](i.png)\n",
+		},
+		{
+			name:     "referenced links",
+			body:     `<p><a href="/a">one</a> <a href="/b">two</a> <a href="/a">three</a></p>`,
+			options:  []flattenhtml.MarkdownOption{flattenhtml.WithMarkdownLinkStyle(flattenhtml.MarkdownLinkReferenced)},
+			expected: "[one][1] [two][2] [three][1]\n\n[1]: /a\n[2]: /b\n",
+		},
+		{
+			name:     "nested lists",
+			body:     `<ul><li>one<ul><li>nested</li></ul></li><li>two</li></ul><ol start="3"><li>three</li></ol>`,
+			expected: "- one\n  - nested\n- two\n\n3. three\n",
+		},
+		{
+			name:     "list items with multiple paragraphs",
+			body:     `<ul><li><p>first</p><p>second</p><ul><li>nested</li></ul></li><li>next</li></ul>`,
+			expected: "- first\n\n  second\n  - nested\n- next\n",
+		},
+		{
+			name:     "line breaks in headings and tables",
+			body:     `<h2>one<br>two</h2><table><tr><th>a<br>b</th></tr></table><p>x<br>y</p>`,
+			expected: "## one two\n\n| a<br>b |\n| --- |\n\nx\\\ny\n",
+		},
+		{
+			name:     "nested table",
+			body:     `<table><tr><th>outer</th></tr><tr><td><table><tr><td>inner</td></tr></table></td></tr></table>`,
+			expected: "| outer |\n| --- |\n| inner |\n",
+		},
+		{
+			name:     "nested table with multiple cells",
+			body:     `<table><tr><th>outer</th></tr><tr><td><table><tr><td>a</td><td>x</td></tr><tr><td>y</td></tr></table></td></tr></table>`,
+			expected: "| outer |\n| --- |\n| a x<br>y |\n",
+		},
+		{
+			name:     "code block with language",
+			body:     "<pre><code class=\"language-go\">fmt.Println(\"hi\")\n</code></pre><p>run <code>go test</code></p>",
+			expected: "```go\nfmt.Println(\"hi\")\n```\n\nrun `go test`\n",
+		},
+		{
+			name:     "blockquote",
+			body:     `<blockquote><p>first</p><p>second</p></blockquote>`,
+			expected: "> first\n>\n> second\n",
+		},
+		{
+			name:     "table",
+			body:     `<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td>a|b</td></tr></tbody></table>`,
+			expected: "| Name | Value |\n| --- | --- |\n| a\\|b |  |\n",
+		},
+		{
+			name:     "keeping unknown elements",
+			body:     `<p>before</p><my-widget>inside</my-widget><script>var x;</script>`,
+			expected: "before\n\ninside\n",
+		},
+		{
+			name:     "dropping unknown elements",
+			body:     `<p>before</p><my-widget>inside</my-widget>`,
+			options:  []flattenhtml.MarkdownOption{flattenhtml.WithMarkdownDropUnknown()},
+			expected: "before\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			output := bytes.Buffer{}
+
+			require.NoError(t, nm.RenderMarkdown(&output, tc.options...))
+			require.Equal(t, tc.expected, output.String())
+		})
+	}
+}
+
+func TestNode_RenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<h2>Skip</h2><div><h3>Keep</h3></div>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	output := bytes.Buffer{}
+
+	require.NoError(t, mc.First().SelectNodes("div").First().RenderMarkdown(&output))
+	require.Equal(t, "### Keep\n", output.String())
+}
@@ -0,0 +1,83 @@
+package flattenhtml
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateName is returned by NodeManager.Parse when two flatteners are
+// registered under the same name using Named.
+var ErrDuplicateName = errors.New("flattener name is already registered")
+
+// namedFlattener registers a flattener under a name. It is unwrapped by
+// NodeManager.Parse and NewMultiCursor, so the rest of the package only sees the
+// underlying flattener and its concrete type.
+type namedFlattener struct {
+	Flattener
+	name string
+}
+
+// Named registers the given flattener under the given name, so it can be selected
+// using MultiCursor.Cursor, regardless of its type and position:
+//
+//	mc, err := nm.Parse(
+//		flattenhtml.Named("by-testid", testIDs),
+//		flattenhtml.Named("by-role", roles),
+//	)
+//
+//	cursor, err := mc.Cursor("by-testid")
+//
+// The returned value is only meant to be passed to NodeManager.Parse,
+// NewNodeManagerFromSnapshot or NewMultiCursor.
+func Named(name string, flattener Flattener) Flattener {
+	return &namedFlattener{Flattener: flattener, name: name}
+}
+
+// unwrapNamedFlatteners returns the underlying flatteners along with their names.
+// The name of an unnamed flattener is empty. It returns ErrDuplicateName if two
+// flatteners have the same name.
+func unwrapNamedFlatteners(flatteners []Flattener) ([]Flattener, []string, error) {
+	unwrapped := make([]Flattener, 0, len(flatteners))
+	names := make([]string, 0, len(flatteners))
+	registered := make(map[string]bool)
+
+	var err error
+
+	for _, flattener := range flatteners {
+		name := ""
+
+		if named, ok := flattener.(*namedFlattener); ok {
+			flattener, name = named.Flattener, named.name
+
+			if registered[name] {
+				if err == nil {
+					err = fmt.Errorf("%w: %s", ErrDuplicateName, name)
+				}
+
+				name = ""
+			} else {
+				registered[name] = true
+			}
+		}
+
+		unwrapped = append(unwrapped, flattener)
+		names = append(names, name)
+	}
+
+	return unwrapped, names, err
+}
+
+// nameFlatteners wraps the flatteners that have a name using Named.
+func nameFlatteners(flatteners []Flattener, names []string) []Flattener {
+	named := make([]Flattener, 0, len(flatteners))
+
+	for i, flattener := range flatteners {
+		if i < len(names) && names[i] != "" {
+			flattener = Named(names[i], flattener)
+		}
+
+		named = append(named, flattener)
+	}
+
+	return named
+}
@@ -0,0 +1,112 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<button data-testid="pay" data-track="click">Pay</button><a data-track="view">Home</a>`,
+	))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(
+		flattenhtml.NewTagFlattener(),
+		flattenhtml.Named("by-testid", flattenhtml.NewKeyFlattener("attribute", func(node *flattenhtml.Node) []string {
+			testID, _ := node.Attribute("data-testid")
+
+			return []string{testID}
+		})),
+		flattenhtml.Named("by-track", flattenhtml.NewKeyFlattener("attribute", func(node *flattenhtml.Node) []string {
+			track, _ := node.Attribute("data-track")
+
+			return []string{track}
+		})),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"by-testid", "by-track"}, mc.Names())
+
+	testIDs, err := mc.Cursor("by-testid")
+	require.NoError(t, err)
+	require.Equal(t, "button", testIDs.SelectNodes("pay").First().TagName())
+
+	tracks, err := mc.Cursor("by-track")
+	require.NoError(t, err)
+	require.Equal(t, "a", tracks.SelectNodes("view").First().TagName())
+
+	_, err = mc.Cursor("missing")
+	require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+
+	// The type-based selection keeps working with the underlying flatteners.
+	tags, err := mc.SelectCursor(&flattenhtml.TagFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, 1, tags.SelectNodes("a").Len())
+
+	// A named flattener is selected by the type of its underlying flattener.
+	tags, err = mc.SelectCursor(flattenhtml.Named("other", flattenhtml.NewTagFlattener()))
+	require.NoError(t, err)
+	require.Equal(t, 1, tags.SelectNodes("button").Len())
+
+	_, err = mc.SelectCursor(flattenhtml.Named("other", flattenhtml.NewDataAttributeFlattener()))
+	require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+
+	// The names are kept by Clone.
+	_, clonedMC, err := nm.Clone()
+	require.NoError(t, err)
+	require.Equal(t, mc.Names(), clonedMC.Names())
+
+	clonedTracks, err := clonedMC.Cursor("by-track")
+	require.NoError(t, err)
+	require.Equal(t, 1, clonedTracks.SelectNodes("click").Len())
+}
+
+func TestNamed_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>a</p>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(
+		flattenhtml.Named("tags", flattenhtml.NewTagFlattener()),
+		flattenhtml.Named("tags", flattenhtml.NewDataAttributeFlattener()),
+	)
+	require.ErrorIs(t, err, flattenhtml.ErrDuplicateName)
+	require.Nil(t, mc)
+
+	mc, err = flattenhtml.NewMultiCursor(
+		flattenhtml.Named("tags", flattenhtml.NewTagFlattener()),
+		flattenhtml.Named("tags", flattenhtml.NewDataAttributeFlattener()),
+	)
+	require.ErrorIs(t, err, flattenhtml.ErrDuplicateName)
+	require.Nil(t, mc)
+}
+
+func TestNamed_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p data-id="1">a</p>`))
+	require.NoError(t, err)
+
+	_, err = nm.Parse(flattenhtml.Named("tags", flattenhtml.NewTagFlattener()))
+	require.NoError(t, err)
+
+	saved := bytes.Buffer{}
+	require.NoError(t, nm.SaveSnapshot(&saved))
+
+	_, mc, err := flattenhtml.NewNodeManagerFromSnapshot(
+		&saved, []flattenhtml.Flattener{flattenhtml.Named("tags", flattenhtml.NewTagFlattener())},
+	)
+	require.NoError(t, err)
+
+	cursor, err := mc.Cursor("tags")
+	require.NoError(t, err)
+	require.Equal(t, 1, cursor.SelectNodes("p").Len())
+}
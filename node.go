@@ -2,6 +2,8 @@ package flattenhtml
 
 import (
 	"errors"
+	"iter"
+	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
@@ -12,6 +14,10 @@ type NodeType html.NodeType
 // NodeIterator is a simple iterator that can iterate over a slice of *Node.
 // It is used to iterate over the nodes that are flattened by a Flattener and
 // perform different operations using the methods that are defined on the NodeIterator.
+//
+// All methods of NodeIterator, except Next and Reset, keep no state and are safe
+// for concurrent use as long as the nodes are not mutated at the same time.
+// Use NodeIterator.All to iterate over the nodes from multiple goroutines.
 type NodeIterator struct {
 	nodes       []*Node
 	cursorIndex uint
@@ -20,10 +26,19 @@ type NodeIterator struct {
 // Node is a simple wrapper around *html.Node.
 // It allows read/write operations on the *html.Node along with keeping the
 // structure of the HTML tree.
+//
+// Once the MultiCursor of the HTML tree is frozen using MultiCursor.Freeze, the
+// write operations of Node, and of the helpers built on them (e.g., ClassList,
+// Style and Form), do not change the tree anymore. The operations that return an
+// error return ErrFrozen and the rest of them do nothing.
 type Node struct {
-	htmlNode   *html.Node
-	attributes map[string]string
-	removed    bool
+	htmlNode *html.Node
+	// root is the top-most ancestor of the node when it was attached to the tree.
+	// It is used to detect if the node or one of its ancestors is detached later.
+	root *html.Node
+	// tree is the state of the tree of the NodeManager that the node belongs to.
+	// It is nil if the node is created using NewNode.
+	tree *treeState
 }
 
 // FilterOption is a function that accepts a *Node and returns a boolean.
@@ -36,7 +51,15 @@ const (
 	NodeTypeText    NodeType = NodeType(html.TextNode)
 )
 
-var ErrParentlessNode = errors.New("node with no parent cannot be removed")
+var (
+	// ErrParentlessNode is returned when an operation needs the parent of a Node
+	// which has no parent, e.g., removing or adding a sibling to the root node.
+	ErrParentlessNode = errors.New("node has no parent")
+
+	// ErrHierarchyRequest is returned when a Node is moved into itself or one of
+	// its descendants.
+	ErrHierarchyRequest = errors.New("node cannot be moved into itself or its descendants")
+)
 
 // NewNodeIterator creates a new NodeIterator.
 func NewNodeIterator() *NodeIterator {
@@ -88,16 +111,36 @@ func (n *NodeIterator) First() *Node {
 	return nil
 }
 
+// All returns an iterator over the non-removed nodes in the NodeIterator.
+// Unlike Next, the iteration state belongs to the caller, so multiple goroutines
+// can iterate over the same NodeIterator at the same time.
+func (n *NodeIterator) All() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for _, node := range n.nodes {
+			if node.IsRemoved() {
+				continue
+			}
+
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
+
 // Next iterates over the nodes in the NodeIterator and returns the next non-removed node.
 // It starts from the first element of the NodeIterator and proceed to the next item on each
 // call to Next. If there is no non-removed node, it returns nil.
 // Once received nil, must be considered as the end of the iteration.
 // Use Reset to start the iteration from the beginning.
+// Next keeps the iteration state in the NodeIterator and is not safe for concurrent
+// use. Use NodeIterator.All instead when the NodeIterator is shared.
 func (n *NodeIterator) Next() *Node {
-	for _, node := range n.nodes[n.cursorIndex:] {
-		if !node.IsRemoved() {
-			n.cursorIndex++
+	for n.cursorIndex < uint(len(n.nodes)) {
+		node := n.nodes[n.cursorIndex]
+		n.cursorIndex++
 
+		if !node.IsRemoved() {
 			return node
 		}
 	}
@@ -185,35 +228,237 @@ func (n *NodeIterator) FilterAnd(options ...FilterOption) *NodeIterator {
 }
 
 // NewNode creates a new Node with the given *html.Node.
+// The Node does not belong to any NodeManager, so it is not frozen by MultiCursor.Freeze,
+// and the changes to the structure of the tree made using it are not tracked by the
+// flatteners that index the nodes by their position, e.g., PathFlattener.
 func NewNode(htmlNode *html.Node) *Node {
-	attrs := make(map[string]string, len(htmlNode.Attr))
-
-	for _, attr := range htmlNode.Attr {
-		attrs[attr.Key] = attr.Val
-	}
+	return newTreeNode(htmlNode, nil)
+}
 
+// newTreeNode creates a new Node with the given *html.Node that shares the given
+// state of its tree.
+func newTreeNode(htmlNode *html.Node, tree *treeState) *Node {
 	return &Node{
-		htmlNode:   htmlNode,
-		attributes: attrs,
+		htmlNode: htmlNode,
+		root:     topAncestor(htmlNode),
+		tree:     tree,
 	}
 }
 
 // IsRemoved returns true if the Node is removed from the NodeIterator
 // and html.Node tree.
+// A Node is considered removed if it, or one of its ancestors, is detached from
+// the HTML tree using any Node (e.g., by removing its parent), and it is not removed
+// anymore once it is added back to the tree. Therefore, all the Node values of the
+// same *html.Node share the same state. The state is not stored, so each call walks
+// up to the root of the tree, and the methods of NodeIterator that skip the removed
+// nodes cost O(depth) per node.
 func (n *Node) IsRemoved() bool {
-	return n.removed
+	top := topAncestor(n.htmlNode)
+
+	return top != n.root && top.Type != html.DocumentNode
 }
 
 // Remove removes the Node from the NodeIterator and html.Node tree.
 // It won't be available if you use the NodeManager.Render.
 func (n *Node) Remove() error {
+	if err := checkFrozen(n); err != nil {
+		return err
+	}
+
 	if n.htmlNode.Parent == nil {
 		return ErrParentlessNode
 	}
 
 	n.htmlNode.Parent.RemoveChild(n.htmlNode)
+	changed(n)
+
+	return nil
+}
+
+// ReplaceWith replaces the Node with the given Node in the HTML tree. If the given
+// Node is already a part of the tree, it is moved to the position of this Node.
+// The Node is marked as removed afterward.
+func (n *Node) ReplaceWith(node *Node) error {
+	if err := node.InsertBefore(n); err != nil {
+		return err
+	}
+
+	return n.Remove()
+}
+
+// ReplaceWithHTML replaces the Node with the nodes parsed from the given HTML
+// fragment. The fragment is parsed in the context of the parent of the Node.
+// It returns the newly added top-level nodes. The Node is marked as removed afterward.
+// The newly added nodes will not be accessible using NodeIterator or Cursor.
+// To add them to the cycle, you can use MultiCursor.RegisterNewNode method.
+func (n *Node) ReplaceWithHTML(rawHTML string) ([]*Node, error) {
+	if err := checkFrozen(n); err != nil {
+		return nil, err
+	}
+
+	parent := n.htmlNode.Parent
+	if parent == nil {
+		return nil, ErrParentlessNode
+	}
+
+	contextNode := parent
+	if contextNode.Type != html.ElementNode {
+		contextNode = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	}
+
+	fragment, err := html.ParseFragment(strings.NewReader(rawHTML), contextNode)
+	if err != nil {
+		return nil, err
+	}
+
+	newNodes := make([]*Node, 0, len(fragment))
+
+	for _, htmlNode := range fragment {
+		parent.InsertBefore(htmlNode, n.htmlNode)
+
+		newNodes = append(newNodes, newTreeNode(htmlNode, n.tree))
+	}
+
+	return newNodes, n.Remove()
+}
+
+// Wrap wraps the Node with a new element with the given tag name and attributes.
+// The new element takes the position of the Node in the tree and the Node becomes
+// its only child. It returns the newly added element.
+// The newly added node will not be accessible using NodeIterator or Cursor.
+// To add the new node to the cycle, you can use MultiCursor.RegisterNewNode method.
+func (n *Node) Wrap(tagName string, attributes map[string]string) (*Node, error) {
+	if err := checkFrozen(n); err != nil {
+		return nil, err
+	}
+
+	parent := n.htmlNode.Parent
+	if parent == nil {
+		return nil, ErrParentlessNode
+	}
+
+	wrapper := prepareNewNode(NodeTypeElement, tagName, attributes)
+
+	parent.InsertBefore(wrapper.htmlNode, n.htmlNode)
+	parent.RemoveChild(n.htmlNode)
+	wrapper.htmlNode.AppendChild(n.htmlNode)
+	wrapper.attach(n.tree)
+	changed(n)
+
+	return wrapper, nil
+}
+
+// Unwrap replaces the Node with its children. The children keep their order and
+// the Node is marked as removed afterward.
+func (n *Node) Unwrap() error {
+	if err := checkFrozen(n); err != nil {
+		return err
+	}
+
+	parent := n.htmlNode.Parent
+	if parent == nil {
+		return ErrParentlessNode
+	}
+
+	for child := n.htmlNode.FirstChild; child != nil; child = n.htmlNode.FirstChild {
+		n.htmlNode.RemoveChild(child)
+		parent.InsertBefore(child, n.htmlNode)
+	}
+
+	return n.Remove()
+}
+
+// Empty removes all children of the Node. The removed children and their
+// descendants are considered removed by all NodeIterators.
+// If the Node is a part of a frozen tree, it does nothing.
+func (n *Node) Empty() {
+	if n.tree.isFrozen() {
+		return
+	}
+
+	for child := n.htmlNode.FirstChild; child != nil; child = n.htmlNode.FirstChild {
+		n.htmlNode.RemoveChild(child)
+	}
+
+	changed(n)
+}
+
+// MoveTo moves the Node to the children list of the given parent at the given position.
+// If the position is negative or not less than the number of the parent's children,
+// the Node is added as the last child. If the parent is the Node itself or one of
+// its descendants, ErrHierarchyRequest is returned.
+func (n *Node) MoveTo(parent *Node, position int) error {
+	if err := checkFrozen(n, parent); err != nil {
+		return err
+	}
+
+	if n.contains(parent.htmlNode) {
+		return ErrHierarchyRequest
+	}
+
+	n.detach()
+
+	reference := parent.htmlNode.FirstChild
+
+	for i := 0; reference != nil && i < position; i++ {
+		reference = reference.NextSibling
+	}
+
+	if position < 0 || reference == nil {
+		parent.htmlNode.AppendChild(n.htmlNode)
+	} else {
+		parent.htmlNode.InsertBefore(n.htmlNode, reference)
+	}
+
+	changed(n, parent)
+	n.attach(parent.tree)
+
+	return nil
+}
+
+// InsertBefore moves the Node, so it becomes the previous sibling of the given target.
+// The Node can be a part of the tree, a detached Node or a removed one.
+func (n *Node) InsertBefore(target *Node) error {
+	if target.htmlNode.Parent == nil {
+		return ErrParentlessNode
+	}
+
+	if err := checkFrozen(n, target); err != nil {
+		return err
+	}
+
+	if n.contains(target.htmlNode) {
+		return ErrHierarchyRequest
+	}
+
+	n.detach()
+	target.htmlNode.Parent.InsertBefore(n.htmlNode, target.htmlNode)
+	changed(n, target)
+	n.attach(target.tree)
+
+	return nil
+}
 
-	n.removed = true
+// InsertAfter moves the Node, so it becomes the next sibling of the given target.
+// The Node can be a part of the tree, a detached Node or a removed one.
+func (n *Node) InsertAfter(target *Node) error {
+	if target.htmlNode.Parent == nil {
+		return ErrParentlessNode
+	}
+
+	if err := checkFrozen(n, target); err != nil {
+		return err
+	}
+
+	if n.contains(target.htmlNode) {
+		return ErrHierarchyRequest
+	}
+
+	n.detach()
+	target.htmlNode.Parent.InsertBefore(n.htmlNode, target.htmlNode.NextSibling)
+	changed(n, target)
+	n.attach(target.tree)
 
 	return nil
 }
@@ -224,22 +469,40 @@ func (n *Node) TagName() string {
 }
 
 // Attributes returns a map of strings containing attributes key and values of the Node.
+// The map is built from the current attributes of the *html.Node on each call, so it
+// reflects the changes made by any Node value of the same *html.Node, or by a Rewriter.
+// Changing the returned map does not change the Node.
 func (n *Node) Attributes() map[string]string {
-	return n.attributes
+	attrs := make(map[string]string, len(n.htmlNode.Attr))
+
+	for _, attr := range n.htmlNode.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+
+	return attrs
 }
 
 // Attribute returns the value of the given attribute key.
 // The second return value is a boolean that indicates whether the given key is found.
 func (n *Node) Attribute(key string) (string, bool) {
-	val, ok := n.attributes[key]
+	for _, attr := range n.htmlNode.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
 
-	return val, ok
+	return "", false
 }
 
 // SetAttribute sets the value of the given attribute key for the node.
 // If the given key does not exist, it will be added to the node as a
 // new attribute. Otherwise, the value of the given key will be updated.
+// If the Node is a part of a frozen tree, it does nothing.
 func (n *Node) SetAttribute(key, value string) {
+	if n.tree.isFrozen() {
+		return
+	}
+
 	exists := false
 
 	for i, attr := range n.htmlNode.Attr {
@@ -258,13 +521,16 @@ func (n *Node) SetAttribute(key, value string) {
 			Val: value,
 		})
 	}
-
-	n.attributes[key] = value
 }
 
 // RemoveAttribute removes the given attribute key from the node.
 // If the given key does not exist, it will be ignored.
+// If the Node is a part of a frozen tree, it does nothing.
 func (n *Node) RemoveAttribute(key string) {
+	if n.tree.isFrozen() {
+		return
+	}
+
 	for i, attr := range n.htmlNode.Attr {
 		if attr.Key == key {
 			n.htmlNode.Attr = append(n.htmlNode.Attr[:i], n.htmlNode.Attr[i+1:]...)
@@ -272,8 +538,6 @@ func (n *Node) RemoveAttribute(key string) {
 			break
 		}
 	}
-
-	delete(n.attributes, key)
 }
 
 // HTMLNode returns the underlying *html.Node of the Node.
@@ -289,14 +553,21 @@ func (n *Node) HTMLNode() *html.Node {
 // The newly added node in this approach will be available if you render the NodeManager.
 // However, the newly added node will not be accessible using NodeIterator or Cursor.
 // To add the new node to the cycle, you can use MultiCursor.RegisterNewNode method.
+// If the Node is a part of a frozen tree, it returns nil.
 func (n *Node) AppendChild(
 	nodeType NodeType,
 	tagNameOrContent string,
 	attributes map[string]string,
 ) *Node {
+	if n.tree.isFrozen() {
+		return nil
+	}
+
 	newNode := prepareNewNode(nodeType, tagNameOrContent, attributes)
 
 	n.htmlNode.AppendChild(newNode.HTMLNode())
+	newNode.attach(n.tree)
+	changed(n)
 
 	return newNode
 }
@@ -308,11 +579,16 @@ func (n *Node) AppendChild(
 // The newly added node in this approach will be available if you render the NodeManager.
 // However, the newly added node will not be accessible using NodeIterator or Cursor.
 // To add the new node to the cycle, you can use MultiCursor.RegisterNewNode method.
+// If the Node is a part of a frozen tree, it returns nil.
 func (n *Node) PrependChild(
 	nodeType NodeType,
 	tagNameOrContent string,
 	attributes map[string]string,
 ) *Node {
+	if n.tree.isFrozen() {
+		return nil
+	}
+
 	newNode := prepareNewNode(nodeType, tagNameOrContent, attributes)
 
 	if n.htmlNode.FirstChild == nil {
@@ -321,6 +597,9 @@ func (n *Node) PrependChild(
 		n.htmlNode.InsertBefore(newNode.HTMLNode(), n.htmlNode.FirstChild)
 	}
 
+	newNode.attach(n.tree)
+	changed(n)
+
 	return newNode
 }
 
@@ -331,16 +610,27 @@ func (n *Node) PrependChild(
 // The newly added node in this approach will be available if you render the NodeManager.
 // However, the newly added node will not be accessible using NodeIterator or Cursor.
 // To add the new node to the cycle, you can use MultiCursor.RegisterNewNode method.
+// If the Node has no parent, ErrParentlessNode is returned.
 func (n *Node) AppendSibling(
 	nodeType NodeType,
 	tagNameOrContent string,
 	attributes map[string]string,
-) *Node {
+) (*Node, error) {
+	if n.htmlNode.Parent == nil {
+		return nil, ErrParentlessNode
+	}
+
+	if err := checkFrozen(n); err != nil {
+		return nil, err
+	}
+
 	newNode := prepareNewNode(nodeType, tagNameOrContent, attributes)
 
 	n.htmlNode.Parent.InsertBefore(newNode.HTMLNode(), n.htmlNode.NextSibling)
+	newNode.attach(n.tree)
+	changed(n)
 
-	return newNode
+	return newNode, nil
 }
 
 // PrependSibling prepends a new sibling to the Node.
@@ -350,16 +640,81 @@ func (n *Node) AppendSibling(
 // The newly added node in this approach will be available if you render the NodeManager.
 // However, the newly added node will not be accessible using NodeIterator or Cursor.
 // To add the new node to the cycle, you can use MultiCursor.RegisterNewNode method.
+// If the Node has no parent, ErrParentlessNode is returned.
 func (n *Node) PrependSibling(
 	nodeType NodeType,
 	tagNameOrContent string,
 	attributes map[string]string,
-) *Node {
+) (*Node, error) {
+	if n.htmlNode.Parent == nil {
+		return nil, ErrParentlessNode
+	}
+
+	if err := checkFrozen(n); err != nil {
+		return nil, err
+	}
+
 	newNode := prepareNewNode(nodeType, tagNameOrContent, attributes)
 
 	n.htmlNode.Parent.InsertBefore(newNode.HTMLNode(), n.htmlNode)
+	newNode.attach(n.tree)
+	changed(n)
 
-	return newNode
+	return newNode, nil
+}
+
+// detach removes the Node from its parent, if any.
+func (n *Node) detach() {
+	if n.htmlNode.Parent != nil {
+		n.htmlNode.Parent.RemoveChild(n.htmlNode)
+	}
+}
+
+// attach marks the Node as a part of its current tree after it is added to it.
+// The Node shares the given state of the tree, unless it is nil.
+func (n *Node) attach(tree *treeState) {
+	n.root = topAncestor(n.htmlNode)
+
+	if tree != nil {
+		n.tree = tree
+	}
+}
+
+// changed records a change to the structure of the trees of the given nodes.
+func changed(nodes ...*Node) {
+	for _, node := range nodes {
+		if node.tree != nil {
+			node.tree.version.Add(1)
+		}
+	}
+}
+
+// contains checks whether the given *html.Node is the Node itself or one of its descendants.
+func (n *Node) contains(htmlNode *html.Node) bool {
+	for ; htmlNode != nil; htmlNode = htmlNode.Parent {
+		if htmlNode == n.htmlNode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topAncestor returns the top-most ancestor of the given node, or the node itself
+// if it has no parent.
+func topAncestor(node *html.Node) *html.Node {
+	for node.Parent != nil {
+		node = node.Parent
+	}
+
+	return node
+}
+
+// Clone returns a copy of the Node that is detached from the HTML tree.
+// If deep is true, all descendants of the Node are copied as well; otherwise,
+// the copy has no children. The copy is not a part of any NodeIterator.
+func (n *Node) Clone(deep bool) *Node {
+	return NewNode(cloneHTMLNode(n.htmlNode, deep))
 }
 
 // prepareNewNode creates a new Node with the given nodeType, tagNameOrContent, and attributes.
@@ -388,3 +743,25 @@ func prepareNewNode(
 
 	return newNode
 }
+
+// cloneHTMLNode returns a detached copy of the given node. If deep is true,
+// all descendants of the node are copied as well.
+func cloneHTMLNode(node *html.Node, deep bool) *html.Node {
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+		Attr:      append([]html.Attribute(nil), node.Attr...),
+	}
+
+	if !deep {
+		return clone
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneHTMLNode(child, true))
+	}
+
+	return clone
+}
@@ -1,6 +1,9 @@
 package flattenhtml_test
 
 import (
+	"bytes"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/seinshah/flattenhtml"
@@ -224,11 +227,16 @@ func TestNode_AddNewNode(t *testing.T) {
 		{
 			name: "append sibling to a node",
 			operation: func(node *flattenhtml.Node) *flattenhtml.Node {
-				return node.AppendSibling(
+				newNode, err := node.AppendSibling(
 					flattenhtml.NodeTypeElement,
 					"span",
 					nil,
 				)
+				if err != nil {
+					return nil
+				}
+
+				return newNode
 			},
 			validate: func(baseNode, newNode *flattenhtml.Node) bool {
 				return baseNode.HTMLNode().NextSibling == newNode.HTMLNode() &&
@@ -239,11 +247,16 @@ func TestNode_AddNewNode(t *testing.T) {
 		{
 			name: "prepend sibling to a node",
 			operation: func(node *flattenhtml.Node) *flattenhtml.Node {
-				return node.PrependSibling(
+				newNode, err := node.PrependSibling(
 					flattenhtml.NodeTypeElement,
 					"span",
 					nil,
 				)
+				if err != nil {
+					return nil
+				}
+
+				return newNode
 			},
 			validate: func(baseNode, newNode *flattenhtml.Node) bool {
 				return baseNode.HTMLNode().PrevSibling == newNode.HTMLNode() &&
@@ -283,3 +296,229 @@ func TestNode_AddNewNode(t *testing.T) {
 		})
 	}
 }
+
+func TestNode_Clone(t *testing.T) {
+	t.Parallel()
+
+	div := &html.Node{
+		Data: "div",
+		Type: html.ElementNode,
+		Attr: []html.Attribute{{Key: "class", Val: "box"}},
+	}
+
+	div.AppendChild(&html.Node{Data: "span", Type: html.ElementNode})
+
+	node := flattenhtml.NewNode(div)
+
+	shallow := node.Clone(false)
+	require.Nil(t, shallow.HTMLNode().FirstChild)
+	require.Equal(t, node.Attributes(), shallow.Attributes())
+
+	deep := node.Clone(true)
+	require.NotNil(t, deep.HTMLNode().FirstChild)
+	require.NotSame(t, div.FirstChild, deep.HTMLNode().FirstChild)
+	require.Nil(t, deep.HTMLNode().Parent)
+
+	deep.SetAttribute("class", "changed")
+
+	val, _ := node.Attribute("class")
+	require.Equal(t, "box", val)
+	require.Equal(t, "box", div.Attr[0].Val)
+}
+
+func TestNode_AddSiblingToParentlessNode(t *testing.T) {
+	t.Parallel()
+
+	node := flattenhtml.NewNode(&html.Node{Data: "div", Type: html.ElementNode})
+
+	_, err := node.AppendSibling(flattenhtml.NodeTypeElement, "span", nil)
+	require.ErrorIs(t, err, flattenhtml.ErrParentlessNode)
+
+	_, err = node.PrependSibling(flattenhtml.NodeTypeElement, "span", nil)
+	require.ErrorIs(t, err, flattenhtml.ErrParentlessNode)
+}
+
+func TestNode_Mutations(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := `<div id="a"><p id="p1">one</p><p id="p2">two</p></div><div id="b"></div>`
+
+	testCases := []struct {
+		name      string
+		operation func(t *testing.T, nodes map[string]*flattenhtml.Node)
+		expected  string
+		removed   []string
+	}{
+		{
+			name: "replace with node",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				require.NoError(t, nodes["p1"].ReplaceWith(nodes["b"]))
+			},
+			expected: `<div id="a"><div id="b"></div><p id="p2">two</p></div>`,
+			removed:  []string{"p1"},
+		},
+		{
+			name: "replace with html",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				newNodes, err := nodes["p2"].ReplaceWithHTML(`<span>x</span><em>y</em>`)
+				require.NoError(t, err)
+				require.Len(t, newNodes, 2)
+			},
+			expected: `<div id="a"><p id="p1">one</p><span>x</span><em>y</em></div><div id="b"></div>`,
+			removed:  []string{"p2"},
+		},
+		{
+			name: "wrap",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				wrapper, err := nodes["p1"].Wrap("section", map[string]string{"class": "w"})
+				require.NoError(t, err)
+				require.False(t, wrapper.IsRemoved())
+			},
+			expected: `<div id="a"><section class="w"><p id="p1">one</p></section><p id="p2">two</p></div><div id="b"></div>`,
+		},
+		{
+			name: "unwrap",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				require.NoError(t, nodes["a"].Unwrap())
+			},
+			expected: `<p id="p1">one</p><p id="p2">two</p><div id="b"></div>`,
+			removed:  []string{"a"},
+		},
+		{
+			name: "empty",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				nodes["a"].Empty()
+			},
+			expected: `<div id="a"></div><div id="b"></div>`,
+			removed:  []string{"p1", "p2"},
+		},
+		{
+			name: "move to",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				require.NoError(t, nodes["p2"].MoveTo(nodes["b"], 0))
+				require.NoError(t, nodes["p1"].MoveTo(nodes["b"], -1))
+				require.ErrorIs(t, nodes["a"].MoveTo(nodes["a"], 0), flattenhtml.ErrHierarchyRequest)
+			},
+			expected: `<div id="a"></div><div id="b"><p id="p2">two</p><p id="p1">one</p></div>`,
+		},
+		{
+			name: "insert before and after",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				require.NoError(t, nodes["p2"].InsertBefore(nodes["p1"]))
+				require.NoError(t, nodes["b"].InsertAfter(nodes["p1"]))
+			},
+			expected: `<div id="a"><p id="p2">two</p><p id="p1">one</p><div id="b"></div></div>`,
+		},
+		{
+			name: "remove parent",
+			operation: func(t *testing.T, nodes map[string]*flattenhtml.Node) {
+				require.NoError(t, nodes["a"].Remove())
+			},
+			expected: `<body><div id="b"></div></body>`,
+			removed:  []string{"a", "p1", "p2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML))
+			require.NoError(t, err)
+
+			mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+			require.NoError(t, err)
+
+			nodes := make(map[string]*flattenhtml.Node)
+
+			for _, tag := range []string{"div", "p"} {
+				mc.First().SelectNodes(tag).Each(func(node *flattenhtml.Node) {
+					id, _ := node.Attribute("id")
+					nodes[id] = node
+				})
+			}
+
+			tc.operation(t, nodes)
+
+			rendered := bytes.Buffer{}
+
+			require.NoError(t, nm.Render(&rendered))
+			require.Contains(t, rendered.String(), tc.expected)
+
+			for id, node := range nodes {
+				require.Equal(t, slices.Contains(tc.removed, id), node.IsRemoved(), id)
+			}
+		})
+	}
+}
+
+func TestNodeIterator_NextSkipsRemoved(t *testing.T) {
+	t.Parallel()
+
+	parent := &html.Node{Data: "div", Type: html.ElementNode}
+	first := &html.Node{Data: "a", Type: html.ElementNode}
+	second := &html.Node{Data: "b", Type: html.ElementNode}
+
+	parent.AppendChild(first)
+	parent.AppendChild(second)
+
+	nodeIterator := flattenhtml.NewNodeIterator()
+	nodeIterator.Add(flattenhtml.NewNode(first))
+	nodeIterator.Add(flattenhtml.NewNode(second))
+
+	require.NoError(t, nodeIterator.First().Remove())
+
+	require.Equal(t, "b", nodeIterator.Next().TagName())
+	require.Nil(t, nodeIterator.Next())
+
+	count := 0
+
+	for range nodeIterator.All() {
+		count++
+	}
+
+	require.Equal(t, 1, count)
+}
+
+func TestNode_IsRemovedSharedState(t *testing.T) {
+	t.Parallel()
+
+	parent := &html.Node{Data: "div", Type: html.ElementNode}
+	child := &html.Node{Data: "p", Type: html.ElementNode}
+
+	parent.AppendChild(child)
+
+	first := flattenhtml.NewNode(child)
+	second := flattenhtml.NewNode(child)
+
+	require.NoError(t, first.Remove())
+	require.True(t, first.IsRemoved())
+	require.True(t, second.IsRemoved())
+
+	// Adding the node back using another Node brings both of them back.
+	require.NoError(t, second.MoveTo(flattenhtml.NewNode(parent), 0))
+	require.False(t, first.IsRemoved())
+	require.False(t, second.IsRemoved())
+}
+
+func TestNode_WrapAndRegister(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p role="note">one</p></div>`))
+	require.NoError(t, err)
+
+	tags := flattenhtml.NewTagFlattener()
+	roles := flattenhtml.NewRoleFlattener()
+
+	mc, err := nm.Parse(tags, roles)
+	require.NoError(t, err)
+
+	wrapper, err := tags.GetNodesByKey("p").First().Wrap("section", nil)
+	require.NoError(t, err)
+
+	// The descendants of the wrapper are already indexed, so they are not added again.
+	require.NoError(t, mc.RegisterNewNode(wrapper))
+	require.Equal(t, 1, tags.GetNodesByKey("p").Len())
+	require.Equal(t, 1, tags.GetNodesByKey("section").Len())
+	require.Equal(t, 1, roles.GetNodesByKey("note").Len())
+}
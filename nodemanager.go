@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"sync"
 
 	"golang.org/x/net/html"
 )
@@ -32,6 +33,15 @@ type Flattener interface {
 	Len() int
 }
 
+// FlattenerFactory is an optional interface for the flatteners that can create
+// a new and empty flattener with the same configuration as themselves.
+// Since flatteners are stateful, NodeManager.Clone uses this interface to
+// flatten the copied HTML tree using fresh flatteners.
+type FlattenerFactory interface {
+	// NewFlattener returns a new flattener with no flattened nodes.
+	NewFlattener() Flattener
+}
+
 // NodeManager is an interface for the top-level logic of this package.
 // This package is responsible to parse HTML nodes in some way, perform
 // some modifications or read-only operations on them, and then render
@@ -49,35 +59,95 @@ type Flattener interface {
 //
 // [html.Parse]: https://pkg.go.dev/golang.org/x/net/html#Parse
 type NodeManager struct {
-	root *html.Node
+	root       *html.Node
+	tree       *treeState
+	flatteners []Flattener
+	names      []string
+	config     nodeManagerConfig
+}
+
+// NodeManagerOption is a function that configures the NodeManager.
+type NodeManagerOption func(config *nodeManagerConfig)
+
+// nodeManagerConfig holds the configurations of the NodeManager.
+type nodeManagerConfig struct {
+	concurrent    bool
+	bufferSize    int
+	maxNodes      int
+	maxDepth      int
+	maxAttributes int
+	maxTextBytes  int
+	maxInputBytes int
+	stats         bool
 }
 
 // ErrNoFlattener is returned when no flattener is provided to the Parse method, or
 // no flattener is found in the MultiCursor.
 var ErrNoFlattener = errors.New("at least one flattener should be provided")
 
+// ErrNotFlattenerFactory is returned by NodeManager.Clone when one of the configured
+// flatteners does not implement the FlattenerFactory interface.
+var ErrNotFlattenerFactory = errors.New("flattener does not implement FlattenerFactory")
+
+// WithConcurrentFlattening makes NodeManager.Parse run each flatteners in its own
+// goroutine. The traversal of the HTML tree happens once and each node is sent to
+// the flatteners through buffered channels of the given size, so the Parse time is
+// dominated by the slowest flattener rather than the sum of all of them.
+// Each flattener still receives the nodes in the same order. If a flattener returns
+// an error, the rest of the flatteners are canceled and the first error is returned.
+// It only takes effect when Parse is called with more than one flattener.
+func WithConcurrentFlattening(bufferSize int) NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.concurrent = true
+		config.bufferSize = max(bufferSize, 0)
+	}
+}
+
 // NewNodeManager creates a new DefaultNodeManager with the given
 // *html.Node as the root of the HTML tree.
-func NewNodeManager(root *html.Node) *NodeManager {
-	return &NodeManager{
+func NewNodeManager(root *html.Node, opts ...NodeManagerOption) *NodeManager {
+	nm := &NodeManager{
 		root: root,
+		tree: &treeState{},
+	}
+
+	for _, opt := range opts {
+		opt(&nm.config)
 	}
+
+	return nm
 }
 
 // NewNodeManagerFromReader creates a new DefaultNodeManager with
 // the HTML tree parsed from the given io.Reader.
-func NewNodeManagerFromReader(r io.Reader) (*NodeManager, error) {
-	root, err := html.Parse(r)
+// If the input exceeds the limit of WithMaxInputBytes, a *LimitError is returned.
+func NewNodeManagerFromReader(r io.Reader, opts ...NodeManagerOption) (*NodeManager, error) {
+	return NewNodeManagerFromReaderContext(context.Background(), r, opts...)
+}
+
+// NewNodeManagerFromReaderContext is the same as NewNodeManagerFromReader, but it
+// stops reading the input and returns the error of the context as soon as the given
+// context is done.
+func NewNodeManagerFromReaderContext(
+	ctx context.Context, r io.Reader, opts ...NodeManagerOption,
+) (*NodeManager, error) {
+	config := nodeManagerConfig{}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	root, err := html.Parse(&inputReader{ctx: ctx, reader: r, maxBytes: config.maxInputBytes})
 	if err != nil {
 		return nil, err
 	}
 
-	return NewNodeManager(root), nil
+	return NewNodeManager(root, opts...), nil
 }
 
 // NewNodeManagerFromURL creates a new DefaultNodeManager with the
 // HTML tree parsed from the response body of the given URL.
-func NewNodeManagerFromURL(ctx context.Context, url string) (*NodeManager, error) {
+func NewNodeManagerFromURL(ctx context.Context, url string, opts ...NodeManagerOption) (*NodeManager, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -90,7 +160,7 @@ func NewNodeManagerFromURL(ctx context.Context, url string) (*NodeManager, error
 
 	defer resp.Body.Close()
 
-	return NewNodeManagerFromReader(resp.Body)
+	return NewNodeManagerFromReaderContext(ctx, resp.Body, opts...)
 }
 
 // Parse parses the HTML tree tha has been converted to *html.Node before.
@@ -98,16 +168,97 @@ func NewNodeManagerFromURL(ctx context.Context, url string) (*NodeManager, error
 // traversed and flattened.
 // If any of the flatteners returns an error, the iteration stops and the
 // error is returned.
+// If the NodeManager is created using WithConcurrentFlattening, the flatteners
+// run concurrently in their own goroutines.
+// If the HTML tree exceeds any of the limits configured for the NodeManager, e.g.,
+// using WithMaxNodes, a *LimitError is returned.
+// The flatteners can be registered under names using Named, to be selected later using
+// MultiCursor.Cursor. If two flatteners have the same name, ErrDuplicateName is returned.
 func (n *NodeManager) Parse(flatteners ...Flattener) (*MultiCursor, error) {
+	return n.ParseContext(context.Background(), flatteners...)
+}
+
+// ParseContext is the same as Parse, but it stops the traversal and returns the
+// error of the context as soon as the given context is done.
+func (n *NodeManager) ParseContext(ctx context.Context, flatteners ...Flattener) (*MultiCursor, error) {
 	if len(flatteners) == 0 {
 		return nil, ErrNoFlattener
 	}
 
-	if err := nodeIterator(n.root, flatteners...); err != nil {
+	flatteners, names, err := unwrapNamedFlatteners(flatteners)
+	if err != nil {
 		return nil, err
 	}
 
-	return NewMultiCursor(flatteners...), nil
+	bindTree(n.tree, flatteners...)
+
+	var stats *parseStats
+
+	switch {
+	case n.config.stats:
+		stats, err = parseWithStats(ctx, n.root, n.config, flatteners...)
+	case n.config.concurrent && len(flatteners) > 1:
+		err = concurrentNodeIterator(ctx, n.root, n.config, flatteners...)
+	default:
+		walker := treeWalker{
+			ctx:    ctx,
+			config: n.config,
+			visit: func(node *html.Node) error {
+				for _, flattener := range flatteners {
+					if err := flattener.Flatten(node); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+
+		err = walker.walk(n.root, 0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	n.flatteners = flatteners
+	n.names = names
+
+	return &MultiCursor{flatteners: flatteners, names: names, stats: stats, tree: n.tree}, nil
+}
+
+// Clone returns a deep copy of the NodeManager. Modifying the copy does not
+// affect the original HTML tree and vice versa.
+// If the NodeManager is already parsed, the copied HTML tree is parsed using new
+// instances of the configured flatteners and the resulting MultiCursor is returned.
+// In this case, all flatteners must implement FlattenerFactory; otherwise,
+// ErrNotFlattenerFactory is returned. If the NodeManager is not parsed yet, the
+// returned MultiCursor is nil.
+func (n *NodeManager) Clone() (*NodeManager, *MultiCursor, error) {
+	clone := NewNodeManager(cloneHTMLNode(n.root, true))
+	clone.config = n.config
+
+	if len(n.flatteners) == 0 {
+		return clone, nil, nil
+	}
+
+	flatteners := make([]Flattener, 0, len(n.flatteners))
+
+	for _, flattener := range n.flatteners {
+		factory, ok := flattener.(FlattenerFactory)
+		if !ok {
+			return nil, nil, ErrNotFlattenerFactory
+		}
+
+		flatteners = append(flatteners, factory.NewFlattener())
+	}
+
+	mc, err := clone.Parse(nameFlatteners(flatteners, n.names)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return clone, mc, nil
 }
 
 // Render renders the HTML tree to the given writer.
@@ -115,6 +266,12 @@ func (n *NodeManager) Render(w io.Writer) error {
 	return html.Render(w, n.root)
 }
 
+// Root returns the document node of the HTML tree as a Node.
+// The returned Node is not a part of any NodeIterator created by the flatteners.
+func (n *NodeManager) Root() *Node {
+	return newTreeNode(n.root, n.tree)
+}
+
 // nodeIterator loops through all the *html.Node in the HTML tree.
 // It continues until all the nodes are traversed.
 // For each node that it meets, it calls the callback method of all
@@ -136,3 +293,91 @@ func nodeIterator(node *html.Node, flatteners ...Flattener) error {
 
 	return nodeIterator(node.NextSibling, flatteners...)
 }
+
+// flattenSubtree calls the given flatteners for the node and all its descendants,
+// without visiting the siblings of the node.
+func flattenSubtree(node *html.Node, flatteners ...Flattener) error {
+	for _, flattener := range flatteners {
+		if err := flattener.Flatten(node); err != nil {
+			return err
+		}
+	}
+
+	return nodeIterator(node.FirstChild, flatteners...)
+}
+
+// concurrentNodeIterator traverses the HTML tree once and fans out each node to
+// the given flatteners, each running in its own goroutine and receiving the nodes
+// through a buffered channel. The first error cancels the traversal and the rest
+// of the flatteners, and it is returned after all goroutines are stopped.
+func concurrentNodeIterator(
+	parent context.Context, root *html.Node, config nodeManagerConfig, flatteners ...Flattener,
+) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	channels := make([]chan *html.Node, len(flatteners))
+
+	for i, flattener := range flatteners {
+		channels[i] = make(chan *html.Node, config.bufferSize)
+
+		wg.Add(1)
+
+		go func(flattener Flattener, nodes <-chan *html.Node) {
+			defer wg.Done()
+
+			for node := range nodes {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if err := flattener.Flatten(node); err != nil {
+					once.Do(func() {
+						firstErr = err
+
+						cancel()
+					})
+				}
+			}
+		}(flattener, channels[i])
+	}
+
+	walker := treeWalker{
+		ctx:    ctx,
+		config: config,
+		visit: func(node *html.Node) error {
+			for _, nodes := range channels {
+				select {
+				case nodes <- node:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			return nil
+		},
+	}
+
+	walkErr := walker.walk(root, 0)
+	if walkErr != nil {
+		cancel()
+	}
+
+	for _, nodes := range channels {
+		close(nodes)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return walkErr
+}
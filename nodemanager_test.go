@@ -191,3 +191,103 @@ func TestNodeManager_Render(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeManager_Clone(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := "<html><head></head><body><p>original</p></body></html>"
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML))
+	require.NoError(t, err)
+
+	clone, mc, err := nm.Clone()
+	require.NoError(t, err)
+	require.NotNil(t, clone)
+	require.Nil(t, mc)
+
+	_, err = nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	clone, mc, err = nm.Clone()
+	require.NoError(t, err)
+	require.NotNil(t, mc)
+
+	p := mc.First().SelectNodes("p").First()
+	p.AppendChild(flattenhtml.NodeTypeText, " changed", nil)
+
+	rendered := bytes.Buffer{}
+
+	require.NoError(t, nm.Render(&rendered))
+	require.Equal(t, sampleHTML, rendered.String())
+
+	rendered.Reset()
+
+	require.NoError(t, clone.Render(&rendered))
+	require.Equal(t, "<html><head></head><body><p>original changed</p></body></html>", rendered.String())
+
+	_, err = nm.Parse(&sampleFlattener{})
+	require.NoError(t, err)
+
+	_, _, err = nm.Clone()
+	require.ErrorIs(t, err, flattenhtml.ErrNotFlattenerFactory)
+}
+
+func TestNodeManager_Parse_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := `<html><head><title>t</title></head><body><div><p>a</p><p>b</p></div><span>c</span></body></html>`
+
+	t.Run("same result as sequential parse", func(t *testing.T) {
+		t.Parallel()
+
+		sequential, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML))
+		require.NoError(t, err)
+
+		concurrent, err := flattenhtml.NewNodeManagerFromReader(
+			strings.NewReader(sampleHTML), flattenhtml.WithConcurrentFlattening(1),
+		)
+		require.NoError(t, err)
+
+		expected := []flattenhtml.Flattener{flattenhtml.NewTagFlattener(), &sampleFlattener{}}
+		actual := []flattenhtml.Flattener{flattenhtml.NewTagFlattener(), &sampleFlattener{}}
+
+		_, err = sequential.Parse(expected...)
+		require.NoError(t, err)
+
+		mc, err := concurrent.Parse(actual...)
+		require.NoError(t, err)
+		require.NotNil(t, mc)
+
+		for index := range expected {
+			require.Equal(t, expected[index].Len(), actual[index].Len())
+		}
+
+		for _, tag := range []string{"html", "p", "span"} {
+			expectedNodes := expected[0].GetNodesByKey(tag)
+			actualNodes := actual[0].GetNodesByKey(tag)
+
+			require.Equal(t, expectedNodes.Len(), actualNodes.Len())
+
+			for node := expectedNodes.Next(); node != nil; node = expectedNodes.Next() {
+				expectedHTML, actualHTML := bytes.Buffer{}, bytes.Buffer{}
+
+				require.NoError(t, html.Render(&expectedHTML, node.HTMLNode()))
+				require.NoError(t, html.Render(&actualHTML, actualNodes.Next().HTMLNode()))
+				require.Equal(t, expectedHTML.String(), actualHTML.String())
+			}
+		}
+	})
+
+	t.Run("first error cancels the rest", func(t *testing.T) {
+		t.Parallel()
+
+		nm, err := flattenhtml.NewNodeManagerFromReader(
+			strings.NewReader(sampleHTML), flattenhtml.WithConcurrentFlattening(0),
+		)
+		require.NoError(t, err)
+
+		mc, err := nm.Parse(&sampleFlattener{}, &sampleFlattener{withErr: true})
+		require.ErrorIs(t, err, errSample)
+		require.Nil(t, mc)
+	})
+}
@@ -0,0 +1,338 @@
+package flattenhtml
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// PathFlattener is a Flattener that flattens the HTML tree by the structural
+// address of the nodes. Each node is indexed by its XPath-like path, e.g.,
+// /html[1]/body[1]/div[3]/p[2] or /html[1]/body[1]/p[1]/text()[1], and the
+// elements can also be looked up by their CSS-like unique selector, e.g.,
+// html > body > div:nth-of-type(3) > p:nth-of-type(2). Therefore, you can access
+// a node using the address returned from Node.Path or Node.Selector, e.g., to store
+// annotations against a page and to find the annotated nodes after reloading it.
+//
+// The addresses follow the changes to the structure of the HTML tree that are made
+// using Node, e.g., Node.MoveTo or Node.Remove. After such a change, the flattened
+// nodes are indexed again by their new paths on the next query, and the removed
+// nodes are left out of the index until they are added back to the tree.
+type PathFlattener struct {
+	treeBinding
+
+	// mu guards the index, so the read methods can be called concurrently while it is
+	// built again after a change to the tree.
+	mu        sync.Mutex
+	flattened map[string]*NodeIterator
+	// nodes holds the flattened nodes, to index them again after the tree is changed.
+	nodes map[*html.Node]*Node
+	// paths caches the path of the flattened nodes to compute the path of their
+	// children without walking up to the root.
+	paths map[*html.Node]string
+	// siblings tracks the last flattened child of each parent along with the number
+	// of its children by their name, to compute the position of the next child.
+	siblings map[*html.Node]*pathSiblings
+	// version is the version of the HTML tree that the index is built for.
+	version uint64
+}
+
+// pathSiblings is the state of the flattened children of a parent.
+type pathSiblings struct {
+	last   *html.Node
+	counts map[string]int
+}
+
+var (
+	_ Flattener        = (*PathFlattener)(nil)
+	_ FlattenerFactory = (*PathFlattener)(nil)
+	_ KeyLister        = (*PathFlattener)(nil)
+	_ Serializable     = (*PathFlattener)(nil)
+)
+
+// NewPathFlattener creates a new PathFlattener.
+func NewPathFlattener() *PathFlattener {
+	return &PathFlattener{
+		flattened: make(map[string]*NodeIterator),
+		nodes:     make(map[*html.Node]*Node),
+		paths:     make(map[*html.Node]string),
+		siblings:  make(map[*html.Node]*pathSiblings),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It indexes all the nodes, except the document node, by
+// their path. This method does not return an error.
+func (p *PathFlattener) Flatten(node *html.Node) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// A node is added once, even if it is flattened again.
+	if _, ok := p.nodes[node]; ok || node.Type == html.DocumentNode {
+		return nil
+	}
+
+	p.nodes[node] = p.newNode(node)
+	p.add(p.path(node), p.nodes[node])
+
+	return nil
+}
+
+// GetNodesByKey returns the node with the given path (i.e., /html[1]/body[1]/p[2])
+// or the element with the given selector (i.e., html > body > p:nth-of-type(2)).
+func (p *PathFlattener) GetNodesByKey(key string) *NodeIterator {
+	if !strings.HasPrefix(key, "/") {
+		key = selectorPath(key)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reindex()
+
+	return p.flattened[key]
+}
+
+func (p *PathFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*PathFlattener)
+
+	return ok
+}
+
+// Len for PathFlattener gives you the number of paths in the HTML tree.
+func (p *PathFlattener) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reindex()
+
+	return len(p.flattened)
+}
+
+// Keys returns the keys of the PathFlattener in the sorted order.
+func (p *PathFlattener) Keys() iter.Seq[string] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reindex()
+
+	return sortedKeys(p.flattened)
+}
+
+// NewFlattener returns a new and empty PathFlattener.
+func (p *PathFlattener) NewFlattener() Flattener {
+	return NewPathFlattener()
+}
+
+// Index returns the flattened nodes of the PathFlattener grouped by their paths.
+// The index is replaced, rather than changed, when the nodes are indexed again, so
+// the returned map is not changed by the later queries.
+func (p *PathFlattener) Index() map[string]*NodeIterator {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reindex()
+
+	return p.flattened
+}
+
+// RestoreIndex replaces the flattened nodes of the PathFlattener with the given index.
+// This method does not return an error.
+func (p *PathFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.flattened = index
+	p.nodes = make(map[*html.Node]*Node)
+	p.paths = make(map[*html.Node]string)
+	p.siblings = make(map[*html.Node]*pathSiblings)
+	p.version = p.treeVersion()
+
+	for _, nodes := range index {
+		for _, node := range nodes.nodes {
+			p.nodes[node.htmlNode] = node
+		}
+	}
+
+	return nil
+}
+
+// add adds the node to the given path.
+func (p *PathFlattener) add(path string, node *Node) {
+	if _, ok := p.flattened[path]; !ok {
+		p.flattened[path] = NewNodeIterator()
+	}
+
+	p.flattened[path].Add(node)
+}
+
+// reindex indexes the flattened nodes again by their current paths, if the structure
+// of the HTML tree is changed since the last indexing. The trees of the flattened
+// nodes are walked in the document order, so the paths are computed incrementally.
+// A new index is built and swapped in, and it must be called while holding p.mu.
+func (p *PathFlattener) reindex() {
+	version := p.treeVersion()
+	if p.version == version {
+		return
+	}
+
+	p.flattened = make(map[string]*NodeIterator)
+	p.paths = make(map[*html.Node]string)
+	p.siblings = make(map[*html.Node]*pathSiblings)
+	p.version = version
+
+	roots := make(map[*html.Node]bool)
+
+	for htmlNode, node := range p.nodes {
+		if !node.IsRemoved() {
+			roots[topAncestor(htmlNode)] = true
+		}
+	}
+
+	for root := range roots {
+		walkTree(root, func(htmlNode *html.Node) {
+			if htmlNode.Type == html.DocumentNode {
+				return
+			}
+
+			path := p.path(htmlNode)
+
+			if node, ok := p.nodes[htmlNode]; ok && !node.IsRemoved() {
+				p.add(path, node)
+			}
+		})
+	}
+}
+
+// path returns the path of the node. Since the nodes are flattened in the document
+// order, the path of the parent and the position among the previous siblings are
+// usually known, and the path is computed from scratch only for the other nodes,
+// e.g., the nodes registered later using MultiCursor.RegisterNewNode.
+func (p *PathFlattener) path(node *html.Node) string {
+	if node.Parent == nil {
+		path := nodeLocation(node)
+		p.paths[node] = path
+
+		return path
+	}
+
+	parentPath, known := p.paths[node.Parent]
+	if node.Parent.Type == html.DocumentNode {
+		parentPath, known = "", true
+	}
+
+	siblings := p.siblings[node.Parent]
+
+	if siblings == nil {
+		siblings = &pathSiblings{counts: make(map[string]int)}
+	}
+
+	if !known || siblings.last != node.PrevSibling {
+		path := nodeLocation(node)
+		p.paths[node] = path
+
+		return path
+	}
+
+	name := locationName(node)
+	siblings.counts[name]++
+	siblings.last = node
+	p.siblings[node.Parent] = siblings
+
+	path := parentPath + "/" + name + "[" + strconv.Itoa(siblings.counts[name]) + "]"
+	p.paths[node] = path
+
+	return path
+}
+
+// Path returns the structural address of the node in the HTML tree, e.g.,
+// /html[1]/body[1]/div[3]/p[2]. The position of each node is counted among its
+// siblings of the same tag name, and the text, comment and doctype nodes are
+// addressed using text(), comment() and doctype(), e.g., /html[1]/body[1]/p[1]/text()[1].
+// The path of the document node is "/". The path is computed from the current HTML
+// tree, so it changes if the node or its ancestors are moved.
+func (n *Node) Path() string {
+	if n.htmlNode.Type == html.DocumentNode {
+		return "/"
+	}
+
+	return nodeLocation(n.htmlNode)
+}
+
+// Selector returns a CSS selector that matches the element and nothing else in its
+// HTML tree, e.g., html > body > div:nth-of-type(3) > p:nth-of-type(2). For the
+// nodes that are not an element, it returns the selector of their parent element.
+func (n *Node) Selector() string {
+	node := n.htmlNode
+
+	for node != nil && node.Type != html.ElementNode {
+		node = node.Parent
+	}
+
+	if node == nil {
+		return ""
+	}
+
+	return nodeSelector(node)
+}
+
+// nodeSelector returns the unique CSS selector of the given element.
+func nodeSelector(node *html.Node) string {
+	segments := make([]string, 0)
+
+	for ; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		segment := node.Data
+		position, total := 0, 0
+
+		if node.Parent != nil {
+			for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+					total++
+
+					if sibling == node {
+						position = total
+					}
+				}
+			}
+		}
+
+		if total > 1 {
+			segment += ":nth-of-type(" + strconv.Itoa(position) + ")"
+		}
+
+		segments = append(segments, segment)
+	}
+
+	builder := strings.Builder{}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		builder.WriteString(segments[i])
+
+		if i > 0 {
+			builder.WriteString(" > ")
+		}
+	}
+
+	return builder.String()
+}
+
+// selectorPath converts a selector returned from Node.Selector to the path of the
+// same element, e.g., html > body > p:nth-of-type(2) to /html[1]/body[1]/p[2].
+func selectorPath(selector string) string {
+	builder := strings.Builder{}
+
+	for _, segment := range strings.Split(selector, ">") {
+		name, position := strings.TrimSpace(segment), "1"
+
+		if tag, nth, ok := strings.Cut(name, ":nth-of-type("); ok {
+			name, position = tag, strings.TrimSuffix(nth, ")")
+		}
+
+		builder.WriteString("/" + name + "[" + position + "]")
+	}
+
+	return builder.String()
+}
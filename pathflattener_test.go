@@ -0,0 +1,189 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<div>a</div><div><p>b</p><span></span><p id="target">c</p></div><div></div>`,
+	))
+	require.NoError(t, err)
+
+	paths := flattenhtml.NewPathFlattener()
+
+	mc, err := nm.Parse(paths)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.PathFlattener{})
+	require.NoError(t, err)
+
+	target := cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]").First()
+	require.NotNil(t, target)
+
+	id, _ := target.Attribute("id")
+	require.Equal(t, "target", id)
+	require.Equal(t, "/html[1]/body[1]/div[2]/p[2]", target.Path())
+	require.Equal(t, "html > body > div:nth-of-type(2) > p:nth-of-type(2)", target.Selector())
+	require.Same(t, target.HTMLNode(), cursor.SelectNodes(target.Selector()).First().HTMLNode())
+
+	text := cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]/text()[1]").First()
+	require.NotNil(t, text)
+	require.Equal(t, "/html[1]/body[1]/div[2]/p[2]/text()[1]", text.Path())
+	require.Equal(t, target.Selector(), text.Selector())
+
+	span := cursor.SelectNodes("html > body > div:nth-of-type(2) > span").First()
+	require.NotNil(t, span)
+	require.Equal(t, "span", span.TagName())
+
+	require.Equal(t, "/", nm.Root().Path())
+	require.Equal(t, "", nm.Root().Selector())
+	require.Equal(t, 0, cursor.SelectNodes("/html[1]/body[1]/div[4]").Len())
+
+	keys := slices.Collect(cursor.Keys())
+	require.Len(t, keys, paths.Len())
+	require.Contains(t, keys, "/html[1]/head[1]")
+	require.Equal(t, 1, cursor.SelectNodes("/html[1]").Len())
+
+	// A node that is added and registered later is indexed by its path.
+	third := cursor.SelectNodes("/html[1]/body[1]/div[3]").First()
+	appended, err := third.AppendSibling(flattenhtml.NodeTypeElement, "div", nil)
+	require.NoError(t, err)
+
+	prepended, err := target.PrependSibling(flattenhtml.NodeTypeElement, "p", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mc.RegisterNewNode(appended))
+	require.NoError(t, mc.RegisterNewNode(prepended))
+	require.Equal(t, "/html[1]/body[1]/div[4]", appended.Path())
+	require.Same(t, appended.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[4]").First().HTMLNode())
+	require.Equal(t, "/html[1]/body[1]/div[2]/p[2]", prepended.Path())
+	require.Same(t, prepended.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]").First().HTMLNode())
+	require.Same(t, target.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[3]").First().HTMLNode())
+
+	// Flattening the same node again does not duplicate it.
+	require.NoError(t, mc.RegisterNewNode(appended))
+	require.NoError(t, mc.RegisterNewNode(prepended))
+	require.Equal(t, 1, cursor.SelectNodes("/html[1]/body[1]/div[4]").Len())
+	require.Equal(t, 1, cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]").Len())
+
+	fresh, ok := paths.NewFlattener().(*flattenhtml.PathFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(paths))
+	require.False(t, fresh.IsMyType(flattenhtml.NewTagFlattener()))
+}
+
+func TestPathFlattener_Mutations(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<div><p id="first">a</p><p id="second">b</p></div><div></div>`,
+	))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewPathFlattener())
+	require.NoError(t, err)
+
+	cursor := mc.First()
+	first := cursor.SelectNodes("/html[1]/body[1]/div[1]/p[1]").First()
+	second := cursor.SelectNodes("/html[1]/body[1]/div[1]/p[2]").First()
+	last := cursor.SelectNodes("/html[1]/body[1]/div[2]").First()
+
+	// The nodes are indexed again by their new paths without registering them.
+	require.NoError(t, first.MoveTo(last, -1))
+	require.Same(t, second.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[1]/p[1]").First().HTMLNode())
+	require.Same(t, first.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[1]").First().HTMLNode())
+	require.Same(t, first.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[1]/text()[1]").First().HTMLNode().Parent)
+	require.Equal(t, 0, cursor.SelectNodes("/html[1]/body[1]/div[1]/p[2]").Len())
+
+	// The removed nodes are left out until they are added back to the tree.
+	require.NoError(t, second.Remove())
+	require.Nil(t, cursor.SelectNodes("/html[1]/body[1]/div[1]/p[1]").First())
+	require.NotContains(t, slices.Collect(cursor.Keys()), "/html[1]/body[1]/div[1]/p[1]")
+
+	require.NoError(t, second.InsertBefore(first))
+	require.Same(t, second.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[1]").First().HTMLNode())
+	require.Same(t, first.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]").First().HTMLNode())
+
+	for node := range cursor.SelectNodes("/html[1]/body[1]/div[2]/p[2]").All() {
+		require.Equal(t, "/html[1]/body[1]/div[2]/p[2]", node.Path())
+	}
+}
+
+func TestPathFlattener_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>a</p><p>b</p></div>`))
+	require.NoError(t, err)
+
+	_, err = nm.Parse(flattenhtml.NewPathFlattener())
+	require.NoError(t, err)
+
+	saved := bytes.Buffer{}
+	require.NoError(t, nm.SaveSnapshot(&saved))
+
+	_, mc, err := flattenhtml.NewNodeManagerFromSnapshot(&saved, []flattenhtml.Flattener{flattenhtml.NewPathFlattener()})
+	require.NoError(t, err)
+
+	cursor := mc.First()
+	second := cursor.SelectNodes("/html[1]/body[1]/div[1]/p[2]").First()
+	require.NotNil(t, second)
+	require.Equal(t, "b", second.HTMLNode().FirstChild.Data)
+
+	// The restored index follows the later changes of the tree as well.
+	require.NoError(t, cursor.SelectNodes("/html[1]/body[1]/div[1]/p[1]").First().Remove())
+	require.Same(t, second.HTMLNode(), cursor.SelectNodes("/html[1]/body[1]/div[1]/p[1]").First().HTMLNode())
+}
+
+func TestPathFlattener_ConcurrentReindex(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>1</p><p>2</p></div>`))
+	require.NoError(t, err)
+
+	flattener := flattenhtml.NewPathFlattener()
+
+	_, err = nm.Parse(flattener)
+	require.NoError(t, err)
+
+	other, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>x</p>`))
+	require.NoError(t, err)
+
+	// The changes to another tree do not make the index outdated.
+	require.NotNil(t, other.Root().AppendChild(flattenhtml.NodeTypeElement, "p", nil))
+	require.Equal(t, 1, flattener.GetNodesByKey("/html[1]/body[1]/div[1]/p[2]").Len())
+
+	require.NoError(t, flattener.GetNodesByKey("/html[1]/body[1]/div[1]/p[1]").First().Remove())
+
+	// The index is built again by the first of the concurrent queries.
+	wg := sync.WaitGroup{}
+	found := make([]int, 8)
+
+	for i := range found {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			found[i] = flattener.GetNodesByKey("/html[1]/body[1]/div[1]/p[1]").Len()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, count := range found {
+		require.Equal(t, 1, count)
+	}
+
+	require.Nil(t, flattener.GetNodesByKey("/html[1]/body[1]/div[1]/p[2]"))
+}
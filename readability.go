@@ -0,0 +1,483 @@
+package flattenhtml
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Article is the main content of a HTML document along with its metadata.
+// It is the result of ExtractArticle.
+type Article struct {
+	// Node is the element that holds the main content of the document.
+	Node *Node
+	// Title is the title of the article taken from the meta tags, the <title>
+	// element or the only <h1> element of the document.
+	Title string
+	// Byline is the author of the article, if any.
+	Byline string
+	// Published is the raw value of the published date of the article, if any.
+	// It is usually an ISO 8601 date taken from the meta tags or a <time> element.
+	Published string
+	// Excerpt is a short description of the article, taken from the meta tags or
+	// the first paragraph of the main content.
+	Excerpt string
+}
+
+// ErrNoContent is returned by ExtractArticle when no element qualifies as the
+// main content of the document.
+var ErrNoContent = errors.New("no main content found in the document")
+
+const (
+	// articleMinParagraphLen is the minimum text length of a paragraph to be scored.
+	articleMinParagraphLen = 25
+	// articleParagraphWeight is the score of each paragraph a candidate holds directly.
+	articleParagraphWeight = 1
+)
+
+var (
+	articleUnlikelyPattern = regexp.MustCompile(
+		`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|` +
+			`header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|` +
+			`supplemental|ad-break|agegate|pagination|pager|popup|yom-remote`,
+	)
+	articleMaybePattern    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	articlePositivePattern = regexp.MustCompile(
+		`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`,
+	)
+	articleNegativePattern = regexp.MustCompile(
+		`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|` +
+			`gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|` +
+			`sponsor|shopping|tags|tool|widget`,
+	)
+	articleBylinePattern = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+)
+
+// articleScoredTags are the elements whose text is scored and propagated to
+// their ancestors. These are looked up from the TagFlattener indexes.
+var articleScoredTags = []string{"p", "pre", "td", "div", "article", "section"}
+
+// articleTitleSeparators are the separators that usually split the article title
+// from the site name in the <title> element.
+var articleTitleSeparators = []string{" | ", " - ", " – ", " — ", " :: ", " » "}
+
+// ExtractArticle finds the main content of the document in the style of Mozilla
+// Readability. Candidate blocks are scored by their text length, comma count,
+// paragraph count, link density and class/id hints, and the score of each paragraph
+// is propagated to its ancestors. The highest scored element is returned as the
+// article node.
+//
+// It uses the indexes of the TagFlattener, so the MultiCursor must be created
+// using a TagFlattener; otherwise, ErrNoFlattener is returned. If no element
+// qualifies as the main content, ErrNoContent is returned.
+func ExtractArticle(mc *MultiCursor) (*Article, error) {
+	cursor, err := mc.SelectCursor(&TagFlattener{})
+	if err != nil {
+		return nil, err
+	}
+
+	top := topArticleCandidate(cursor)
+	if top == nil {
+		return nil, ErrNoContent
+	}
+
+	article := &Article{
+		Node:      cursorNode(cursor, top),
+		Title:     articleTitle(cursor),
+		Byline:    articleByline(cursor, top),
+		Published: articlePublished(cursor, top),
+		Excerpt:   firstMetaContent(cursor, "description", "og:description", "twitter:description"),
+	}
+
+	if article.Excerpt == "" {
+		article.Excerpt = articleExcerpt(top)
+	}
+
+	return article, nil
+}
+
+// topArticleCandidate scores the candidate blocks and returns the element with the
+// highest score after adjusting it by its link density. The <body> is scored as
+// well, but it is only returned if no other element qualifies, e.g., for the pages
+// whose paragraphs are not wrapped in any container.
+func topArticleCandidate(cursor *Cursor) *html.Node {
+	scores := make(map[*html.Node]float64)
+	paragraphs := make(map[*html.Node]int)
+	candidates := make([]*html.Node, 0)
+
+	var body *html.Node
+
+	initialize := func(node *html.Node) {
+		if _, ok := scores[node]; ok {
+			return
+		}
+
+		scores[node] = articleTagWeight(node) + articleClassWeight(node)
+
+		if node.Data == "body" {
+			body = node
+		} else {
+			candidates = append(candidates, node)
+		}
+	}
+
+	for _, tag := range articleScoredTags {
+		cursor.SelectNodes(tag).Each(func(node *Node) {
+			element := node.htmlNode
+
+			if !isArticleParagraph(element) || isUnlikelyArticleCandidate(element) {
+				return
+			}
+
+			text := innerText(element)
+			if len(text) < articleMinParagraphLen {
+				return
+			}
+
+			score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+			level := 0
+
+			for ancestor := element.Parent; ancestor != nil && level < 3; ancestor = ancestor.Parent {
+				if ancestor.Type != html.ElementNode || ancestor.Data == "html" {
+					break
+				}
+
+				initialize(ancestor)
+
+				switch level {
+				case 0:
+					scores[ancestor] += score
+					paragraphs[ancestor]++
+				case 1:
+					scores[ancestor] += score / 2
+				default:
+					scores[ancestor] += score / float64(level*3)
+				}
+
+				if ancestor.Data == "body" {
+					break
+				}
+
+				level++
+			}
+		})
+	}
+
+	var (
+		top      *html.Node
+		topScore float64
+	)
+
+	for _, candidate := range candidates {
+		score := articleScore(candidate, scores[candidate], paragraphs[candidate])
+
+		if top == nil || score > topScore {
+			top = candidate
+			topScore = score
+		}
+	}
+
+	if top == nil || topScore <= 0 {
+		if body != nil && articleScore(body, scores[body], paragraphs[body]) > 0 {
+			return body
+		}
+	}
+
+	return top
+}
+
+// articleScore returns the final score of a candidate. Each paragraph that is a
+// direct child of the candidate adds articleParagraphWeight, so the element that
+// holds the paragraphs wins over its ancestors, and the score is reduced by the
+// link density of the candidate.
+func articleScore(candidate *html.Node, score float64, paragraphs int) float64 {
+	return (score + float64(paragraphs)*articleParagraphWeight) * (1 - linkDensity(candidate))
+}
+
+// isArticleParagraph checks whether the element holds paragraph-like text. Divs and
+// sections only count as paragraphs if they do not wrap other block elements.
+func isArticleParagraph(node *html.Node) bool {
+	switch node.Data {
+	case "p", "pre", "td":
+		return true
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+
+		if textParagraphTags[child.Data] || textBlockTags[child.Data] || child.Data == "article" || child.Data == "section" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUnlikelyArticleCandidate checks whether the element or one of its ancestors is
+// unlikely to be a part of the main content, based on their class, id and role.
+func isUnlikelyArticleCandidate(node *html.Node) bool {
+	for ; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		if node.Data == "body" || node.Data == "article" {
+			return false
+		}
+
+		if isHiddenElement(node) {
+			return true
+		}
+
+		if role, _ := htmlAttribute(node, "role"); role == "complementary" || role == "navigation" || role == "menu" {
+			return true
+		}
+
+		class, _ := htmlAttribute(node, "class")
+		id, _ := htmlAttribute(node, "id")
+		hint := class + " " + id
+
+		if articleUnlikelyPattern.MatchString(hint) && !articleMaybePattern.MatchString(hint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// articleTagWeight returns the initial score of a candidate based on its tag name.
+func articleTagWeight(node *html.Node) float64 {
+	switch node.Data {
+	case "article":
+		return 10
+	case "div":
+		return 5
+	case "pre", "td", "blockquote":
+		return 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		return -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		return -5
+	default:
+		return 0
+	}
+}
+
+// articleClassWeight returns the score of a candidate based on its class and id hints.
+func articleClassWeight(node *html.Node) float64 {
+	weight := 0.0
+
+	for _, key := range []string{"class", "id"} {
+		value, ok := htmlAttribute(node, key)
+		if !ok || value == "" {
+			continue
+		}
+
+		if articleNegativePattern.MatchString(value) {
+			weight -= 25
+		}
+
+		if articlePositivePattern.MatchString(value) {
+			weight += 25
+		}
+	}
+
+	return weight
+}
+
+// linkDensity returns the ratio of the text inside links to all text of the element.
+func linkDensity(node *html.Node) float64 {
+	total := len(innerText(node))
+	if total == 0 {
+		return 0
+	}
+
+	linked := 0
+
+	walkTree(node.FirstChild, func(child *html.Node) {
+		if child.Type == html.ElementNode && child.Data == "a" {
+			linked += len(innerText(child))
+		}
+	})
+
+	return float64(linked) / float64(total)
+}
+
+// articleTitle returns the title of the document from the meta tags, the <title>
+// element without the site name, or the only <h1> of the document.
+func articleTitle(cursor *Cursor) string {
+	if title := firstMetaContent(cursor, "og:title", "twitter:title", "title"); title != "" {
+		return title
+	}
+
+	title := ""
+
+	if node := cursor.SelectNodes("title").First(); node != nil {
+		title = innerText(node.htmlNode)
+	}
+
+	for _, separator := range articleTitleSeparators {
+		if index := strings.LastIndex(title, separator); index > 0 {
+			if candidate := title[:index]; len(strings.Fields(candidate)) >= 3 {
+				return candidate
+			}
+		}
+	}
+
+	if headings := cursor.SelectNodes("h1"); title == "" && headings.Len() == 1 {
+		return innerText(headings.First().htmlNode)
+	}
+
+	return title
+}
+
+// articleByline returns the author of the article from the meta tags or the elements
+// that are marked as the author using rel, itemprop, class or id.
+func articleByline(cursor *Cursor, top *html.Node) string {
+	if byline := firstMetaContent(cursor, "author", "article:author", "dc.creator"); byline != "" {
+		return byline
+	}
+
+	byline := ""
+
+	for _, tag := range []string{"a", "span", "p", "div", "address"} {
+		cursor.SelectNodes(tag).Each(func(node *Node) {
+			if byline != "" || !isByline(node.htmlNode) {
+				return
+			}
+
+			// Prefer the bylines inside or around the main content.
+			if text := innerText(node.htmlNode); text != "" && len(text) < 100 && isNear(node.htmlNode, top) {
+				byline = text
+			}
+		})
+	}
+
+	return byline
+}
+
+// isByline checks whether the element is marked as the author of the document.
+func isByline(node *html.Node) bool {
+	if rel, _ := htmlAttribute(node, "rel"); rel == "author" {
+		return true
+	}
+
+	if itemprop, _ := htmlAttribute(node, "itemprop"); strings.Contains(itemprop, "author") {
+		return true
+	}
+
+	class, _ := htmlAttribute(node, "class")
+	id, _ := htmlAttribute(node, "id")
+
+	return articleBylinePattern.MatchString(class + " " + id)
+}
+
+// articlePublished returns the published date from the meta tags or a <time> element
+// inside the main content.
+func articlePublished(cursor *Cursor, top *html.Node) string {
+	published := firstMetaContent(
+		cursor, "article:published_time", "datePublished", "date", "pubdate", "dc.date", "og:published_time",
+	)
+	if published != "" {
+		return published
+	}
+
+	cursor.SelectNodes("time").Each(func(node *Node) {
+		if published != "" || !isNear(node.htmlNode, top) {
+			return
+		}
+
+		if datetime, ok := node.Attribute("datetime"); ok && datetime != "" {
+			published = datetime
+		} else {
+			published = innerText(node.htmlNode)
+		}
+	})
+
+	return published
+}
+
+// articleExcerpt returns the text of the first paragraph of the main content.
+func articleExcerpt(top *html.Node) string {
+	excerpt := ""
+
+	walkTree(top.FirstChild, func(node *html.Node) {
+		if excerpt == "" && node.Type == html.ElementNode && node.Data == "p" {
+			excerpt = innerText(node)
+		}
+	})
+
+	return excerpt
+}
+
+// firstMetaContent returns the content of the first <meta> element whose name,
+// property or itemprop matches one of the given names, in the order of the names.
+func firstMetaContent(cursor *Cursor, names ...string) string {
+	metas := cursor.SelectNodes("meta")
+
+	for _, name := range names {
+		content := ""
+
+		metas.Each(func(node *Node) {
+			if content != "" {
+				return
+			}
+
+			for _, key := range []string{"name", "property", "itemprop"} {
+				if value, ok := node.Attribute(key); ok && strings.EqualFold(value, name) {
+					content, _ = node.Attribute("content")
+					content = strings.TrimSpace(content)
+				}
+			}
+		})
+
+		if content != "" {
+			return content
+		}
+	}
+
+	return ""
+}
+
+// isNear checks whether the node is inside the given container or its parent.
+func isNear(node, container *html.Node) bool {
+	if container.Parent != nil && container.Parent.Type == html.ElementNode && container.Parent.Data != "body" {
+		container = container.Parent
+	}
+
+	for ; node != nil; node = node.Parent {
+		if node == container {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cursorNode returns the Node of the cursor that wraps the given *html.Node,
+// so the caller shares the same Node with the flattener indexes.
+func cursorNode(cursor *Cursor, htmlNode *html.Node) *Node {
+	var found *Node
+
+	cursor.SelectNodes(htmlNode.Data).Each(func(node *Node) {
+		if found == nil && node.htmlNode == htmlNode {
+			found = node
+		}
+	})
+
+	if found == nil {
+		found = NewNode(htmlNode)
+	}
+
+	return found
+}
+
+// innerText returns the text content of the node with collapsed whitespace.
+func innerText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return strings.Join(strings.Fields(node.Data), " ")
+	}
+
+	return strings.Join(strings.Fields(textContent(node)), " ")
+}
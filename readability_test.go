@@ -0,0 +1,81 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractArticle(t *testing.T) {
+	t.Parallel()
+
+	rawHTML := `<html><head>
+		<title>Rivers are rising again this spring | Daily News</title>
+		<meta name="author" content="Jane Doe">
+		<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+	</head><body>
+		<div class="menu"><a href="/">Home</a> <a href="/news">News, politics, weather and more</a></div>
+		<div id="main-content" class="post">
+			<p>The rivers in the northern valleys are rising again, and the officials are worried.</p>
+			<p>Heavy rain, melting snow and blocked channels have pushed the levels above the average.</p>
+			<p>Residents were told to prepare sandbags, move cars and follow the local announcements.</p>
+		</div>
+		<div class="sidebar comments"><p>This is a very long comment that should never be the main content, really.</p></div>
+	</body></html>`
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(rawHTML))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	article, err := flattenhtml.ExtractArticle(mc)
+	require.NoError(t, err)
+
+	id, _ := article.Node.Attribute("id")
+	require.Equal(t, "main-content", id)
+	require.Equal(t, "Rivers are rising again this spring", article.Title)
+	require.Equal(t, "Jane Doe", article.Byline)
+	require.Equal(t, "2024-03-01T10:00:00Z", article.Published)
+	require.True(t, strings.HasPrefix(article.Excerpt, "The rivers in the northern valleys"))
+}
+
+func TestExtractArticle_NoWrapper(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<html><body>
+		<p>The rivers in the northern valleys are rising again, and the officials are worried.</p>
+		<p>Heavy rain, melting snow and blocked channels have pushed the levels above the average.</p>
+		<p>Residents were told to prepare sandbags, move cars and follow the local announcements.</p>
+	</body></html>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	article, err := flattenhtml.ExtractArticle(mc)
+	require.NoError(t, err)
+	require.Equal(t, "body", article.Node.TagName())
+	require.True(t, strings.HasPrefix(article.Excerpt, "The rivers in the northern valleys"))
+}
+
+func TestExtractArticle_Errors(t *testing.T) {
+	t.Parallel()
+
+	mc, err := flattenhtml.NewMultiCursor()
+	require.NoError(t, err)
+
+	_, err = flattenhtml.ExtractArticle(mc)
+	require.ErrorIs(t, err, flattenhtml.ErrNoFlattener)
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><a href="/">short</a></div>`))
+	require.NoError(t, err)
+
+	mc, err = nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	_, err = flattenhtml.ExtractArticle(mc)
+	require.ErrorIs(t, err, flattenhtml.ErrNoContent)
+}
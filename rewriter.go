@@ -0,0 +1,423 @@
+package flattenhtml
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// URLKind describes where in the HTML document a rewritten URL was found.
+// It allows the RewriteFunc to treat URLs differently based on their origin,
+// e.g., only rewriting links to images or leaving anchors untouched.
+type URLKind int
+
+// RewriteFunc is called for every URL that the Rewriter finds in the HTML tree.
+// It receives the kind of the URL along with the parsed URL and returns the new
+// URL and a boolean that indicates whether the URL should be replaced.
+// If the boolean is false, the original value is kept untouched.
+type RewriteFunc func(kind URLKind, u *url.URL) (*url.URL, bool)
+
+// Rewriter applies a RewriteFunc to all URL-bearing parts of the HTML tree
+// managed by a NodeManager. It covers href and src like attributes, including
+// the xlink:href attribute of SVG elements, srcset candidate lists, url(...) values of inline style attributes and the target
+// of <meta http-equiv="refresh"> elements.
+type Rewriter struct {
+	fn RewriteFunc
+}
+
+const (
+	// URLKindHref is used for href attributes, e.g., <a>, <link> and <area>.
+	URLKindHref URLKind = iota
+	// URLKindSrc is used for src attributes, e.g., <img>, <script> and <iframe>.
+	URLKindSrc
+	// URLKindSrcset is used for each candidate of srcset and imagesrcset attributes.
+	URLKindSrcset
+	// URLKindAction is used for action and formaction attributes of forms and buttons.
+	URLKindAction
+	// URLKindPoster is used for the poster attribute of <video>.
+	URLKindPoster
+	// URLKindCite is used for the cite attribute of <blockquote>, <q>, <del> and <ins>.
+	URLKindCite
+	// URLKindData is used for the data attribute of <object>.
+	URLKindData
+	// URLKindStyle is used for url(...) values inside inline style attributes.
+	URLKindStyle
+	// URLKindMetaRefresh is used for the target URL of <meta http-equiv="refresh">.
+	URLKindMetaRefresh
+)
+
+// urlAttributes maps the URL-bearing attribute names to their URLKind.
+var urlAttributes = map[string]URLKind{
+	"href":       URLKindHref,
+	"src":        URLKindSrc,
+	"action":     URLKindAction,
+	"formaction": URLKindAction,
+	"poster":     URLKindPoster,
+	"cite":       URLKindCite,
+	"data":       URLKindData,
+}
+
+// urlAttributeElements limits the URL-bearing attributes that are generic names to
+// the elements that define them as a URL, e.g., data is a URL only on <object>.
+var urlAttributeElements = map[string]map[atom.Atom]bool{
+	"cite": {atom.Blockquote: true, atom.Q: true, atom.Del: true, atom.Ins: true},
+	"data": {atom.Object: true},
+}
+
+var styleURLPattern = regexp.MustCompile(`(?i)url\(\s*('[^']*'|"[^"]*"|[^)'"\s]*)\s*\)`)
+
+// String returns a human-readable name of the URLKind.
+func (k URLKind) String() string {
+	switch k {
+	case URLKindHref:
+		return "href"
+	case URLKindSrc:
+		return "src"
+	case URLKindSrcset:
+		return "srcset"
+	case URLKindAction:
+		return "action"
+	case URLKindPoster:
+		return "poster"
+	case URLKindCite:
+		return "cite"
+	case URLKindData:
+		return "data"
+	case URLKindStyle:
+		return "style"
+	case URLKindMetaRefresh:
+		return "meta-refresh"
+	default:
+		return "unknown"
+	}
+}
+
+// NewRewriter creates a new Rewriter that uses the given RewriteFunc
+// to decide the new value of each URL.
+func NewRewriter(fn RewriteFunc) *Rewriter {
+	return &Rewriter{
+		fn: fn,
+	}
+}
+
+// Rewrite traverses the HTML tree of the given NodeManager and rewrites all URLs
+// that the RewriteFunc asks for. Values that cannot be parsed as a URL are kept
+// untouched. It returns the number of URLs that were replaced.
+// The changes are applied directly on the html.Node tree, and the Node values that
+// are already flattened read the new values, the same as after Node.SetAttribute.
+// If the HTML tree is frozen using MultiCursor.Freeze, nothing is rewritten.
+func (r *Rewriter) Rewrite(nm *NodeManager) int {
+	replaced := 0
+
+	if nm.tree.isFrozen() {
+		return replaced
+	}
+
+	walkTree(nm.root, func(node *html.Node) {
+		if node.Type != html.ElementNode {
+			return
+		}
+
+		for i, attr := range node.Attr {
+			if attr.Namespace != "" && !isXLinkHref(attr) {
+				continue
+			}
+
+			value, count := r.rewriteAttribute(node, attr)
+			if count == 0 {
+				continue
+			}
+
+			node.Attr[i].Val = value
+			replaced += count
+		}
+	})
+
+	return replaced
+}
+
+// Render rewrites the URLs of the given NodeManager using Rewriter.Rewrite and then
+// renders the HTML tree to the given writer.
+func (r *Rewriter) Render(nm *NodeManager, w io.Writer) error {
+	r.Rewrite(nm)
+
+	return nm.Render(w)
+}
+
+// rewriteAttribute returns the rewritten value of the given attribute along with the
+// number of URLs that were replaced inside it.
+func (r *Rewriter) rewriteAttribute(node *html.Node, attr html.Attribute) (string, int) {
+	key := strings.ToLower(attr.Key)
+
+	switch {
+	case key == "srcset" || key == "imagesrcset":
+		return r.rewriteSrcset(attr.Val)
+	case key == "style":
+		return r.rewriteStyle(attr.Val)
+	case key == "content" && isMetaRefresh(node):
+		return r.rewriteMetaRefresh(attr.Val)
+	}
+
+	kind, ok := urlAttributes[key]
+	if !ok {
+		return attr.Val, 0
+	}
+
+	if elements, ok := urlAttributeElements[key]; ok && !elements[node.DataAtom] {
+		return attr.Val, 0
+	}
+
+	value, ok := r.apply(kind, strings.TrimSpace(attr.Val))
+	if !ok {
+		return attr.Val, 0
+	}
+
+	return value, 1
+}
+
+// isXLinkHref checks whether the given attribute is the xlink:href attribute, which
+// is the href of the SVG elements, e.g., <use> and <image>, in the older documents.
+func isXLinkHref(attr html.Attribute) bool {
+	return attr.Namespace == "xlink" && strings.EqualFold(attr.Key, "href")
+}
+
+// rewriteSrcset rewrites each URL of a srcset candidate list and keeps the
+// descriptors (e.g., 2x or 480w) as they are.
+func (r *Rewriter) rewriteSrcset(value string) (string, int) {
+	candidates := parseSrcset(value)
+	if len(candidates) == 0 {
+		return value, 0
+	}
+
+	replaced := 0
+	parts := make([]string, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		if newURL, ok := r.apply(URLKindSrcset, candidate.url); ok {
+			candidate.url = newURL
+			replaced++
+		}
+
+		if candidate.descriptor == "" {
+			parts = append(parts, candidate.url)
+		} else {
+			parts = append(parts, candidate.url+" "+candidate.descriptor)
+		}
+	}
+
+	if replaced == 0 {
+		return value, 0
+	}
+
+	return strings.Join(parts, ", "), replaced
+}
+
+// rewriteStyle rewrites all url(...) values of an inline style declaration.
+func (r *Rewriter) rewriteStyle(value string) (string, int) {
+	replaced := 0
+
+	result := styleURLPattern.ReplaceAllStringFunc(value, func(match string) string {
+		raw := styleURLPattern.FindStringSubmatch(match)[1]
+		quote := ""
+
+		if len(raw) >= 2 && (raw[0] == '\'' || raw[0] == '"') {
+			quote = raw[:1]
+			raw = raw[1 : len(raw)-1]
+		}
+
+		newURL, ok := r.apply(URLKindStyle, raw)
+		if !ok {
+			return match
+		}
+
+		replaced++
+
+		return "url(" + quote + newURL + quote + ")"
+	})
+
+	return result, replaced
+}
+
+// rewriteMetaRefresh rewrites the URL part of a meta refresh content, such as
+// "5; url=https://example.com".
+func (r *Rewriter) rewriteMetaRefresh(value string) (string, int) {
+	prefix, target, suffix, ok := splitMetaRefresh(value)
+	if !ok {
+		return value, 0
+	}
+
+	newURL, ok := r.apply(URLKindMetaRefresh, target)
+	if !ok {
+		return value, 0
+	}
+
+	return prefix + newURL + suffix, 1
+}
+
+// apply parses the given raw URL and passes it to the RewriteFunc.
+// It returns false if the URL cannot be parsed or the RewriteFunc rejects it.
+func (r *Rewriter) apply(kind URLKind, raw string) (string, bool) {
+	if raw == "" {
+		return "", false
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	newURL, ok := r.fn(kind, parsed)
+	if !ok || newURL == nil {
+		return "", false
+	}
+
+	return newURL.String(), true
+}
+
+// srcsetCandidate is a single image candidate of a srcset attribute.
+type srcsetCandidate struct {
+	url        string
+	descriptor string
+}
+
+// parseSrcset splits a srcset attribute into its image candidates following the
+// parsing rules of the HTML specification. URLs can contain commas (i.e., data URLs),
+// so it is not possible to simply split the value by comma.
+func parseSrcset(value string) []srcsetCandidate {
+	candidates := make([]srcsetCandidate, 0)
+	pos := 0
+
+	for pos < len(value) {
+		for pos < len(value) && (isHTMLSpace(value[pos]) || value[pos] == ',') {
+			pos++
+		}
+
+		if pos >= len(value) {
+			break
+		}
+
+		start := pos
+
+		for pos < len(value) && !isHTMLSpace(value[pos]) {
+			pos++
+		}
+
+		candidateURL := value[start:pos]
+
+		// A URL ending with commas has no descriptor.
+		if strings.HasSuffix(candidateURL, ",") {
+			candidates = append(candidates, srcsetCandidate{url: strings.TrimRight(candidateURL, ",")})
+
+			continue
+		}
+
+		start = pos
+		inParens := false
+
+		for pos < len(value) {
+			if value[pos] == '(' {
+				inParens = true
+			} else if value[pos] == ')' {
+				inParens = false
+			} else if value[pos] == ',' && !inParens {
+				break
+			}
+
+			pos++
+		}
+
+		candidates = append(candidates, srcsetCandidate{
+			url:        candidateURL,
+			descriptor: strings.TrimSpace(value[start:pos]),
+		})
+	}
+
+	return candidates
+}
+
+// splitMetaRefresh splits the content of a meta refresh element into the part
+// before the URL, the URL itself and the part after the URL.
+func splitMetaRefresh(value string) (string, string, string, bool) {
+	pos := 0
+
+	for pos < len(value) && (isHTMLSpace(value[pos]) || (value[pos] >= '0' && value[pos] <= '9') || value[pos] == '.') {
+		pos++
+	}
+
+	for pos < len(value) && (isHTMLSpace(value[pos]) || value[pos] == ';' || value[pos] == ',') {
+		pos++
+	}
+
+	if pos+3 <= len(value) && strings.EqualFold(value[pos:pos+3], "url") {
+		rest := pos + 3
+
+		for rest < len(value) && isHTMLSpace(value[rest]) {
+			rest++
+		}
+
+		if rest < len(value) && value[rest] == '=' {
+			pos = rest + 1
+
+			for pos < len(value) && isHTMLSpace(value[pos]) {
+				pos++
+			}
+		}
+	}
+
+	end := len(value)
+
+	if pos < len(value) && (value[pos] == '\'' || value[pos] == '"') {
+		if closing := strings.IndexByte(value[pos+1:], value[pos]); closing >= 0 {
+			end = pos + 1 + closing
+		}
+
+		pos++
+	}
+
+	for pos < end && isHTMLSpace(value[pos]) {
+		pos++
+	}
+
+	for end > pos && isHTMLSpace(value[end-1]) {
+		end--
+	}
+
+	if pos == end {
+		return "", "", "", false
+	}
+
+	return value[:pos], value[pos:end], value[end:], true
+}
+
+// isMetaRefresh checks whether the given node is a <meta http-equiv="refresh"> element.
+func isMetaRefresh(node *html.Node) bool {
+	if node.Data != "meta" {
+		return false
+	}
+
+	for _, attr := range node.Attr {
+		if strings.EqualFold(attr.Key, "http-equiv") && strings.EqualFold(strings.TrimSpace(attr.Val), "refresh") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHTMLSpace reports whether the given byte is an ASCII whitespace as defined by HTML.
+func isHTMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
+
+// walkTree calls the given function for every node in the HTML tree in depth-first order.
+func walkTree(node *html.Node, fn func(node *html.Node)) {
+	for ; node != nil; node = node.NextSibling {
+		fn(node)
+
+		walkTree(node.FirstChild, fn)
+	}
+}
@@ -0,0 +1,146 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriter(t *testing.T) {
+	t.Parallel()
+
+	toCDN := func(kind flattenhtml.URLKind, u *url.URL) (*url.URL, bool) {
+		if kind == flattenhtml.URLKindHref || u.IsAbs() {
+			return nil, false
+		}
+
+		return &url.URL{Scheme: "https", Host: "cdn.test", Path: "/" + strings.TrimPrefix(u.Path, "/")}, true
+	}
+
+	testCases := []struct {
+		name     string
+		body     string
+		fn       flattenhtml.RewriteFunc
+		expected string
+		replaced int
+	}{
+		{
+			name:     "src and href",
+			body:     `<a href="/page"><img src="img/a.png"/></a>`,
+			fn:       toCDN,
+			expected: `<a href="/page"><img src="https://cdn.test/img/a.png"/></a>`,
+			replaced: 1,
+		},
+		{
+			name:     "srcset candidates",
+			body:     `<img srcset="a.png 1x, https://other.test/b.png 2x,c.png"/>`,
+			fn:       toCDN,
+			expected: `<img srcset="https://cdn.test/a.png 1x, https://other.test/b.png 2x, https://cdn.test/c.png"/>`,
+			replaced: 2,
+		},
+		{
+			name:     "inline style url values",
+			body:     `<div style="background: url('bg.png') no-repeat; mask: url(m.svg)"></div>`,
+			fn:       toCDN,
+			expected: `<div style="background: url(&#39;https://cdn.test/bg.png&#39;) no-repeat; mask: url(https://cdn.test/m.svg)"></div>`,
+			replaced: 2,
+		},
+		{
+			name:     "meta refresh target",
+			body:     `<meta http-equiv="refresh" content="5; url=/next"/>`,
+			fn:       toCDN,
+			expected: `<meta http-equiv="refresh" content="5; url=https://cdn.test/next"/>`,
+			replaced: 1,
+		},
+		{
+			name:     "cite and data only on their elements",
+			body:     `<blockquote cite="q.html"></blockquote><object data="o.swf"></object><div cite="x" data="y"></div>`,
+			fn:       toCDN,
+			expected: `<blockquote cite="https://cdn.test/q.html"></blockquote><object data="https://cdn.test/o.swf"></object><div cite="x" data="y"></div>`,
+			replaced: 2,
+		},
+		{
+			name: "xlink href of svg elements",
+			body: `<svg><use xlink:href="icons.svg#cart"></use><image href="a.png"></image></svg>`,
+			fn: func(_ flattenhtml.URLKind, u *url.URL) (*url.URL, bool) {
+				return &url.URL{Scheme: "https", Host: "cdn.test", Path: "/" + u.Path, Fragment: u.Fragment}, true
+			},
+			expected: `<svg><use xlink:href="https://cdn.test/icons.svg#cart"></use><image href="https://cdn.test/a.png"></image></svg>`,
+			replaced: 2,
+		},
+		{
+			name: "rejected by the rewrite func",
+			body: `<a href="/page">page</a>`,
+			fn: func(_ flattenhtml.URLKind, _ *url.URL) (*url.URL, bool) {
+				return nil, false
+			},
+			expected: `<a href="/page">page</a>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rewriter := flattenhtml.NewRewriter(tc.fn)
+			require.Equal(t, tc.replaced, rewriter.Rewrite(nm))
+
+			rendered := bytes.Buffer{}
+
+			require.NoError(t, nm.Render(&rendered))
+			require.Contains(t, rendered.String(), tc.expected)
+		})
+	}
+}
+
+func TestRewriter_Render(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<a href="http://old.test/x">x</a>`))
+	require.NoError(t, err)
+
+	rewriter := flattenhtml.NewRewriter(func(kind flattenhtml.URLKind, u *url.URL) (*url.URL, bool) {
+		require.Equal(t, flattenhtml.URLKindHref, kind)
+
+		u.Host = "new.test"
+
+		return u, true
+	})
+
+	rendered := bytes.Buffer{}
+
+	require.NoError(t, rewriter.Render(nm, &rendered))
+	require.Contains(t, rendered.String(), `<a href="http://new.test/x">x</a>`)
+}
+
+func TestRewriter_FlattenedNodes(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<img src="a.png">`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	img := mc.First().SelectNodes("img").First()
+	require.NotNil(t, img)
+
+	rewriter := flattenhtml.NewRewriter(func(_ flattenhtml.URLKind, u *url.URL) (*url.URL, bool) {
+		u.Path = "/b.png"
+
+		return u, true
+	})
+	require.Equal(t, 1, rewriter.Rewrite(nm))
+
+	src, ok := img.Attribute("src")
+	require.True(t, ok)
+	require.Equal(t, "/b.png", src)
+	require.Equal(t, "/b.png", img.Attributes()["src"])
+}
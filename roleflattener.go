@@ -0,0 +1,258 @@
+package flattenhtml
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// RoleFlattener is a Flattener that flattens the elements by their WAI-ARIA role,
+// i.e., the value returned by Node.Role. An element is indexed by its explicit role
+// attribute, or by the implicit role of its tag, so the "button" key returns the
+// <button> and <input type="submit"> elements along with <div role="button">. Along
+// with the WithAccessibleName filter, it locates the elements by their role and
+// name, the same as the role queries of the testing libraries:
+//
+//	cursor.SelectNodes("button").Filter(flattenhtml.WithAccessibleName("Save"))
+//
+// The roles are computed when the elements are flattened, so they do not follow
+// the later changes of the attributes.
+type RoleFlattener struct {
+	treeBinding
+
+	flattened map[string]*NodeIterator
+	nodes     map[*html.Node]bool
+}
+
+var (
+	_ Flattener        = (*RoleFlattener)(nil)
+	_ FlattenerFactory = (*RoleFlattener)(nil)
+	_ KeyLister        = (*RoleFlattener)(nil)
+	_ Serializable     = (*RoleFlattener)(nil)
+)
+
+// NewRoleFlattener creates a new RoleFlattener.
+func NewRoleFlattener() *RoleFlattener {
+	return &RoleFlattener{
+		flattened: make(map[string]*NodeIterator),
+		nodes:     make(map[*html.Node]bool),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It indexes the elements that have a role by their role.
+// This method does not return an error.
+func (r *RoleFlattener) Flatten(node *html.Node) error {
+	// An element is added once, even if it is flattened again.
+	if node.Type != html.ElementNode || r.nodes[node] {
+		return nil
+	}
+
+	r.nodes[node] = true
+
+	role := nodeRole(node)
+	if role == "" {
+		return nil
+	}
+
+	if _, ok := r.flattened[role]; !ok {
+		r.flattened[role] = NewNodeIterator()
+	}
+
+	r.flattened[role].Add(r.newNode(node))
+
+	return nil
+}
+
+// GetNodesByKey returns the elements with the given role, e.g., "button".
+func (r *RoleFlattener) GetNodesByKey(key string) *NodeIterator {
+	return r.flattened[strings.ToLower(key)]
+}
+
+func (r *RoleFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*RoleFlattener)
+
+	return ok
+}
+
+// Len for RoleFlattener gives you the number of distinct roles in the HTML tree.
+func (r *RoleFlattener) Len() int {
+	return len(r.flattened)
+}
+
+// Keys returns the keys of the RoleFlattener in the sorted order.
+func (r *RoleFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(r.flattened)
+}
+
+// NewFlattener returns a new and empty RoleFlattener.
+func (r *RoleFlattener) NewFlattener() Flattener {
+	return NewRoleFlattener()
+}
+
+// Index returns the flattened elements of the RoleFlattener grouped by their roles.
+func (r *RoleFlattener) Index() map[string]*NodeIterator {
+	return r.flattened
+}
+
+// RestoreIndex replaces the flattened elements of the RoleFlattener with the given index.
+// This method does not return an error.
+func (r *RoleFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	r.flattened = index
+	r.nodes = make(map[*html.Node]bool)
+
+	for _, nodes := range index {
+		for _, node := range nodes.nodes {
+			r.nodes[node.htmlNode] = true
+		}
+	}
+
+	return nil
+}
+
+// Role returns the WAI-ARIA role of the element. It is the first valid role of its
+// role attribute, or otherwise the implicit role of its tag as defined by the HTML
+// Accessibility API Mappings, e.g., "link" for <a href>, "heading" for <h2>, and
+// "checkbox" for <input type="checkbox">. It returns an empty string for the nodes
+// that are not an element and for the elements that have no role, e.g., <head>.
+func (n *Node) Role() string {
+	if n.htmlNode.Type != html.ElementNode {
+		return ""
+	}
+
+	return nodeRole(n.htmlNode)
+}
+
+// nodeRole returns the explicit or the implicit role of the given element.
+func nodeRole(node *html.Node) string {
+	for _, role := range strings.Fields(strings.ToLower(trimmedAttribute(node, "role"))) {
+		if ariaRoles[role] {
+			return role
+		}
+	}
+
+	return implicitRole(node)
+}
+
+// implicitRoles is the implicit role of the tags that have the same role
+// regardless of their attributes and position.
+var implicitRoles = map[atom.Atom]string{
+	atom.Article: "article", atom.Aside: "complementary", atom.B: "generic", atom.Bdi: "generic",
+	atom.Bdo: "generic", atom.Blockquote: "blockquote", atom.Body: "generic", atom.Button: "button",
+	atom.Caption: "caption", atom.Code: "code", atom.Data: "generic", atom.Datalist: "listbox",
+	atom.Dd: "definition", atom.Del: "deletion", atom.Details: "group", atom.Dfn: "term",
+	atom.Dialog: "dialog", atom.Div: "generic", atom.Dt: "term", atom.Em: "emphasis",
+	atom.Fieldset: "group", atom.Figure: "figure", atom.Form: "form", atom.H1: "heading",
+	atom.H2: "heading", atom.H3: "heading", atom.H4: "heading", atom.H5: "heading", atom.H6: "heading",
+	atom.Hgroup: "group", atom.Hr: "separator", atom.Html: "document", atom.I: "generic",
+	atom.Ins: "insertion", atom.Li: "listitem", atom.Main: "main", atom.Math: "math", atom.Menu: "list",
+	atom.Meter: "meter", atom.Nav: "navigation", atom.Ol: "list", atom.Optgroup: "group",
+	atom.Option: "option", atom.Output: "status", atom.P: "paragraph", atom.Pre: "generic",
+	atom.Progress: "progressbar", atom.Q: "generic", atom.S: "deletion", atom.Samp: "generic",
+	atom.Search: "search", atom.Small: "generic", atom.Span: "generic", atom.Strong: "strong",
+	atom.Sub: "subscript", atom.Sup: "superscript", atom.Table: "table", atom.Tbody: "rowgroup",
+	atom.Td: "cell", atom.Textarea: "textbox", atom.Tfoot: "rowgroup", atom.Thead: "rowgroup",
+	atom.Time: "time", atom.Tr: "row", atom.U: "generic", atom.Ul: "list",
+}
+
+// implicitRole returns the implicit role of the given element, or an empty string.
+func implicitRole(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.A, atom.Area:
+		if _, ok := htmlAttribute(node, "href"); ok {
+			return "link"
+		}
+
+		if node.DataAtom == atom.A {
+			return "generic"
+		}
+
+		return ""
+	case atom.Img:
+		if alt, ok := htmlAttribute(node, "alt"); ok && alt == "" && !hasLabelAttribute(node) {
+			return "presentation"
+		}
+
+		return "img"
+	case atom.Input:
+		return inputRole(node)
+	case atom.Select:
+		size, err := strconv.Atoi(trimmedAttribute(node, "size"))
+		if _, ok := htmlAttribute(node, "multiple"); ok || (err == nil && size > 1) {
+			return "listbox"
+		}
+
+		return "combobox"
+	case atom.Header, atom.Footer:
+		// The header and footer are landmarks only if they are not scoped to a section.
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			switch parent.DataAtom {
+			case atom.Article, atom.Aside, atom.Main, atom.Nav, atom.Section:
+				return "generic"
+			}
+		}
+
+		if node.DataAtom == atom.Header {
+			return "banner"
+		}
+
+		return "contentinfo"
+	case atom.Section:
+		if hasLabelAttribute(node) {
+			return "region"
+		}
+
+		return "generic"
+	case atom.Th:
+		if strings.EqualFold(trimmedAttribute(node, "scope"), "row") {
+			return "rowheader"
+		}
+
+		return "columnheader"
+	}
+
+	return implicitRoles[node.DataAtom]
+}
+
+// inputRole returns the implicit role of an <input> by its type. The text inputs
+// with a list attribute are combo boxes.
+func inputRole(node *html.Node) string {
+	_, list := htmlAttribute(node, "list")
+
+	switch (&FormControl{node: NewNode(node)}).Type() {
+	case "button", "image", "reset", "submit":
+		return "button"
+	case "checkbox":
+		return "checkbox"
+	case "radio":
+		return "radio"
+	case "range":
+		return "slider"
+	case "number":
+		return "spinbutton"
+	case "search":
+		if list {
+			return "combobox"
+		}
+
+		return "searchbox"
+	case "email", "tel", "text", "url":
+		if list {
+			return "combobox"
+		}
+
+		return "textbox"
+	default:
+		return ""
+	}
+}
+
+// hasLabelAttribute checks whether the element has a non-empty aria-label,
+// aria-labelledby or title attribute.
+func hasLabelAttribute(node *html.Node) bool {
+	return trimmedAttribute(node, "aria-label") != "" || trimmedAttribute(node, "aria-labelledby") != "" ||
+		trimmedAttribute(node, "title") != ""
+}
@@ -0,0 +1,116 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestRoleFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`
+		<header><nav><a href="/">Home</a><a name="top">Top</a></nav></header>
+		<main>
+			<h1>Checkout</h1>
+			<button>Save</button>
+			<input type="submit" value="Pay">
+			<div role="button" aria-label="Save">icon</div>
+			<div role="unknown switch">toggle</div>
+			<article><header>Post</header></article>
+		</main>`,
+	))
+	require.NoError(t, err)
+
+	roles := flattenhtml.NewRoleFlattener()
+
+	mc, err := nm.Parse(roles)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.RoleFlattener{})
+	require.NoError(t, err)
+
+	buttons := cursor.SelectNodes("button")
+	require.Equal(t, 3, buttons.Len())
+	require.Equal(t, 2, buttons.Filter(flattenhtml.WithAccessibleName("Save")).Len())
+	require.Equal(t, "input", buttons.Filter(flattenhtml.WithAccessibleName("Pay")).First().TagName())
+	require.Equal(t, 3, cursor.SelectNodes("BUTTON").Len())
+
+	require.Equal(t, 1, cursor.SelectNodes("banner").Len())
+	require.Equal(t, 1, cursor.SelectNodes("link").Len())
+	require.Equal(t, 1, cursor.SelectNodes("switch").Len())
+	require.Equal(t, "Checkout", cursor.SelectNodes("heading").First().AccessibleName())
+	require.Equal(t, 0, cursor.SelectNodes("unknown").Len())
+
+	keys := slices.Collect(cursor.Keys())
+	require.True(t, slices.IsSorted(keys))
+	require.Contains(t, keys, "main")
+	require.Contains(t, keys, "navigation")
+	require.NotContains(t, keys, "")
+
+	// An element is added once, even if it is registered again.
+	require.NoError(t, mc.RegisterNewNode(buttons.First()))
+	require.Equal(t, 3, cursor.SelectNodes("button").Len())
+
+	fresh, ok := roles.NewFlattener().(*flattenhtml.RoleFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(roles))
+	require.False(t, fresh.IsMyType(flattenhtml.NewTagFlattener()))
+}
+
+func TestNode_Role(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		html     string
+		expected string
+	}{
+		{html: `<a href="/">x</a>`, expected: "link"},
+		{html: `<a>x</a>`, expected: "generic"},
+		{html: `<img src="a.png" alt="Logo">`, expected: "img"},
+		{html: `<img src="a.png" alt="">`, expected: "presentation"},
+		{html: `<input>`, expected: "textbox"},
+		{html: `<input type="email" list="suggestions">`, expected: "combobox"},
+		{html: `<input type="checkbox">`, expected: "checkbox"},
+		{html: `<input type="range">`, expected: "slider"},
+		{html: `<input type="reset">`, expected: "button"},
+		{html: `<input type="password">`, expected: ""},
+		{html: `<select><option>a</option></select>`, expected: "combobox"},
+		{html: `<select multiple><option>a</option></select>`, expected: "listbox"},
+		{html: `<section>x</section>`, expected: "generic"},
+		{html: `<section aria-label="Intro">x</section>`, expected: "region"},
+		{html: `<footer>x</footer>`, expected: "contentinfo"},
+		{html: `<table><tr><th scope="row">x</th></tr></table>`, expected: "table"},
+		{html: `<h3>x</h3>`, expected: "heading"},
+		{html: `<ul><li>x</li></ul>`, expected: "list"},
+		{html: `<span role="Tab">x</span>`, expected: "tab"},
+		{html: `<span role="bogus">x</span>`, expected: "generic"},
+		{html: `<h2 role="presentation">x</h2>`, expected: "presentation"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.html, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.html))
+			require.NoError(t, err)
+
+			mc, err := nm.Parse(flattenhtml.NewPathFlattener())
+			require.NoError(t, err)
+
+			body := mc.First().SelectNodes("/html[1]/body[1]").First()
+			require.NotNil(t, body)
+
+			target := flattenhtml.NewNode(body.HTMLNode().FirstChild)
+			require.Equal(t, tc.expected, target.Role())
+			require.Equal(t, "generic", body.Role())
+		})
+	}
+
+	require.Equal(t, "", flattenhtml.NewNode(&html.Node{Type: html.TextNode, Data: "x"}).Role())
+}
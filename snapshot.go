@@ -0,0 +1,328 @@
+package flattenhtml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Serializable is an optional interface for the flatteners whose index can be
+// saved by NodeManager.SaveSnapshot and restored by NewNodeManagerFromSnapshot,
+// without flattening the HTML tree again. The flatteners that do not implement it,
+// e.g., the ones whose index is derived from the structure of the tree such as
+// AncestryFlattener, are rebuilt by flattening the loaded HTML tree.
+type Serializable interface {
+	// Index returns the flattened nodes of the flattener grouped by their keys.
+	Index() map[string]*NodeIterator
+
+	// RestoreIndex replaces the flattened nodes of the flattener with the given
+	// index, which has the same shape as the one returned by Index.
+	RestoreIndex(index map[string]*NodeIterator) error
+}
+
+// snapshotVersion is the version of the snapshot format. Snapshots of other
+// versions are rejected by NewNodeManagerFromSnapshot.
+const snapshotVersion = 1
+
+// ErrInvalidSnapshot is returned by NewNodeManagerFromSnapshot when the snapshot
+// is malformed or does not match the given flatteners.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// snapshot is the persisted form of a NodeManager and the indexes of its flatteners.
+type snapshot struct {
+	Version    int                 `json:"version"`
+	Tree       *snapshotNode       `json:"tree"`
+	Flatteners []snapshotFlattener `json:"flatteners"`
+}
+
+// snapshotNode is the persisted form of a *html.Node and its descendants.
+type snapshotNode struct {
+	Type      html.NodeType   `json:"t"`
+	Data      string          `json:"d,omitempty"`
+	Namespace string          `json:"ns,omitempty"`
+	Attr      []snapshotAttr  `json:"a,omitempty"`
+	Children  []*snapshotNode `json:"c,omitempty"`
+}
+
+// snapshotAttr is the persisted form of a html.Attribute.
+type snapshotAttr struct {
+	Namespace string `json:"ns,omitempty"`
+	Key       string `json:"k"`
+	Val       string `json:"v"`
+}
+
+// snapshotFlattener is the persisted index of a flattener. The nodes are referenced
+// by their child index path from the document node, the same way as Change.Path.
+// The index of a flattener that is not Serializable is not saved, and Rebuild is true.
+// Name is the name of the flattener registered using Named, and Key is the name of
+// a KeyFlattener.
+type snapshotFlattener struct {
+	Type    string             `json:"type"`
+	Name    string             `json:"name,omitempty"`
+	Key     string             `json:"key,omitempty"`
+	Index   map[string][][]int `json:"index,omitempty"`
+	Rebuild bool               `json:"rebuild,omitempty"`
+}
+
+// SaveSnapshot writes the HTML tree and the indexes of the flatteners that were
+// given to Parse to the given writer, in a compact JSON format. The nodes of the
+// indexes are referenced by their stable tree paths, so the snapshot can be loaded
+// using NewNodeManagerFromSnapshot without flattening the HTML tree again.
+// Only the indexes of the flatteners that implement Serializable are saved, and the
+// rest of the flatteners are rebuilt when the snapshot is loaded. The removed nodes
+// are not saved. If the NodeManager is not parsed yet, only the HTML tree is saved.
+func (n *NodeManager) SaveSnapshot(w io.Writer) error {
+	paths := make(map[*html.Node][]int)
+
+	s := snapshot{
+		Version:    snapshotVersion,
+		Tree:       newSnapshotNode(n.root, nil, paths),
+		Flatteners: make([]snapshotFlattener, 0, len(n.flatteners)),
+	}
+
+	for i, flattener := range n.flatteners {
+		saved := snapshotFlattener{Type: flattenerType(flattener), Name: n.names[i], Key: flattenerKey(flattener)}
+
+		serializable, ok := flattener.(Serializable)
+		if !ok {
+			saved.Rebuild = true
+			s.Flatteners = append(s.Flatteners, saved)
+
+			continue
+		}
+
+		index := make(map[string][][]int)
+
+		for key, nodes := range serializable.Index() {
+			keyPaths := make([][]int, 0, len(nodes.nodes))
+
+			for node := range nodes.All() {
+				if path, ok := paths[node.htmlNode]; ok {
+					keyPaths = append(keyPaths, path)
+				}
+			}
+
+			index[key] = keyPaths
+		}
+
+		saved.Index = index
+		s.Flatteners = append(s.Flatteners, saved)
+	}
+
+	return json.NewEncoder(w).Encode(s)
+}
+
+// NewNodeManagerFromSnapshot creates a new NodeManager from a snapshot written by
+// NodeManager.SaveSnapshot. The given flatteners must be new instances of the same
+// types, in the same order, as the flatteners of the saved NodeManager, and they
+// must be registered under the same names using Named. The KeyFlattener values must
+// have the same names, and since their KeyFunc cannot be compared, it is the
+// responsibility of the caller to give the same KeyFunc. The indexes of the Serializable
+// flatteners are restored from the snapshot, the rest of them are rebuilt by
+// flattening the HTML tree, and the returned MultiCursor can be used right away.
+// If no flatteners are given, the returned MultiCursor is nil.
+func NewNodeManagerFromSnapshot(
+	r io.Reader, flatteners []Flattener, opts ...NodeManagerOption,
+) (*NodeManager, *MultiCursor, error) {
+	s := snapshot{}
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	if s.Version != snapshotVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, s.Version)
+	}
+
+	if s.Tree == nil || s.Tree.Type != html.DocumentNode {
+		return nil, nil, fmt.Errorf("%w: missing document node", ErrInvalidSnapshot)
+	}
+
+	nm := NewNodeManager(s.Tree.htmlNode(), opts...)
+
+	if len(flatteners) == 0 {
+		return nm, nil, nil
+	}
+
+	flatteners, names, err := unwrapNamedFlatteners(flatteners)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(flatteners) != len(s.Flatteners) {
+		return nil, nil, fmt.Errorf("%w: %d flatteners are saved, but %d are given",
+			ErrInvalidSnapshot, len(s.Flatteners), len(flatteners))
+	}
+
+	bindTree(nm.tree, flatteners...)
+
+	// wrappers makes sure that the same *html.Node is wrapped by a single Node,
+	// just like a flattener that adds a node to multiple keys.
+	wrappers := make(map[*html.Node]*Node)
+	rebuilt := make([]Flattener, 0)
+
+	for i, flattener := range flatteners {
+		saved := s.Flatteners[i]
+
+		if saved.Type != flattenerType(flattener) {
+			return nil, nil, fmt.Errorf("%w: flatteners[%d] is %s, but %s is saved",
+				ErrInvalidSnapshot, i, flattenerType(flattener), saved.Type)
+		}
+
+		if saved.Name != names[i] {
+			return nil, nil, fmt.Errorf("%w: flatteners[%d] is named %q, but %q is saved",
+				ErrInvalidSnapshot, i, names[i], saved.Name)
+		}
+
+		if key := flattenerKey(flattener); saved.Key != key {
+			return nil, nil, fmt.Errorf("%w: flatteners[%d] has the key %q, but %q is saved",
+				ErrInvalidSnapshot, i, key, saved.Key)
+		}
+
+		serializable, ok := flattener.(Serializable)
+		if !ok || saved.Rebuild {
+			rebuilt = append(rebuilt, flattener)
+
+			continue
+		}
+
+		index := make(map[string]*NodeIterator, len(saved.Index))
+
+		for key, paths := range saved.Index {
+			nodes := NewNodeIterator()
+
+			for _, path := range paths {
+				htmlNode := resolvePath(nm.root, path)
+				if htmlNode == nil {
+					return nil, nil, fmt.Errorf("%w: no node at %s", ErrInvalidSnapshot, formatPath(path))
+				}
+
+				if _, ok := wrappers[htmlNode]; !ok {
+					wrappers[htmlNode] = newTreeNode(htmlNode, nm.tree)
+				}
+
+				nodes.Add(wrappers[htmlNode])
+			}
+
+			index[key] = nodes
+		}
+
+		if err := serializable.RestoreIndex(index); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The flatteners that are not saved are rebuilt together in a single traversal.
+	if len(rebuilt) > 0 {
+		if err := nodeIterator(nm.root, rebuilt...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	nm.flatteners = flatteners
+	nm.names = names
+
+	return nm, &MultiCursor{flatteners: flatteners, names: names, tree: nm.tree}, nil
+}
+
+// newSnapshotNode converts the node and its descendants to snapshotNode, and records
+// the path of each node in the given map.
+func newSnapshotNode(node *html.Node, path []int, paths map[*html.Node][]int) *snapshotNode {
+	paths[node] = path
+
+	s := &snapshotNode{
+		Type:      node.Type,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+	}
+
+	for _, attr := range node.Attr {
+		s.Attr = append(s.Attr, snapshotAttr{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+
+	index := 0
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		s.Children = append(s.Children, newSnapshotNode(child, childPath(path, index), paths))
+		index++
+	}
+
+	return s
+}
+
+// htmlNode converts the snapshotNode and its descendants back to *html.Node.
+func (s *snapshotNode) htmlNode() *html.Node {
+	node := &html.Node{
+		Type:      s.Type,
+		Data:      s.Data,
+		Namespace: s.Namespace,
+	}
+
+	if s.Type == html.ElementNode {
+		node.DataAtom = atom.Lookup([]byte(s.Data))
+	}
+
+	for _, attr := range s.Attr {
+		node.Attr = append(node.Attr, html.Attribute{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+
+	for _, child := range s.Children {
+		node.AppendChild(child.htmlNode())
+	}
+
+	return node
+}
+
+// resolvePath returns the node at the given child index path, or nil if it does not exist.
+func resolvePath(root *html.Node, path []int) *html.Node {
+	node := root
+
+	for _, index := range path {
+		child := node.FirstChild
+
+		for i := 0; i < index && child != nil; i++ {
+			child = child.NextSibling
+		}
+
+		if index < 0 || child == nil {
+			return nil
+		}
+
+		node = child
+	}
+
+	return node
+}
+
+// formatPath returns a human-readable form of a child index path, e.g., /0/1/3.
+func formatPath(path []int) string {
+	segments := make([]string, 0, len(path))
+
+	for _, index := range path {
+		segments = append(segments, strconv.Itoa(index))
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// flattenerType returns the name of the concrete type of the flattener, which is
+// used to verify that a snapshot is restored into the same flatteners.
+func flattenerType(flattener Flattener) string {
+	return fmt.Sprintf("%T", flattener)
+}
+
+// flattenerKey returns the name of the given flattener if it is a KeyFlattener, which
+// tells apart the KeyFlattener values that index the elements by different keys.
+func flattenerKey(flattener Flattener) string {
+	if key, ok := flattener.(*KeyFlattener); ok {
+		return key.Name()
+	}
+
+	return ""
+}
@@ -0,0 +1,222 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeManager_SaveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := `<!DOCTYPE html><html><head><title>t</title></head>` +
+		`<body><div data-id="1"><p>a</p><svg><circle r="1"></circle></svg></div><p data-id="2">b</p><span>c</span></body></html>`
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener(), flattenhtml.NewDataAttributeFlattener())
+	require.NoError(t, err)
+
+	// Removed nodes are not saved.
+	span := mc.First().SelectNodes("span").First()
+	require.NoError(t, span.Remove())
+
+	saved := bytes.Buffer{}
+	require.NoError(t, nm.SaveSnapshot(&saved))
+
+	loadedNM, loadedMC, err := flattenhtml.NewNodeManagerFromSnapshot(
+		bytes.NewReader(saved.Bytes()),
+		[]flattenhtml.Flattener{flattenhtml.NewTagFlattener(), flattenhtml.NewDataAttributeFlattener()},
+	)
+	require.NoError(t, err)
+	require.NotNil(t, loadedMC)
+
+	expected, actual := bytes.Buffer{}, bytes.Buffer{}
+
+	require.NoError(t, nm.Render(&expected))
+	require.NoError(t, loadedNM.Render(&actual))
+	require.Equal(t, expected.String(), actual.String())
+
+	tags, err := loadedMC.SelectCursor(&flattenhtml.TagFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, 2, tags.SelectNodes("p").Len())
+	require.Equal(t, 0, tags.SelectNodes("span").Len())
+	require.Equal(t, "b", tags.SelectNodes("p").Filter(flattenhtml.WithAttribute("data-id")).First().HTMLNode().FirstChild.Data)
+
+	data, err := loadedMC.SelectCursor(&flattenhtml.DataAttributeFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, 2, data.SelectNodes("id").Len())
+	require.Equal(t, "div", data.SelectNodes("id=1").First().TagName())
+
+	// The same element is shared between the flatteners, so mutations are visible to both.
+	require.NoError(t, data.SelectNodes("id=2").First().Remove())
+	require.Equal(t, 1, tags.SelectNodes("p").Len())
+}
+
+func TestNewNodeManagerFromSnapshot_Errors(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>a</p>`))
+	require.NoError(t, err)
+
+	_, err = nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	saved := bytes.Buffer{}
+	require.NoError(t, nm.SaveSnapshot(&saved))
+
+	testCases := []struct {
+		name       string
+		snapshot   string
+		flatteners []flattenhtml.Flattener
+		err        error
+	}{
+		{
+			name:       "malformed snapshot",
+			snapshot:   `{`,
+			flatteners: []flattenhtml.Flattener{flattenhtml.NewTagFlattener()},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:       "unsupported version",
+			snapshot:   `{"version":0}`,
+			flatteners: []flattenhtml.Flattener{flattenhtml.NewTagFlattener()},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:       "different flattener",
+			snapshot:   saved.String(),
+			flatteners: []flattenhtml.Flattener{flattenhtml.NewDataAttributeFlattener()},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:     "different number of flatteners",
+			snapshot: saved.String(),
+			flatteners: []flattenhtml.Flattener{
+				flattenhtml.NewTagFlattener(), flattenhtml.NewDataAttributeFlattener(),
+			},
+			err: flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:       "different rebuilt flattener",
+			snapshot:   saved.String(),
+			flatteners: []flattenhtml.Flattener{&sampleFlattener{}},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:       "different name",
+			snapshot:   saved.String(),
+			flatteners: []flattenhtml.Flattener{flattenhtml.Named("tags", flattenhtml.NewTagFlattener())},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:     "different key flattener",
+			snapshot: `{"version":1,"tree":{"t":2},"flatteners":[{"type":"*flattenhtml.KeyFlattener","key":"href"}]}`,
+			flatteners: []flattenhtml.Flattener{flattenhtml.NewKeyFlattener("id", func(_ *flattenhtml.Node) []string {
+				return nil
+			})},
+			err: flattenhtml.ErrInvalidSnapshot,
+		},
+		{
+			name:       "missing node",
+			snapshot:   `{"version":1,"tree":{"t":2},"flatteners":[{"type":"*flattenhtml.TagFlattener","index":{"p":[[0,1]]}}]}`,
+			flatteners: []flattenhtml.Flattener{flattenhtml.NewTagFlattener()},
+			err:        flattenhtml.ErrInvalidSnapshot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mc, err := flattenhtml.NewNodeManagerFromSnapshot(strings.NewReader(tc.snapshot), tc.flatteners)
+			require.ErrorIs(t, err, tc.err)
+			require.Nil(t, mc)
+		})
+	}
+}
+
+func TestNodeManager_SaveSnapshot_Rebuild(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(
+		`<nav><a href="/">Home</a></nav><main><h1>Rise and fall</h1><img src="a.png">` +
+			`<form id="f"><input name="q" value="x"></form><table><tr><td>1</td></tr></table></main>`,
+	))
+	require.NoError(t, err)
+
+	newFlatteners := func() []flattenhtml.Flattener {
+		return []flattenhtml.Flattener{
+			flattenhtml.NewTagFlattener(),
+			flattenhtml.NewRoleFlattener(),
+			flattenhtml.NewTextIndexFlattener(flattenhtml.WithTextIndexStopWords("and")),
+			flattenhtml.NewKeyFlattener("href", func(node *flattenhtml.Node) []string {
+				href, _ := node.Attribute("href")
+
+				return []string{href}
+			}),
+			flattenhtml.NewAncestryFlattener(),
+			flattenhtml.NewFormFlattener(),
+			flattenhtml.NewTableFlattener(),
+			flattenhtml.NewLinter(flattenhtml.AccessibilityRules()...),
+			&sampleFlattener{},
+		}
+	}
+
+	_, err = nm.Parse(newFlatteners()...)
+	require.NoError(t, err)
+
+	saved := bytes.Buffer{}
+	require.NoError(t, nm.SaveSnapshot(&saved))
+
+	flatteners := newFlatteners()
+
+	_, mc, err := flattenhtml.NewNodeManagerFromSnapshot(&saved, flatteners)
+	require.NoError(t, err)
+
+	// The Serializable flatteners are restored from the snapshot.
+	tags, err := mc.SelectCursor(&flattenhtml.TagFlattener{})
+	require.NoError(t, err)
+
+	nav := tags.SelectNodes("nav").First()
+	require.NotNil(t, nav)
+
+	roles, err := mc.SelectCursor(&flattenhtml.RoleFlattener{})
+	require.NoError(t, err)
+	require.Same(t, nav.HTMLNode(), roles.SelectNodes("navigation").First().HTMLNode())
+	require.Equal(t, 1, roles.SelectNodes("link").Len())
+
+	texts, err := flattenhtml.SelectFlattenerOf[*flattenhtml.TextIndexFlattener](mc, nil)
+	require.NoError(t, err)
+	require.Len(t, texts.Search(`"rise and fall"`), 1)
+	require.Empty(t, texts.Search(`"rise fall"`))
+
+	hrefs, err := mc.SelectKeyCursor("href")
+	require.NoError(t, err)
+	require.Equal(t, "a", hrefs.SelectNodes("/").First().TagName())
+
+	// The rest of the flatteners are rebuilt from the loaded tree.
+	ancestry, err := flattenhtml.SelectFlattenerOf[*flattenhtml.AncestryFlattener](mc, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, tags.SelectNodes("a").Filter(flattenhtml.WithAncestor(ancestry, nav)).Len())
+
+	forms, err := flattenhtml.SelectFlattenerOf[*flattenhtml.FormFlattener](mc, nil)
+	require.NoError(t, err)
+	require.Equal(t, "x", forms.Form("f").Control("q").Value())
+
+	tables, err := flattenhtml.SelectFlattenerOf[*flattenhtml.TableFlattener](mc, nil)
+	require.NoError(t, err)
+	require.Len(t, tables.Tables(), 1)
+
+	linter, err := flattenhtml.SelectFlattenerOf[*flattenhtml.Linter](mc, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, linter.Findings())
+
+	sample, ok := flatteners[len(flatteners)-1].(*sampleFlattener)
+	require.True(t, ok)
+	require.Positive(t, sample.called)
+}
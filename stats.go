@@ -0,0 +1,223 @@
+package flattenhtml
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"runtime"
+	"slices"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// KeyLister is an optional interface for the flatteners that can list their keys.
+// Cursor.Keys, Cursor.Counts and MultiCursor.Stats use it to introspect the index
+// of the flattener. All the built-in flatteners implement it.
+type KeyLister interface {
+	// Keys returns the keys of the flattener in a deterministic order.
+	Keys() iter.Seq[string]
+}
+
+// Stats is a report of the flattened document and the flatteners of a MultiCursor.
+// The fields that describe the HTML tree and the Duration and AllocatedBytes of the
+// flatteners are only collected if the NodeManager is created using WithParseStats.
+type Stats struct {
+	// Nodes is the number of nodes in the HTML tree by their type, i.e., document,
+	// element, text, comment and doctype.
+	Nodes map[string]int
+	// MaxDepth is the depth of the deepest node in the HTML tree. The document
+	// node has a depth of zero.
+	MaxDepth int
+	// Attributes is the number of elements that have each attribute.
+	Attributes map[string]int
+	// Flatteners holds the stats of each flatteners, in the order they were given
+	// to NodeManager.Parse.
+	Flatteners []FlattenerStats
+}
+
+// FlattenerStats is the report of a single flattener of a MultiCursor.
+type FlattenerStats struct {
+	// Name is the name given to Named, or the type of the flattener otherwise.
+	Name string
+	// Keys is the number of keys of the flattener, as reported by Flattener.Len.
+	Keys int
+	// Entries is the total number of nodes over all the keys. It is only available
+	// if the flattener implements KeyLister.
+	Entries int
+	// Duration is the time spent in flattening the HTML tree during Parse.
+	Duration time.Duration
+	// AllocatedBytes is the approximate number of bytes allocated while flattening
+	// the HTML tree during Parse. Since the allocations are measured for the whole
+	// process, it also includes the allocations of other goroutines running at the
+	// same time.
+	AllocatedBytes uint64
+}
+
+// parseStats holds the stats collected during NodeManager.Parse.
+type parseStats struct {
+	nodes      map[string]int
+	maxDepth   int
+	attributes map[string]int
+	durations  []time.Duration
+	allocated  []uint64
+}
+
+// WithParseStats makes NodeManager.Parse collect the stats of the HTML tree and
+// the time and memory spent in each flattener, which are reported by
+// MultiCursor.Stats. To measure each flattener separately, the HTML tree is
+// traversed once per flattener and WithConcurrentFlattening is ignored. Therefore,
+// it is meant for debugging and monitoring rather than for every parse.
+func WithParseStats() NodeManagerOption {
+	return func(config *nodeManagerConfig) {
+		config.stats = true
+	}
+}
+
+// newParseStats creates an empty parseStats for the given number of flatteners.
+func newParseStats(flatteners int) *parseStats {
+	return &parseStats{
+		nodes:      make(map[string]int),
+		attributes: make(map[string]int),
+		durations:  make([]time.Duration, flatteners),
+		allocated:  make([]uint64, flatteners),
+	}
+}
+
+// observe records the node at the given depth.
+func (s *parseStats) observe(node *html.Node, depth int) {
+	s.nodes[nodeTypeName(node.Type)]++
+	s.maxDepth = max(s.maxDepth, depth)
+
+	for _, attr := range node.Attr {
+		s.attributes[attr.Key]++
+	}
+}
+
+// parseWithStats flattens the HTML tree once per flattener, so the time and memory
+// spent in each flattener are measured separately. The stats of the HTML tree are
+// collected during the first traversal.
+func parseWithStats(
+	ctx context.Context, root *html.Node, config nodeManagerConfig, flatteners ...Flattener,
+) (*parseStats, error) {
+	stats := newParseStats(len(flatteners))
+
+	var before, after runtime.MemStats
+
+	for i, flattener := range flatteners {
+		walker := treeWalker{
+			ctx:    ctx,
+			config: config,
+			visit:  flattener.Flatten,
+		}
+
+		if i == 0 {
+			walker.stats = stats
+		}
+
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+
+		if err := walker.walk(root, 0); err != nil {
+			return nil, err
+		}
+
+		stats.durations[i] = time.Since(start)
+
+		runtime.ReadMemStats(&after)
+		stats.allocated[i] = after.TotalAlloc - before.TotalAlloc
+	}
+
+	return stats, nil
+}
+
+// Stats returns a report of the flattened document and the flatteners of the
+// MultiCursor. The report of the HTML tree and the time and memory spent in each
+// flatteners are only available if the NodeManager is created using WithParseStats.
+func (m *MultiCursor) Stats() *Stats {
+	stats := &Stats{
+		Nodes:      make(map[string]int),
+		Attributes: make(map[string]int),
+		Flatteners: make([]FlattenerStats, 0, len(m.flatteners)),
+	}
+
+	if m.stats != nil {
+		maps.Copy(stats.Nodes, m.stats.nodes)
+		maps.Copy(stats.Attributes, m.stats.attributes)
+		stats.MaxDepth = m.stats.maxDepth
+	}
+
+	for i, flattener := range m.flatteners {
+		flattenerStats := FlattenerStats{
+			Name: flattenerType(flattener),
+			Keys: flattener.Len(),
+		}
+
+		if i < len(m.names) && m.names[i] != "" {
+			flattenerStats.Name = m.names[i]
+		}
+
+		cursor := &Cursor{flattener: flattener, multiCursor: m}
+
+		for _, count := range cursor.Counts() {
+			flattenerStats.Entries += count
+		}
+
+		if m.stats != nil {
+			flattenerStats.Duration = m.stats.durations[i]
+			flattenerStats.AllocatedBytes = m.stats.allocated[i]
+		}
+
+		stats.Flatteners = append(stats.Flatteners, flattenerStats)
+	}
+
+	return stats
+}
+
+// Keys returns the keys of the flattener of the Cursor. If the flattener does not
+// implement KeyLister, it returns an empty sequence.
+func (c *Cursor) Keys() iter.Seq[string] {
+	if lister, ok := c.flattener.(KeyLister); ok {
+		return lister.Keys()
+	}
+
+	return func(func(string) bool) {}
+}
+
+// Counts returns the number of nodes of each key of the flattener of the Cursor.
+// The removed nodes are not counted. If the flattener does not implement KeyLister,
+// it returns an empty map.
+func (c *Cursor) Counts() map[string]int {
+	counts := make(map[string]int)
+
+	for key := range c.Keys() {
+		counts[key] = c.SelectNodes(key).Len()
+	}
+
+	return counts
+}
+
+// sortedKeys returns the keys of the given index in the sorted order.
+func sortedKeys(index map[string]*NodeIterator) iter.Seq[string] {
+	return slices.Values(slices.Sorted(maps.Keys(index)))
+}
+
+// nodeTypeName returns a human-readable name of the node type.
+func nodeTypeName(nodeType html.NodeType) string {
+	switch nodeType {
+	case html.DocumentNode:
+		return "document"
+	case html.ElementNode:
+		return "element"
+	case html.TextNode:
+		return "text"
+	case html.CommentNode:
+		return "comment"
+	case html.DoctypeNode:
+		return "doctype"
+	case html.RawNode:
+		return "raw"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,88 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_Keys(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>a</p><p data-id="1">b</p><span>c</span>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener(), &sampleFlattener{defaultKeys: []string{"x"}})
+	require.NoError(t, err)
+
+	tags := mc.First()
+	require.Equal(t, []string{"body", "head", "html", "p", "span"}, slices.Collect(tags.Keys()))
+	require.Equal(t, map[string]int{"body": 1, "head": 1, "html": 1, "p": 2, "span": 1}, tags.Counts())
+
+	require.NoError(t, tags.SelectNodes("p").First().Remove())
+	require.Equal(t, 1, tags.Counts()["p"])
+
+	// Custom flatteners without KeyLister have no keys.
+	custom, err := mc.SelectCursor(&sampleFlattener{})
+	require.NoError(t, err)
+	require.Empty(t, slices.Collect(custom.Keys()))
+	require.Empty(t, custom.Counts())
+}
+
+func TestMultiCursor_Stats(t *testing.T) {
+	t.Parallel()
+
+	sampleHTML := `<!DOCTYPE html><html><head></head><body><!-- c --><div class="a" id="b"><p class="c">text</p></div></body></html>`
+
+	for _, concurrent := range []bool{false, true} {
+		options := []flattenhtml.NodeManagerOption{flattenhtml.WithParseStats()}
+
+		if concurrent {
+			options = append(options, flattenhtml.WithConcurrentFlattening(4))
+		}
+
+		nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(sampleHTML), options...)
+		require.NoError(t, err)
+
+		mc, err := nm.Parse(
+			flattenhtml.NewTagFlattener(),
+			flattenhtml.Named("data", flattenhtml.NewDataAttributeFlattener()),
+		)
+		require.NoError(t, err)
+
+		stats := mc.Stats()
+		require.Equal(t, map[string]int{"document": 1, "doctype": 1, "element": 5, "comment": 1, "text": 1}, stats.Nodes)
+		require.Equal(t, 5, stats.MaxDepth)
+		require.Equal(t, map[string]int{"class": 2, "id": 1}, stats.Attributes)
+		require.Len(t, stats.Flatteners, 2)
+
+		require.Equal(t, "*flattenhtml.TagFlattener", stats.Flatteners[0].Name)
+		require.Equal(t, 5, stats.Flatteners[0].Keys)
+		require.Equal(t, 5, stats.Flatteners[0].Entries)
+		require.Positive(t, stats.Flatteners[0].Duration)
+		require.Positive(t, stats.Flatteners[0].AllocatedBytes)
+
+		require.Equal(t, "data", stats.Flatteners[1].Name)
+		require.Equal(t, 0, stats.Flatteners[1].Entries)
+	}
+}
+
+func TestMultiCursor_Stats_Disabled(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>a</p>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	stats := mc.Stats()
+	require.Empty(t, stats.Nodes)
+	require.Zero(t, stats.MaxDepth)
+	require.Len(t, stats.Flatteners, 1)
+	require.Equal(t, 4, stats.Flatteners[0].Entries)
+	require.Zero(t, stats.Flatteners[0].Duration)
+}
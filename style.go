@@ -0,0 +1,167 @@
+package flattenhtml
+
+import (
+	"strings"
+)
+
+// Style gives access to the declarations of the inline style attribute of a Node.
+// All changes are written back using Node.SetAttribute, so the *html.Node and the
+// attributes of the Node stay in sync.
+type Style struct {
+	node *Node
+}
+
+// styleDeclaration is a single property and value pair of an inline style.
+type styleDeclaration struct {
+	property string
+	value    string
+}
+
+// Style returns the inline Style of the Node.
+func (n *Node) Style() *Style {
+	return &Style{node: n}
+}
+
+// Properties returns the properties of the inline style in their order.
+func (s *Style) Properties() []string {
+	declarations := s.declarations()
+	properties := make([]string, 0, len(declarations))
+
+	for _, declaration := range declarations {
+		properties = append(properties, declaration.property)
+	}
+
+	return properties
+}
+
+// Get returns the value of the given property. Property names are case-insensitive.
+// The second return value is a boolean that indicates whether the property is found.
+// If the property is declared more than once, the last declaration wins.
+func (s *Style) Get(property string) (string, bool) {
+	property = strings.ToLower(strings.TrimSpace(property))
+	value, found := "", false
+
+	for _, declaration := range s.declarations() {
+		if declaration.property == property {
+			value, found = declaration.value, true
+		}
+	}
+
+	return value, found
+}
+
+// Set sets the value of the given property. If the property does not exist, it
+// will be added to the end of the inline style. Otherwise, its value is updated.
+func (s *Style) Set(property, value string) {
+	property = strings.ToLower(strings.TrimSpace(property))
+	declarations := s.declarations()
+	updated := make([]styleDeclaration, 0, len(declarations)+1)
+	found := false
+
+	for _, declaration := range declarations {
+		if declaration.property == property {
+			if found {
+				continue
+			}
+
+			declaration.value = strings.TrimSpace(value)
+			found = true
+		}
+
+		updated = append(updated, declaration)
+	}
+
+	if !found {
+		updated = append(updated, styleDeclaration{property: property, value: strings.TrimSpace(value)})
+	}
+
+	s.write(updated)
+}
+
+// Remove removes the given property from the inline style.
+// If the property does not exist, it will be ignored.
+func (s *Style) Remove(property string) {
+	property = strings.ToLower(strings.TrimSpace(property))
+
+	if _, ok := s.Get(property); !ok {
+		return
+	}
+
+	declarations := s.declarations()
+	updated := make([]styleDeclaration, 0, len(declarations))
+
+	for _, declaration := range declarations {
+		if declaration.property != property {
+			updated = append(updated, declaration)
+		}
+	}
+
+	s.write(updated)
+}
+
+// declarations parses the style attribute of the Node.
+func (s *Style) declarations() []styleDeclaration {
+	style, _ := s.node.Attribute("style")
+
+	return parseStyleDeclarations(style)
+}
+
+// write writes the given declarations back to the style attribute of the Node.
+func (s *Style) write(declarations []styleDeclaration) {
+	parts := make([]string, 0, len(declarations))
+
+	for _, declaration := range declarations {
+		parts = append(parts, declaration.property+": "+declaration.value)
+	}
+
+	style := strings.Join(parts, "; ")
+	if style != "" {
+		style += ";"
+	}
+
+	s.node.SetAttribute("style", style)
+}
+
+// parseStyleDeclarations splits an inline style into its declarations. Semicolons
+// inside parentheses (e.g., data URLs) and quoted strings do not end a declaration.
+// Declarations without a property or a value are dropped.
+func parseStyleDeclarations(style string) []styleDeclaration {
+	declarations := make([]styleDeclaration, 0)
+	depth := 0
+	quote := byte(0)
+	start := 0
+
+	add := func(raw string) {
+		property, value, ok := strings.Cut(raw, ":")
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+
+		if ok && property != "" && value != "" {
+			declarations = append(declarations, styleDeclaration{property: property, value: value})
+		}
+	}
+
+	for i := 0; i < len(style); i++ {
+		switch char := style[i]; {
+		case quote != 0:
+			if char == '\\' {
+				i++
+			} else if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '(':
+			depth++
+		case char == ')' && depth > 0:
+			depth--
+		case char == ';' && depth == 0:
+			add(style[start:i])
+			start = i + 1
+		}
+	}
+
+	add(style[start:])
+
+	return declarations
+}
@@ -0,0 +1,49 @@
+package flattenhtml_test
+
+import (
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestStyle(t *testing.T) {
+	t.Parallel()
+
+	htmlNode := &html.Node{
+		Type: html.ElementNode,
+		Data: "div",
+		Attr: []html.Attribute{{
+			Key: "style",
+			Val: "COLOR: red; background: url(data:image/png;base64,AA==) ;; content: 'a;b'; broken",
+		}},
+	}
+
+	node := flattenhtml.NewNode(htmlNode)
+	style := node.Style()
+
+	require.Equal(t, []string{"color", "background", "content"}, style.Properties())
+
+	val, ok := style.Get("Color")
+	require.True(t, ok)
+	require.Equal(t, "red", val)
+
+	val, ok = style.Get("background")
+	require.True(t, ok)
+	require.Equal(t, "url(data:image/png;base64,AA==)", val)
+
+	_, ok = style.Get("margin")
+	require.False(t, ok)
+
+	style.Set("color", "blue !important")
+	style.Set("margin", "0")
+	style.Remove("background")
+	style.Remove("missing")
+
+	expected := "color: blue !important; content: 'a;b'; margin: 0;"
+
+	val, _ = node.Attribute("style")
+	require.Equal(t, expected, val)
+	require.Equal(t, expected, htmlNode.Attr[0].Val)
+}
@@ -0,0 +1,425 @@
+package flattenhtml
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TableSection is the row group of a table that a row belongs to.
+type TableSection int
+
+const (
+	// TableSectionHead is the <thead> row group.
+	TableSectionHead TableSection = iota
+	// TableSectionBody is the <tbody> row group, or the rows that are not inside any
+	// row group.
+	TableSectionBody
+	// TableSectionFoot is the <tfoot> row group.
+	TableSectionFoot
+)
+
+const (
+	// tableMaxColSpan is the maximum colspan of a cell, the same as browsers.
+	tableMaxColSpan = 1000
+	// tableMaxRowSpan is the maximum rowspan of a cell, the same as browsers.
+	tableMaxRowSpan = 65534
+)
+
+// Table is the grid model of a <table> element, returned by TableFlattener.Tables
+// or NewTable. The rows of the <thead> come first and the rows of the <tfoot> come
+// last, regardless of their position in the HTML, the same as browsers render them.
+// A cell that spans multiple rows or columns takes all the slots it covers, so every
+// row of the grid has the same number of columns. The rows of the nested tables are
+// not a part of the grid.
+type Table struct {
+	node       *Node
+	grid       [][]*TableCell
+	sections   []TableSection
+	headerRows int
+}
+
+// TableCell is a <td> or <th> element of a Table. Row and Column are the position of
+// its top-left slot in the grid.
+type TableCell struct {
+	Node    *Node
+	Text    string
+	Header  bool
+	Row     int
+	Column  int
+	RowSpan int
+	ColSpan int
+}
+
+// tableRowGroup is the rows of a single row group of a table.
+type tableRowGroup struct {
+	section TableSection
+	rows    []*html.Node
+}
+
+// String returns a human-readable name of the TableSection.
+func (s TableSection) String() string {
+	switch s {
+	case TableSectionHead:
+		return "thead"
+	case TableSectionBody:
+		return "tbody"
+	case TableSectionFoot:
+		return "tfoot"
+	default:
+		return "unknown"
+	}
+}
+
+// NewTable builds the Table model of the given <table> element. The header rows of
+// the table are the rows of its <thead>, or if it has no <thead>, the leading rows
+// that only have <th> cells.
+func NewTable(node *Node) *Table {
+	table := &Table{
+		node:     node,
+		grid:     make([][]*TableCell, 0),
+		sections: make([]TableSection, 0),
+	}
+
+	groups := tableRowGroups(node.htmlNode)
+
+	for _, section := range []TableSection{TableSectionHead, TableSectionBody, TableSectionFoot} {
+		for _, group := range groups {
+			if group.section == section {
+				table.addRowGroup(group)
+			}
+		}
+	}
+
+	width := 0
+	for _, row := range table.grid {
+		width = max(width, len(row))
+	}
+
+	for i := range table.grid {
+		for len(table.grid[i]) < width {
+			table.grid[i] = append(table.grid[i], nil)
+		}
+	}
+
+	table.headerRows = table.countHeaderRows()
+
+	return table
+}
+
+// Node returns the <table> element of the Table.
+func (t *Table) Node() *Node {
+	return t.node
+}
+
+// Caption returns the text of the <caption> of the Table.
+func (t *Table) Caption() string {
+	for child := t.node.htmlNode.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Caption {
+			return cellText(child)
+		}
+	}
+
+	return ""
+}
+
+// Size returns the number of rows and columns of the grid.
+func (t *Table) Size() (int, int) {
+	if len(t.grid) == 0 {
+		return 0, 0
+	}
+
+	return len(t.grid), len(t.grid[0])
+}
+
+// Grid returns the cells of the Table by their row and column. The slots that no
+// cell covers are nil.
+func (t *Table) Grid() [][]*TableCell {
+	return t.grid
+}
+
+// Cell returns the cell that covers the given slot of the grid, or nil.
+func (t *Table) Cell(row, column int) *TableCell {
+	if row < 0 || row >= len(t.grid) || column < 0 || column >= len(t.grid[row]) {
+		return nil
+	}
+
+	return t.grid[row][column]
+}
+
+// Section returns the row group of the given row of the grid.
+func (t *Table) Section(row int) TableSection {
+	if row < 0 || row >= len(t.sections) {
+		return TableSectionBody
+	}
+
+	return t.sections[row]
+}
+
+// HeaderRows returns the number of the header rows at the top of the grid.
+func (t *Table) HeaderRows() int {
+	return t.headerRows
+}
+
+// Rows returns the text of the cells of all the rows, including the header rows.
+// The text of a spanning cell is repeated in all the slots it covers, and the empty
+// slots are empty strings.
+func (t *Table) Rows() [][]string {
+	rows := make([][]string, 0, len(t.grid))
+
+	for _, row := range t.grid {
+		texts := make([]string, len(row))
+
+		for i, cell := range row {
+			if cell != nil {
+				texts[i] = cell.Text
+			}
+		}
+
+		rows = append(rows, texts)
+	}
+
+	return rows
+}
+
+// Header returns the label of each column. The label is the text of the header rows
+// in the column joined by a space, e.g., "2024 Q1" for a "2024" cell spanning over a
+// "Q1" cell. Empty labels are replaced with the 1-based number of the column, and
+// repeated labels get a suffix with their occurrence, e.g., "Price_2".
+func (t *Table) Header() []string {
+	_, width := t.Size()
+	labels := make([]string, width)
+	seen := make(map[string]int, width)
+
+	for column := range width {
+		parts := make([]string, 0, t.headerRows)
+
+		var previous *TableCell
+
+		for row := range t.headerRows {
+			cell := t.grid[row][column]
+
+			if cell != nil && cell != previous && cell.Text != "" {
+				parts = append(parts, cell.Text)
+			}
+
+			previous = cell
+		}
+
+		label := strings.Join(parts, " ")
+		if label == "" {
+			label = strconv.Itoa(column + 1)
+		}
+
+		seen[label]++
+
+		if seen[label] > 1 {
+			label += "_" + strconv.Itoa(seen[label])
+		}
+
+		labels[column] = label
+	}
+
+	return labels
+}
+
+// Maps returns the rows after the header rows as maps from the Header labels to the
+// text of the cells.
+func (t *Table) Maps() []map[string]string {
+	header := t.Header()
+	maps := make([]map[string]string, 0, len(t.grid)-t.headerRows)
+
+	for _, row := range t.Rows()[t.headerRows:] {
+		values := make(map[string]string, len(header))
+
+		for i, label := range header {
+			values[label] = row[i]
+		}
+
+		maps = append(maps, values)
+	}
+
+	return maps
+}
+
+// WriteCSV writes all the Rows of the Table, including the header rows, to the given
+// writer as CSV.
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.WriteAll(t.Rows()); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// addRowGroup expands the cells of the given row group into the rows of the grid.
+// A rowspan does not cross the boundary of the row group, and a zero rowspan spans
+// to the end of the row group, the same as browsers.
+func (t *Table) addRowGroup(group tableRowGroup) {
+	start := len(t.grid)
+
+	for range group.rows {
+		t.grid = append(t.grid, make([]*TableCell, 0))
+		t.sections = append(t.sections, group.section)
+	}
+
+	for i, tr := range group.rows {
+		row := start + i
+		column := 0
+
+		for child := tr.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || (child.DataAtom != atom.Td && child.DataAtom != atom.Th) {
+				continue
+			}
+
+			for column < len(t.grid[row]) && t.grid[row][column] != nil {
+				column++
+			}
+
+			rowSpan := spanAttribute(child, "rowspan", 1, tableMaxRowSpan)
+			if rowSpan == 0 || rowSpan > len(group.rows)-i {
+				rowSpan = len(group.rows) - i
+			}
+
+			cell := &TableCell{
+				Node:    newTreeNode(child, t.node.tree),
+				Text:    cellText(child),
+				Header:  child.DataAtom == atom.Th,
+				Row:     row,
+				Column:  column,
+				RowSpan: rowSpan,
+				ColSpan: max(spanAttribute(child, "colspan", 1, tableMaxColSpan), 1),
+			}
+
+			for r := row; r < row+cell.RowSpan; r++ {
+				for c := column; c < column+cell.ColSpan; c++ {
+					for len(t.grid[r]) <= c {
+						t.grid[r] = append(t.grid[r], nil)
+					}
+
+					if t.grid[r][c] == nil {
+						t.grid[r][c] = cell
+					}
+				}
+			}
+
+			column += cell.ColSpan
+		}
+	}
+}
+
+// countHeaderRows returns the number of the <thead> rows, or if there is none, the
+// number of the leading rows that only have <th> cells.
+func (t *Table) countHeaderRows() int {
+	count := 0
+
+	for count < len(t.grid) && t.sections[count] == TableSectionHead {
+		count++
+	}
+
+	if count > 0 {
+		return count
+	}
+
+	for count < len(t.grid) && t.sections[count] == TableSectionBody && headerOnlyRow(t.grid[count]) {
+		count++
+	}
+
+	return count
+}
+
+// headerOnlyRow checks whether all the cells of the given row are <th> cells.
+func headerOnlyRow(cells []*TableCell) bool {
+	found := false
+
+	for _, cell := range cells {
+		if cell == nil {
+			continue
+		}
+
+		if !cell.Header {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
+
+// tableRowGroups returns the row groups of the given <table> in the tree order. The
+// rows that are direct children of the table are grouped as a body.
+func tableRowGroups(table *html.Node) []tableRowGroup {
+	groups := make([]tableRowGroup, 0)
+
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			section := TableSectionBody
+
+			switch child.DataAtom {
+			case atom.Thead:
+				section = TableSectionHead
+			case atom.Tfoot:
+				section = TableSectionFoot
+			}
+
+			group := tableRowGroup{section: section, rows: make([]*html.Node, 0)}
+
+			for row := child.FirstChild; row != nil; row = row.NextSibling {
+				if row.DataAtom == atom.Tr {
+					group.rows = append(group.rows, row)
+				}
+			}
+
+			groups = append(groups, group)
+		case atom.Tr:
+			if len(groups) == 0 || groups[len(groups)-1].section != TableSectionBody {
+				groups = append(groups, tableRowGroup{section: TableSectionBody, rows: make([]*html.Node, 0)})
+			}
+
+			groups[len(groups)-1].rows = append(groups[len(groups)-1].rows, child)
+		}
+	}
+
+	return groups
+}
+
+// spanAttribute parses the rowspan or colspan attribute of a cell. A missing or an
+// invalid value is the given default, and the value is capped to the given maximum.
+func spanAttribute(cell *html.Node, key string, defaultValue, maxValue int) int {
+	value, ok := htmlAttribute(cell, key)
+	if !ok {
+		return defaultValue
+	}
+
+	span, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || span < 0 {
+		return defaultValue
+	}
+
+	return min(span, maxValue)
+}
+
+// cellText returns the text of a cell with the whitespace collapsed. The <br>
+// elements are treated as spaces, and the nested tables are included.
+func cellText(node *html.Node) string {
+	builder := strings.Builder{}
+
+	walkTree(node.FirstChild, func(child *html.Node) {
+		switch {
+		case child.Type == html.TextNode:
+			builder.WriteString(child.Data)
+		case child.DataAtom == atom.Br:
+			builder.WriteByte(' ')
+		}
+	})
+
+	return strings.TrimSpace(collapseSpaces(builder.String()))
+}
@@ -0,0 +1,105 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTable(t *testing.T, rawHTML string) *flattenhtml.Table {
+	t.Helper()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(rawHTML))
+	require.NoError(t, err)
+
+	tables := flattenhtml.NewTableFlattener()
+
+	_, err = nm.Parse(tables)
+	require.NoError(t, err)
+	require.NotEmpty(t, tables.Tables())
+
+	return tables.Tables()[0]
+}
+
+func TestTable_Spans(t *testing.T) {
+	t.Parallel()
+
+	table := parseTable(t, `<table>
+		<caption> Quarterly
+			results </caption>
+		<tfoot><tr><td>Total</td><td colspan="2">30</td></tr></tfoot>
+		<thead>
+			<tr><th rowspan="2">Region</th><th colspan="2">2024</th></tr>
+			<tr><th>Q1</th><th>Q2</th></tr>
+		</thead>
+		<tbody>
+			<tr><th rowspan="2">North</th><td>1</td><td>2</td></tr>
+			<tr><td>3<br>4</td><td>5</td></tr>
+			<tr><td>South</td><td rowspan="0">6</td><td>7</td></tr>
+			<tr><td>West</td><td><table><tr><td>nested</td></tr></table></td></tr>
+		</tbody>
+	</table>`)
+
+	require.Equal(t, "Quarterly results", table.Caption())
+	require.Equal(t, 2, table.HeaderRows())
+
+	rows, columns := table.Size()
+	require.Equal(t, 7, rows)
+	require.Equal(t, 3, columns)
+
+	require.Equal(t, [][]string{
+		{"Region", "2024", "2024"},
+		{"Region", "Q1", "Q2"},
+		{"North", "1", "2"},
+		{"North", "3 4", "5"},
+		{"South", "6", "7"},
+		{"West", "6", "nested"},
+		{"Total", "30", "30"},
+	}, table.Rows())
+
+	require.Equal(t, []string{"Region", "2024 Q1", "2024 Q2"}, table.Header())
+
+	maps := table.Maps()
+	require.Len(t, maps, 5)
+	require.Equal(t, map[string]string{"Region": "North", "2024 Q1": "3 4", "2024 Q2": "5"}, maps[1])
+	require.Equal(t, "30", maps[4]["2024 Q2"])
+
+	six := table.Cell(5, 1)
+	require.NotNil(t, six)
+	require.Equal(t, 4, six.Row)
+	require.Equal(t, 2, six.RowSpan)
+	require.Same(t, six, table.Grid()[4][1])
+	require.True(t, table.Cell(2, 0).Header)
+	require.Nil(t, table.Cell(7, 0))
+
+	require.Equal(t, flattenhtml.TableSectionHead, table.Section(0))
+	require.Equal(t, flattenhtml.TableSectionBody, table.Section(2))
+	require.Equal(t, flattenhtml.TableSectionFoot, table.Section(6))
+	require.Equal(t, "tfoot", table.Section(6).String())
+}
+
+func TestTable_Export(t *testing.T) {
+	t.Parallel()
+
+	table := parseTable(t, `<table>
+		<tr><th>Name</th><th>Name</th><th></th></tr>
+		<tr><td>a, "b"</td><td>c</td></tr>
+	</table>`)
+
+	require.Equal(t, 1, table.HeaderRows())
+	require.Equal(t, []string{"Name", "Name_2", "3"}, table.Header())
+	require.Equal(t, []map[string]string{{"Name": `a, "b"`, "Name_2": "c", "3": ""}}, table.Maps())
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, table.WriteCSV(buffer))
+	require.Equal(t, "Name,Name,\n\"a, \"\"b\"\"\",c,\n", buffer.String())
+
+	empty := flattenhtml.NewTable(table.Cell(0, 0).Node)
+	rows, columns := empty.Size()
+	require.Zero(t, rows)
+	require.Zero(t, columns)
+	require.Empty(t, empty.Maps())
+}
@@ -0,0 +1,137 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TableFlattener is a Flattener that flattens the <table> elements by their id
+// attribute. Tables returns the Table model of each table, which expands the
+// rowspan and colspan of the cells into a grid of rows and columns and exports it
+// to [][]string, to maps keyed by the header or to CSV.
+type TableFlattener struct {
+	treeBinding
+
+	flattened map[string]*NodeIterator
+	tables    []*Node
+	nodes     map[*html.Node]*Node
+	// last is the last flattened node, used to detect whether the nodes are
+	// flattened in the tree order.
+	last *html.Node
+	// sorted is false if a table is flattened out of the tree order, e.g., using
+	// MultiCursor.RegisterNewNode, so the tables are sorted before being used.
+	sorted bool
+	order  treeOrder
+}
+
+var (
+	_ Flattener        = (*TableFlattener)(nil)
+	_ FlattenerFactory = (*TableFlattener)(nil)
+	_ KeyLister        = (*TableFlattener)(nil)
+)
+
+// NewTableFlattener creates a new TableFlattener.
+func NewTableFlattener() *TableFlattener {
+	return &TableFlattener{
+		flattened: make(map[string]*NodeIterator),
+		tables:    make([]*Node, 0),
+		nodes:     make(map[*html.Node]*Node),
+		sorted:    true,
+		order:     make(treeOrder),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It collects the <table> elements and indexes them by their id.
+// This method does not return an error.
+func (t *TableFlattener) Flatten(node *html.Node) error {
+	if t.last != nil && node != nextInTreeOrder(t.last) {
+		t.sorted = false
+	}
+
+	t.last = node
+	t.order.addRoot(node)
+
+	if node.Type != html.ElementNode || node.DataAtom != atom.Table || t.nodes[node] != nil {
+		return nil
+	}
+
+	wrapper := t.newNode(node)
+	t.nodes[node] = wrapper
+	t.tables = append(t.tables, wrapper)
+
+	if id, ok := htmlAttribute(node, "id"); ok && id != "" {
+		if _, ok := t.flattened[id]; !ok {
+			t.flattened[id] = NewNodeIterator()
+		}
+
+		t.flattened[id].Add(wrapper)
+	}
+
+	return nil
+}
+
+// GetNodesByKey returns the <table> elements with the given id.
+func (t *TableFlattener) GetNodesByKey(key string) *NodeIterator {
+	return t.flattened[key]
+}
+
+func (t *TableFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*TableFlattener)
+
+	return ok
+}
+
+// Len for TableFlattener gives you the number of distinct table ids in the HTML tree.
+func (t *TableFlattener) Len() int {
+	return len(t.flattened)
+}
+
+// Keys returns the keys of the TableFlattener in the sorted order.
+func (t *TableFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(t.flattened)
+}
+
+// NewFlattener returns a new and empty TableFlattener.
+func (t *TableFlattener) NewFlattener() Flattener {
+	return NewTableFlattener()
+}
+
+// Tables returns the Table model of the flattened tables in the tree order. The
+// models are built from the current HTML tree and the removed tables are skipped.
+func (t *TableFlattener) Tables() []*Table {
+	if !t.sorted {
+		slices.SortStableFunc(t.tables, t.order.compare)
+
+		t.sorted = true
+	}
+
+	tables := make([]*Table, 0, len(t.tables))
+
+	for _, node := range t.tables {
+		if !node.IsRemoved() {
+			tables = append(tables, NewTable(node))
+		}
+	}
+
+	return tables
+}
+
+// Table returns the Table model of the first table with the given id. If there is
+// no such table, it returns nil.
+func (t *TableFlattener) Table(key string) *Table {
+	nodes := t.flattened[key]
+	if nodes == nil {
+		return nil
+	}
+
+	first := nodes.First()
+	if first == nil {
+		return nil
+	}
+
+	return NewTable(first)
+}
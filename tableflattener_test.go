@@ -0,0 +1,55 @@
+package flattenhtml_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableFlattener(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`
+		<table id="prices"><tr><th>Item</th><th>Price</th></tr><tr><td>Tea</td><td>3</td></tr></table>
+		<table><tr><td>anonymous</td></tr></table>
+		<table id="specs"><tr><td>a</td></tr></table>`,
+	))
+	require.NoError(t, err)
+
+	tables := flattenhtml.NewTableFlattener()
+
+	mc, err := nm.Parse(tables)
+	require.NoError(t, err)
+
+	cursor, err := mc.SelectCursor(&flattenhtml.TableFlattener{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prices", "specs"}, slices.Collect(cursor.Keys()))
+	require.Equal(t, 1, cursor.SelectNodes("prices").Len())
+
+	all := tables.Tables()
+	require.Len(t, all, 3)
+	require.Equal(t, "anonymous", all[1].Cell(0, 0).Text)
+
+	prices := tables.Table("prices")
+	require.NotNil(t, prices)
+	require.Equal(t, []map[string]string{{"Item": "Tea", "Price": "3"}}, prices.Maps())
+	require.Nil(t, tables.Table("missing"))
+
+	// Tables that are registered later keep the tree order.
+	added := all[0].Node().PrependChild(flattenhtml.NodeTypeElement, "table", nil)
+	require.NoError(t, mc.RegisterNewNode(added))
+	require.Same(t, added.HTMLNode(), tables.Tables()[1].Node().HTMLNode())
+
+	require.NoError(t, all[2].Node().Remove())
+	require.Len(t, tables.Tables(), 3)
+	require.Nil(t, tables.Table("specs"))
+
+	fresh, ok := tables.NewFlattener().(*flattenhtml.TableFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, fresh.Len())
+	require.True(t, fresh.IsMyType(tables))
+	require.False(t, fresh.IsMyType(flattenhtml.NewTagFlattener()))
+}
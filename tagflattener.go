@@ -1,6 +1,8 @@
 package flattenhtml
 
 import (
+	"iter"
+
 	"golang.org/x/net/html"
 )
 
@@ -9,16 +11,27 @@ import (
 // NodeIterator by the tag name. Therefore, you can access all nodes with the
 // same tag name (i.e., meta, a, p, etc.) using the GetNodesByKey method or
 // Cursor.SelectNodes method.
+// An element is added once, even if it is flattened again, e.g., when it is a
+// descendant of a new node that is registered using MultiCursor.RegisterNewNode.
 type TagFlattener struct {
+	treeBinding
+
 	flattened map[string]*NodeIterator
+	nodes     map[*html.Node]bool
 }
 
-var _ Flattener = (*TagFlattener)(nil)
+var (
+	_ Flattener        = (*TagFlattener)(nil)
+	_ FlattenerFactory = (*TagFlattener)(nil)
+	_ KeyLister        = (*TagFlattener)(nil)
+	_ Serializable     = (*TagFlattener)(nil)
+)
 
 // NewTagFlattener creates a new TagFlattener.
 func NewTagFlattener() *TagFlattener {
 	return &TagFlattener{
 		flattened: make(map[string]*NodeIterator),
+		nodes:     make(map[*html.Node]bool),
 	}
 }
 
@@ -27,12 +40,14 @@ func NewTagFlattener() *TagFlattener {
 // NodeIterator as NodeManager traverses the HTML tree. This method does not
 // return an error.
 func (t *TagFlattener) Flatten(node *html.Node) error {
-	if node.Type == html.ElementNode {
+	if node.Type == html.ElementNode && !t.nodes[node] {
+		t.nodes[node] = true
+
 		if _, ok := t.flattened[node.Data]; !ok {
 			t.flattened[node.Data] = NewNodeIterator()
 		}
 
-		t.flattened[node.Data].Add(NewNode(node))
+		t.flattened[node.Data].Add(t.newNode(node))
 	}
 
 	return nil
@@ -52,3 +67,33 @@ func (t *TagFlattener) IsMyType(flattener Flattener) bool {
 func (t *TagFlattener) Len() int {
 	return len(t.flattened)
 }
+
+// Keys returns the keys of the TagFlattener in the sorted order.
+func (t *TagFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(t.flattened)
+}
+
+// NewFlattener returns a new and empty TagFlattener.
+func (t *TagFlattener) NewFlattener() Flattener {
+	return NewTagFlattener()
+}
+
+// Index returns the flattened nodes of the TagFlattener grouped by their keys.
+func (t *TagFlattener) Index() map[string]*NodeIterator {
+	return t.flattened
+}
+
+// RestoreIndex replaces the flattened nodes of the TagFlattener with the given index.
+// This method does not return an error.
+func (t *TagFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	t.flattened = index
+	t.nodes = make(map[*html.Node]bool)
+
+	for _, nodes := range index {
+		for _, node := range nodes.nodes {
+			t.nodes[node.htmlNode] = true
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,542 @@
+package flattenhtml
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// TextIndexFlattener is a Flattener that builds an inverted index over the text
+// nodes of the HTML tree. The text is split into terms by the Unicode letters and
+// numbers, lowercased and optionally filtered by stop words and stemmed. Each term
+// is mapped to the nearest enclosing elements of the text nodes that contain it.
+// Therefore, you can access all elements that mention "checkout" using
+// Cursor.SelectNodes("checkout"), and use Search for AND/OR/phrase queries.
+// The text of script, style, template and noscript elements is not indexed.
+type TextIndexFlattener struct {
+	treeBinding
+
+	flattened map[string]*NodeIterator
+	options   textIndexOptions
+	// elements holds the indexed elements in the document order.
+	elements []*Node
+	// elementIndexes maps the indexed elements to their position in elements.
+	elementIndexes map[*html.Node]int
+	// texts holds the tokens of each indexed text node.
+	texts []textIndexEntry
+	// textNodes holds the indexed text nodes, so a text node that is flattened again,
+	// e.g., when its new parent is registered using MultiCursor.RegisterNewNode, is
+	// not indexed twice.
+	textNodes map[*html.Node]bool
+	// postings maps each term to its occurrences in texts.
+	postings map[string][]textOccurrence
+	// indexed holds the terms that are already indexed for each element.
+	indexed map[textElementTerm]bool
+}
+
+// TextIndexOption is a function that configures the TextIndexFlattener.
+type TextIndexOption func(options *textIndexOptions)
+
+// textIndexOptions holds the configurations of the TextIndexFlattener.
+type textIndexOptions struct {
+	stopWords map[string]bool
+	stemmer   func(term string) string
+}
+
+// TextMatch is a single result of TextIndexFlattener.Search.
+type TextMatch struct {
+	// Node is the nearest enclosing element of the matched text.
+	Node *Node
+	// Positions holds the matched parts of the text nodes of the element.
+	Positions []TextPosition
+}
+
+// TextPosition is the position of a matched term or phrase in a text node.
+type TextPosition struct {
+	// Text is the text node that contains the match.
+	Text *Node
+	// Token is the index of the first matched term among the words of the text node,
+	// including the stop words.
+	Token int
+	// Start and End are the byte offsets of the match in the data of the text node.
+	Start int
+	End   int
+}
+
+// textIndexEntry is an indexed text node along with its tokens.
+type textIndexEntry struct {
+	element int
+	text    *Node
+	tokens  []textToken
+}
+
+// textToken is a normalized term along with its byte offsets in the text node.
+// A stop word is kept as a token with the stop flag, so it takes a position in
+// the text node, but it is not indexed.
+type textToken struct {
+	term  string
+	start int
+	end   int
+	stop  bool
+}
+
+// textOccurrence is the position of a term in the indexed text nodes.
+type textOccurrence struct {
+	text  int
+	token int
+}
+
+// textHit is a match of a clause of the query, which spans length terms of a text node.
+type textHit struct {
+	textOccurrence
+	length int
+}
+
+// textElementTerm identifies a term of an indexed element.
+type textElementTerm struct {
+	element int
+	term    string
+}
+
+// textIndexSkippedTags are the elements whose text is never indexed.
+var textIndexSkippedTags = map[string]bool{
+	"script": true, "style": true, "template": true, "noscript": true,
+}
+
+var (
+	_ Flattener        = (*TextIndexFlattener)(nil)
+	_ FlattenerFactory = (*TextIndexFlattener)(nil)
+	_ KeyLister        = (*TextIndexFlattener)(nil)
+	_ Serializable     = (*TextIndexFlattener)(nil)
+)
+
+// WithTextIndexStopWords ignores the given words, both in the text and in the queries.
+// The words are compared case-insensitively.
+func WithTextIndexStopWords(words ...string) TextIndexOption {
+	return func(options *textIndexOptions) {
+		for _, word := range words {
+			options.stopWords[strings.ToLower(word)] = true
+		}
+	}
+}
+
+// WithTextIndexStemmer reduces each lowercased term to its stem using the given
+// function, both in the text and in the queries. StemEnglish can be used for a
+// light stemming of English text.
+func WithTextIndexStemmer(stemmer func(term string) string) TextIndexOption {
+	return func(options *textIndexOptions) {
+		options.stemmer = stemmer
+	}
+}
+
+// NewTextIndexFlattener creates a new TextIndexFlattener.
+func NewTextIndexFlattener(opts ...TextIndexOption) *TextIndexFlattener {
+	options := textIndexOptions{
+		stopWords: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &TextIndexFlattener{
+		flattened:      make(map[string]*NodeIterator),
+		options:        options,
+		elementIndexes: make(map[*html.Node]int),
+		textNodes:      make(map[*html.Node]bool),
+		postings:       make(map[string][]textOccurrence),
+		indexed:        make(map[textElementTerm]bool),
+	}
+}
+
+// Flatten is a callback function called for each node during the
+// NodeManager.Parse. It tokenizes the text nodes and indexes the terms by
+// their nearest enclosing element. This method does not return an error.
+func (t *TextIndexFlattener) Flatten(node *html.Node) error {
+	if node.Type != html.TextNode || node.Parent == nil || node.Parent.Type != html.ElementNode ||
+		textIndexSkippedTags[node.Parent.Data] || t.textNodes[node] {
+		return nil
+	}
+
+	tokens := t.options.tokenize(node.Data)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	element, ok := t.elementIndexes[node.Parent]
+	if !ok {
+		element = len(t.elements)
+		t.elementIndexes[node.Parent] = element
+		t.elements = append(t.elements, t.newNode(node.Parent))
+	}
+
+	t.textNodes[node] = true
+
+	text := len(t.texts)
+	t.texts = append(t.texts, textIndexEntry{element: element, text: t.newNode(node), tokens: tokens})
+
+	for index, token := range tokens {
+		if token.stop {
+			continue
+		}
+
+		if _, ok := t.flattened[token.term]; !ok {
+			t.flattened[token.term] = NewNodeIterator()
+		}
+
+		// An element is added once per term, even if the term is repeated in it.
+		if key := (textElementTerm{element: element, term: token.term}); !t.indexed[key] {
+			t.indexed[key] = true
+			t.flattened[token.term].Add(t.elements[element])
+		}
+
+		t.postings[token.term] = append(t.postings[token.term], textOccurrence{text: text, token: index})
+	}
+
+	return nil
+}
+
+// GetNodesByKey returns the nearest enclosing elements of the text nodes that
+// contain the given term. The term is normalized the same way as the text, so
+// GetNodesByKey("Checkout") returns the elements that mention "checkout".
+func (t *TextIndexFlattener) GetNodesByKey(key string) *NodeIterator {
+	tokens := t.options.tokenize(key)
+	if len(tokens) != 1 || tokens[0].stop {
+		return nil
+	}
+
+	return t.flattened[tokens[0].term]
+}
+
+func (t *TextIndexFlattener) IsMyType(flattener Flattener) bool {
+	_, ok := flattener.(*TextIndexFlattener)
+
+	return ok
+}
+
+// Len for TextIndexFlattener gives you the number of distinct terms in the HTML tree.
+func (t *TextIndexFlattener) Len() int {
+	return len(t.flattened)
+}
+
+// Keys returns the keys of the TextIndexFlattener in the sorted order.
+func (t *TextIndexFlattener) Keys() iter.Seq[string] {
+	return sortedKeys(t.flattened)
+}
+
+// NewFlattener returns a new and empty TextIndexFlattener with the same options.
+func (t *TextIndexFlattener) NewFlattener() Flattener {
+	return NewTextIndexFlattener(func(options *textIndexOptions) {
+		*options = t.options
+	})
+}
+
+// Index returns the indexed elements of the TextIndexFlattener grouped by their terms.
+func (t *TextIndexFlattener) Index() map[string]*NodeIterator {
+	return t.flattened
+}
+
+// RestoreIndex replaces the indexed elements of the TextIndexFlattener with the elements
+// of the given index. Since the positions of the terms are not a part of the index, the
+// text nodes of the elements are tokenized again. This method does not return an error.
+func (t *TextIndexFlattener) RestoreIndex(index map[string]*NodeIterator) error {
+	t.flattened = make(map[string]*NodeIterator)
+	t.elements = make([]*Node, 0)
+	t.elementIndexes = make(map[*html.Node]int)
+	t.texts = nil
+	t.textNodes = make(map[*html.Node]bool)
+	t.postings = make(map[string][]textOccurrence)
+	t.indexed = make(map[textElementTerm]bool)
+
+	for _, nodes := range index {
+		for _, node := range nodes.nodes {
+			if _, ok := t.elementIndexes[node.htmlNode]; !ok {
+				t.elementIndexes[node.htmlNode] = len(t.elements)
+				t.elements = append(t.elements, node)
+			}
+		}
+	}
+
+	// The elements are kept in the document order, the same as after flattening them.
+	slices.SortFunc(t.elements, make(treeOrder).compare)
+
+	for i, element := range t.elements {
+		t.elementIndexes[element.htmlNode] = i
+	}
+
+	for _, element := range t.elements {
+		for child := element.htmlNode.FirstChild; child != nil; child = child.NextSibling {
+			if err := t.Flatten(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Search returns the elements that match the given query, in the document order.
+// The query consists of terms that must all be present in the element (AND), and
+// quoted phrases whose terms must appear next to each other in a text node, e.g.,
+// `"credit card" checkout`. The OR keyword separates alternative groups, e.g.,
+// `checkout OR "credit card"`. Each TextMatch holds the positions of the matched
+// terms and phrases. The removed elements are not returned.
+func (t *TextIndexFlattener) Search(query string) []TextMatch {
+	matches := make(map[int][]textHit)
+
+	for _, group := range t.parseQuery(query) {
+		for element, hits := range t.searchGroup(group) {
+			for _, hit := range hits {
+				if !slices.Contains(matches[element], hit) {
+					matches[element] = append(matches[element], hit)
+				}
+			}
+		}
+	}
+
+	elements := make([]int, 0, len(matches))
+
+	for element := range matches {
+		if !t.elements[element].IsRemoved() {
+			elements = append(elements, element)
+		}
+	}
+
+	slices.Sort(elements)
+
+	result := make([]TextMatch, 0, len(elements))
+
+	for _, element := range elements {
+		hits := matches[element]
+
+		slices.SortFunc(hits, func(a, b textHit) int {
+			if a.text != b.text {
+				return a.text - b.text
+			}
+
+			return a.token - b.token
+		})
+
+		positions := make([]TextPosition, 0, len(hits))
+
+		for _, hit := range hits {
+			entry := t.texts[hit.text]
+
+			positions = append(positions, TextPosition{
+				Text:  entry.text,
+				Token: hit.token,
+				Start: entry.tokens[hit.token].start,
+				End:   entry.tokens[hit.token+hit.length-1].end,
+			})
+		}
+
+		result = append(result, TextMatch{Node: t.elements[element], Positions: positions})
+	}
+
+	return result
+}
+
+// parseQuery splits the query into OR groups, each holding the terms of its clauses.
+// A clause with more than one term is a phrase.
+func (t *TextIndexFlattener) parseQuery(query string) [][][]string {
+	groups := make([][][]string, 0)
+	group := make([][]string, 0)
+
+	for i, part := range strings.Split(query, `"`) {
+		// Odd parts are inside the quotes.
+		if i%2 == 1 {
+			if terms := t.options.phraseTerms(part); len(terms) > 0 {
+				group = append(group, terms)
+			}
+
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if word == "OR" {
+				groups = append(groups, group)
+				group = make([][]string, 0)
+
+				continue
+			}
+
+			for _, term := range t.options.terms(word) {
+				group = append(group, []string{term})
+			}
+		}
+	}
+
+	groups = append(groups, group)
+
+	return slices.DeleteFunc(groups, func(group [][]string) bool {
+		return len(group) == 0
+	})
+}
+
+// searchGroup returns the hits of the elements that match all the clauses of the group.
+func (t *TextIndexFlattener) searchGroup(group [][]string) map[int][]textHit {
+	var result map[int][]textHit
+
+	for _, clause := range group {
+		clauseMatches := t.searchClause(clause)
+
+		if result == nil {
+			result = clauseMatches
+
+			continue
+		}
+
+		for element, hits := range result {
+			if clauseHits, ok := clauseMatches[element]; ok {
+				result[element] = append(hits, clauseHits...)
+			} else {
+				delete(result, element)
+			}
+		}
+	}
+
+	return result
+}
+
+// searchClause returns the hits of the consecutive terms of the clause per element.
+func (t *TextIndexFlattener) searchClause(clause []string) map[int][]textHit {
+	result := make(map[int][]textHit)
+
+	for _, occurrence := range t.postings[clause[0]] {
+		entry := t.texts[occurrence.text]
+
+		if occurrence.token+len(clause) > len(entry.tokens) {
+			continue
+		}
+
+		matched := true
+
+		for i, term := range clause[1:] {
+			if entry.tokens[occurrence.token+i+1].term != term {
+				matched = false
+
+				break
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		result[entry.element] = append(result[entry.element], textHit{
+			textOccurrence: occurrence,
+			length:         len(clause),
+		})
+	}
+
+	return result
+}
+
+// terms returns the normalized terms of the given text, without the stop words.
+func (o textIndexOptions) terms(text string) []string {
+	tokens := o.tokenize(text)
+	terms := make([]string, 0, len(tokens))
+
+	for _, token := range tokens {
+		if !token.stop {
+			terms = append(terms, token.term)
+		}
+	}
+
+	return terms
+}
+
+// phraseTerms returns the normalized terms of the given phrase. The stop words in
+// the middle of the phrase are kept, so they must be present at the same positions
+// in the text, e.g., "rise and fall" does not match "rise fall". The stop words at
+// the start and the end of the phrase are dropped.
+func (o textIndexOptions) phraseTerms(text string) []string {
+	tokens := o.tokenize(text)
+
+	first := slices.IndexFunc(tokens, func(token textToken) bool { return !token.stop })
+	if first < 0 {
+		return nil
+	}
+
+	last := len(tokens) - 1
+	for tokens[last].stop {
+		last--
+	}
+
+	terms := make([]string, 0, last-first+1)
+
+	for _, token := range tokens[first : last+1] {
+		terms = append(terms, token.term)
+	}
+
+	return terms
+}
+
+// tokenize splits the text into the sequences of Unicode letters and numbers, and
+// normalizes them. The stop words are kept as tokens with the stop flag, and they
+// are lowercased but not stemmed.
+func (o textIndexOptions) tokenize(text string) []textToken {
+	tokens := make([]textToken, 0)
+	start := -1
+
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+
+		term := strings.ToLower(text[start:end])
+		stop := o.stopWords[term]
+
+		if !stop && o.stemmer != nil {
+			term = o.stemmer(term)
+		}
+
+		tokens = append(tokens, textToken{term: term, start: start, end: end, stop: stop})
+
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		}
+
+		flush(i)
+	}
+
+	flush(len(text))
+
+	return tokens
+}
+
+// StemEnglish is a light stemmer for English terms that removes the common
+// inflectional suffixes, e.g., "checkouts" and "checking" become "checkout"
+// and "check". It is meant to be used with WithTextIndexStemmer.
+func StemEnglish(term string) string {
+	suffixes := []struct {
+		suffix      string
+		replacement string
+	}{
+		{"ies", "y"}, {"sses", "ss"}, {"ing", ""}, {"edly", ""}, {"ed", ""}, {"ly", ""}, {"es", ""}, {"s", ""},
+	}
+
+	for _, s := range suffixes {
+		stem, ok := strings.CutSuffix(term, s.suffix)
+
+		// Keep at least three characters, so short words such as "bus" or "red" stay as they are.
+		if !ok || len(stem)+len(s.replacement) < 3 || (s.suffix == "s" && strings.HasSuffix(stem, "s")) {
+			continue
+		}
+
+		return stem + s.replacement
+	}
+
+	return term
+}
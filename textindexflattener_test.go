@@ -0,0 +1,173 @@
+package flattenhtml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+const textIndexSampleHTML = `<html><head><title>Shop</title><script>var checkout = 1;</script></head><body>
+<h1>Checkout</h1>
+<p id="first">Pay with a credit card at checkout. Checkout is fast.</p>
+<p id="second">Your card and your credit are <b>safe</b>.</p>
+<p id="third">Paying with PayPal is supported.</p>
+</body></html>`
+
+func parseTextIndex(t *testing.T, opts ...flattenhtml.TextIndexOption) *flattenhtml.TextIndexFlattener {
+	t.Helper()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(textIndexSampleHTML))
+	require.NoError(t, err)
+
+	flattener := flattenhtml.NewTextIndexFlattener(opts...)
+
+	_, err = nm.Parse(flattener)
+	require.NoError(t, err)
+
+	return flattener
+}
+
+func TestTextIndexFlattener_GetNodesByKey(t *testing.T) {
+	t.Parallel()
+
+	flattener := parseTextIndex(t)
+
+	nodes := flattener.GetNodesByKey("Checkout")
+	require.NotNil(t, nodes)
+	require.Equal(t, 2, nodes.Len())
+	require.Equal(t, "h1", nodes.First().TagName())
+
+	require.Equal(t, "b", flattener.GetNodesByKey("safe").First().TagName())
+	require.Nil(t, flattener.GetNodesByKey("var"))
+	require.Nil(t, flattener.GetNodesByKey("credit card"))
+	require.Equal(t, 1, flattener.GetNodesByKey("shop").Len())
+}
+
+func TestTextIndexFlattener_Search(t *testing.T) {
+	t.Parallel()
+
+	flattener := parseTextIndex(t)
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "single term", query: "card", expected: []string{"first", "second"}},
+		{name: "and", query: "credit checkout", expected: []string{"first"}},
+		{name: "phrase", query: `"credit card"`, expected: []string{"first"}},
+		{name: "or", query: `paypal OR "credit card"`, expected: []string{"first", "third"}},
+		{name: "no match", query: "bitcoin", expected: []string{}},
+		{name: "empty query", query: `""`, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ids := make([]string, 0)
+
+			for _, match := range flattener.Search(tc.query) {
+				id, _ := match.Node.Attribute("id")
+				ids = append(ids, id)
+			}
+
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestTextIndexFlattener_Search_Positions(t *testing.T) {
+	t.Parallel()
+
+	flattener := parseTextIndex(t)
+
+	matches := flattener.Search(`checkout "credit card"`)
+	require.Len(t, matches, 1)
+
+	text := matches[0].Positions[0].Text.HTMLNode().Data
+	found := make([]string, 0)
+
+	for _, position := range matches[0].Positions {
+		found = append(found, text[position.Start:position.End])
+	}
+
+	require.Equal(t, []string{"credit card", "checkout", "Checkout"}, found)
+	require.Equal(t, 3, matches[0].Positions[0].Token)
+}
+
+func TestTextIndexFlattener_RegisterWrapper(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<div><p>credit card</p></div>`))
+	require.NoError(t, err)
+
+	tags := flattenhtml.NewTagFlattener()
+	flattener := flattenhtml.NewTextIndexFlattener()
+
+	mc, err := nm.Parse(tags, flattener)
+	require.NoError(t, err)
+
+	wrapper, err := tags.GetNodesByKey("p").First().Wrap("section", nil)
+	require.NoError(t, err)
+
+	// The text of the wrapped paragraph is already indexed, so it is not indexed again.
+	require.NoError(t, mc.RegisterNewNode(wrapper))
+
+	matches := flattener.Search(`"credit card"`)
+	require.Len(t, matches, 1)
+	require.Len(t, matches[0].Positions, 1)
+}
+
+func TestTextIndexFlattener_Options(t *testing.T) {
+	t.Parallel()
+
+	flattener := parseTextIndex(t,
+		flattenhtml.WithTextIndexStopWords("A", "with", "and"),
+		flattenhtml.WithTextIndexStemmer(flattenhtml.StemEnglish),
+	)
+
+	require.Nil(t, flattener.GetNodesByKey("with"))
+	require.Equal(t, 2, flattener.GetNodesByKey("pays").Len())
+	require.Empty(t, flattener.Search(`"pay credit"`))
+	require.Empty(t, flattener.Search(`"pay and a credit"`))
+
+	// The stop words take a position in the text, but they do not start or end a match.
+	matches := flattener.Search(`"a pay with a credit card and"`)
+	require.Len(t, matches, 1)
+	require.Equal(t, 0, matches[0].Positions[0].Token)
+
+	text := matches[0].Positions[0].Text.HTMLNode().Data
+	require.Equal(t, "Pay with a credit card", text[matches[0].Positions[0].Start:matches[0].Positions[0].End])
+
+	matches = flattener.Search("checkout")
+	require.Len(t, matches, 2)
+	require.Equal(t, 6, matches[1].Positions[0].Token)
+
+	clone, ok := flattener.NewFlattener().(*flattenhtml.TextIndexFlattener)
+	require.True(t, ok)
+	require.Equal(t, 0, clone.Len())
+	require.Nil(t, clone.GetNodesByKey("with"))
+}
+
+func TestStemEnglish(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"checkouts": "checkout",
+		"checking":  "check",
+		"paid":      "paid",
+		"policies":  "policy",
+		"classes":   "class",
+		"class":     "class",
+		"red":       "red",
+		"quickly":   "quick",
+		"is":        "is",
+	}
+
+	for term, expected := range testCases {
+		require.Equal(t, expected, flattenhtml.StemEnglish(term), term)
+	}
+}
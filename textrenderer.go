@@ -0,0 +1,415 @@
+package flattenhtml
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// TextRenderer renders the HTML tree as readable plain text. Unlike the raw
+// text content of the nodes, it respects the layout of the document: block
+// elements start on a new line, <br> becomes a newline, whitespace of <pre> is
+// kept, list items get bullets or numbers and table cells are put in columns.
+// Scripts, styles, templates and hidden elements are skipped.
+type TextRenderer struct {
+	width int
+}
+
+// TextRendererOption is a function that configures the TextRenderer.
+type TextRendererOption func(renderer *TextRenderer)
+
+// textWriter holds the state of a single plain text rendering.
+type textWriter struct {
+	width        int
+	out          []byte
+	indent       string
+	lineLen      int
+	pendingBreak int
+	pendingSpace bool
+	listDepth    int
+}
+
+// textParagraphTags are the block elements that are separated from their
+// siblings by an empty line.
+var textParagraphTags = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "pre": true, "blockquote": true, "ul": true, "ol": true,
+	"table": true, "hr": true, "dl": true, "figure": true, "form": true,
+	"fieldset": true,
+}
+
+// textBlockTags are the block elements that start on a new line.
+var textBlockTags = map[string]bool{
+	"html": true, "body": true, "div": true, "section": true, "article": true,
+	"main": true, "header": true, "footer": true, "nav": true, "aside": true,
+	"address": true, "li": true, "dt": true, "dd": true, "tr": true,
+	"figcaption": true, "details": true, "summary": true, "caption": true,
+	"legend": true, "option": true, "center": true,
+}
+
+// textSkippedTags are the elements whose content is never rendered.
+var textSkippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"noscript": true, "title": true, "meta": true, "link": true,
+	"iframe": true, "object": true, "svg": true, "select": true,
+}
+
+// WithTextWidth wraps the rendered lines at the given width. Preformatted text
+// and tables are never wrapped. A zero or negative width disables wrapping which
+// is also the default.
+func WithTextWidth(width int) TextRendererOption {
+	return func(renderer *TextRenderer) {
+		renderer.width = width
+	}
+}
+
+// NewTextRenderer creates a new TextRenderer with the given options.
+func NewTextRenderer(opts ...TextRendererOption) *TextRenderer {
+	renderer := &TextRenderer{}
+
+	for _, opt := range opts {
+		opt(renderer)
+	}
+
+	return renderer
+}
+
+// Render renders the HTML tree of the given NodeManager as plain text to the given writer.
+func (r *TextRenderer) Render(nm *NodeManager, w io.Writer) error {
+	return r.render(nm.root, w)
+}
+
+// RenderNode renders the given Node and its descendants as plain text to the given writer.
+func (r *TextRenderer) RenderNode(node *Node, w io.Writer) error {
+	return r.render(node.htmlNode, w)
+}
+
+// render renders the given node as plain text to the given writer.
+func (r *TextRenderer) render(node *html.Node, w io.Writer) error {
+	writer := &textWriter{width: r.width}
+	writer.node(node)
+
+	output := writer.String()
+	if output != "" {
+		output += "\n"
+	}
+
+	_, err := io.WriteString(w, output)
+
+	return err
+}
+
+// String returns the rendered text without the leading and trailing empty lines.
+func (t *textWriter) String() string {
+	return strings.Trim(string(t.out), "\n")
+}
+
+// node renders a single node and its descendants.
+func (t *textWriter) node(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		t.text(node.Data)
+
+		return
+	case html.DocumentNode:
+		t.children(node)
+
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if textSkippedTags[node.Data] || isHiddenElement(node) {
+		return
+	}
+
+	switch node.Data {
+	case "br":
+		t.newline()
+	case "pre":
+		t.block(2)
+		t.preformatted(textContent(node))
+		t.block(2)
+	case "hr":
+		t.block(2)
+		t.raw(strings.Repeat("-", max(t.width-len(t.indent), 3)))
+		t.block(2)
+	case "ul", "ol":
+		// Nested lists stay tight with their parent item.
+		breaks := 2
+
+		if t.listDepth > 0 {
+			breaks = 1
+		}
+
+		t.block(breaks)
+		t.list(node)
+		t.block(breaks)
+	case "table":
+		t.block(2)
+		t.table(node)
+		t.block(2)
+	case "blockquote":
+		t.block(2)
+
+		indent := t.indent
+		t.indent += "  "
+		t.children(node)
+		t.indent = indent
+
+		t.block(2)
+	case "td", "th":
+		t.children(node)
+		t.space()
+	default:
+		breaks := 0
+
+		if textParagraphTags[node.Data] {
+			breaks = 2
+		} else if textBlockTags[node.Data] {
+			breaks = 1
+		}
+
+		t.block(breaks)
+		t.children(node)
+		t.block(breaks)
+	}
+}
+
+// children renders all children of the given node.
+func (t *textWriter) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		t.node(child)
+	}
+}
+
+// list renders the <li> children of a <ul> or <ol> element. The lines of each
+// item are indented to the width of its bullet.
+func (t *textWriter) list(node *html.Node) {
+	ordered := node.Data == "ol"
+	number := 1
+
+	if start, ok := htmlAttribute(node, "start"); ok && ordered {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(start)); err == nil {
+			number = parsed
+		}
+	}
+
+	t.listDepth++
+	defer func() { t.listDepth-- }()
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" || isHiddenElement(child) {
+			continue
+		}
+
+		bullet := "* "
+
+		if ordered {
+			bullet = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		t.block(1)
+		t.raw(bullet)
+
+		indent := t.indent
+		t.indent += strings.Repeat(" ", len(bullet))
+		t.pendingSpace = false
+		t.children(child)
+		t.indent = indent
+	}
+}
+
+// table renders the rows of a table and aligns the cells in columns. The rows of
+// the nested tables are a part of their cell, so they are rendered inside it.
+func (t *textWriter) table(node *html.Node) {
+	rows := make([][]string, 0)
+	widths := make([]int, 0)
+
+	for _, group := range tableRowGroups(node) {
+		for _, tr := range group.rows {
+			if isHiddenElement(tr) {
+				continue
+			}
+
+			row := make([]string, 0)
+
+			for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+					continue
+				}
+
+				cellWriter := &textWriter{}
+				cellWriter.children(cell)
+
+				text := strings.Join(strings.Fields(cellWriter.String()), " ")
+
+				if len(row) >= len(widths) {
+					widths = append(widths, 0)
+				}
+
+				widths[len(row)] = max(widths[len(row)], utf8.RuneCountInString(text))
+				row = append(row, text)
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	for _, row := range rows {
+		line := strings.Builder{}
+
+		for i, cell := range row {
+			line.WriteString(cell)
+
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+
+		t.block(1)
+		t.raw(line.String())
+	}
+}
+
+// text writes the given text with collapsed whitespace, wrapping the lines if needed.
+func (t *textWriter) text(text string) {
+	if text == "" {
+		return
+	}
+
+	if isHTMLSpace(text[0]) {
+		t.space()
+	}
+
+	for _, word := range strings.Fields(text) {
+		t.word(word)
+		t.pendingSpace = true
+	}
+
+	if !isHTMLSpace(text[len(text)-1]) {
+		t.pendingSpace = false
+	}
+}
+
+// word writes a single word, breaking the line first if it would exceed the width.
+func (t *textWriter) word(word string) {
+	t.flushBreak()
+
+	wordLen := utf8.RuneCountInString(word)
+
+	if t.lineLen > len(t.indent) && t.pendingSpace {
+		if t.width > 0 && t.lineLen+1+wordLen > t.width {
+			t.out = append(t.out, "\n"+t.indent...)
+			t.lineLen = len(t.indent)
+		} else {
+			t.out = append(t.out, ' ')
+			t.lineLen++
+		}
+	}
+
+	t.pendingSpace = false
+
+	t.out = append(t.out, word...)
+	t.lineLen += wordLen
+}
+
+// raw writes the given text as is, without wrapping.
+func (t *textWriter) raw(text string) {
+	t.flushBreak()
+
+	t.out = append(t.out, text...)
+	t.lineLen += utf8.RuneCountInString(text)
+	t.pendingSpace = false
+}
+
+// preformatted writes the given text keeping all whitespace and line breaks.
+func (t *textWriter) preformatted(text string) {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "\n"), "\n")
+
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			t.newline()
+		}
+
+		t.raw(line)
+	}
+}
+
+// space requests a space before the next word.
+func (t *textWriter) space() {
+	t.pendingSpace = true
+}
+
+// newline ends the current line immediately, even if it is empty.
+func (t *textWriter) newline() {
+	t.flushBreak()
+
+	t.out = append(t.out, "\n"+t.indent...)
+	t.lineLen = len(t.indent)
+	t.pendingSpace = false
+}
+
+// block requests the given number of line breaks before the next content.
+// Consecutive requests are merged into the largest one.
+func (t *textWriter) block(breaks int) {
+	t.pendingBreak = max(t.pendingBreak, breaks)
+}
+
+// flushBreak writes the pending line breaks, if any.
+func (t *textWriter) flushBreak() {
+	if t.pendingBreak == 0 {
+		return
+	}
+
+	if len(t.out) > 0 {
+		for len(t.out) > 0 && t.out[len(t.out)-1] == ' ' {
+			t.out = t.out[:len(t.out)-1]
+		}
+
+		existing := 0
+
+		for existing < len(t.out) && t.out[len(t.out)-1-existing] == '\n' {
+			existing++
+		}
+
+		for range max(t.pendingBreak-existing, 0) {
+			t.out = append(t.out, '\n')
+		}
+	}
+
+	t.out = append(t.out, t.indent...)
+	t.lineLen = len(t.indent)
+	t.pendingBreak = 0
+	t.pendingSpace = false
+}
+
+// isHiddenElement checks whether the given element is hidden from the user using
+// the hidden attribute, aria-hidden, the hidden input type or an inline style.
+func isHiddenElement(node *html.Node) bool {
+	if _, ok := htmlAttribute(node, "hidden"); ok {
+		return true
+	}
+
+	if val, ok := htmlAttribute(node, "aria-hidden"); ok && strings.EqualFold(val, "true") {
+		return true
+	}
+
+	if val, ok := htmlAttribute(node, "type"); ok && node.Data == "input" && strings.EqualFold(val, "hidden") {
+		return true
+	}
+
+	style, ok := htmlAttribute(node, "style")
+	if !ok {
+		return false
+	}
+
+	style = strings.ToLower(strings.ReplaceAll(style, " ", ""))
+
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
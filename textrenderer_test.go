@@ -0,0 +1,89 @@
+package flattenhtml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seinshah/flattenhtml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextRenderer_Render(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     string
+		options  []flattenhtml.TextRendererOption
+		expected string
+	}{
+		{
+			name:     "block and inline elements",
+			body:     `<h1>Title</h1><p>Hello <b>big</b>   world</p><div>one</div><div>two<br>three</div>`,
+			expected: "Title\n\nHello big world\n\none\ntwo\nthree\n",
+		},
+		{
+			name:     "preformatted text",
+			body:     "<p>code:</p><pre>  a  b\n    c</pre>",
+			expected: "code:\n\n  a  b\n    c\n",
+		},
+		{
+			name:     "lists",
+			body:     `<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>`,
+			expected: "* one\n* two\n  1. nested\n",
+		},
+		{
+			name:     "table columns",
+			body:     `<table><tr><th>Name</th><th>Qty</th></tr><tr><td>apple</td><td>3</td></tr></table>`,
+			expected: "Name   Qty\napple  3\n",
+		},
+		{
+			name: "nested table",
+			body: `<table><tr><th>Name</th><th>Sizes</th></tr>` +
+				`<tr><td>shirt</td><td><table><tr><td>S</td></tr><tr><td>M</td></tr></table></td></tr></table>`,
+			expected: "Name   Sizes\nshirt  S M\n",
+		},
+		{
+			name: "skipped and hidden elements",
+			body: `<script>var a;</script><style>p{}</style><template><p>t</p></template>` +
+				`<p hidden>hidden</p><p style="display: none">none</p><p aria-hidden="true">aria</p><p>shown</p>`,
+			expected: "shown\n",
+		},
+		{
+			name:     "wrapping lines",
+			body:     `<p>the quick brown fox jumps over the lazy dog</p>`,
+			options:  []flattenhtml.TextRendererOption{flattenhtml.WithTextWidth(15)},
+			expected: "the quick brown\nfox jumps over\nthe lazy dog\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			output := bytes.Buffer{}
+
+			require.NoError(t, flattenhtml.NewTextRenderer(tc.options...).Render(nm, &output))
+			require.Equal(t, tc.expected, output.String())
+		})
+	}
+}
+
+func TestTextRenderer_RenderNode(t *testing.T) {
+	t.Parallel()
+
+	nm, err := flattenhtml.NewNodeManagerFromReader(strings.NewReader(`<p>skip</p><div><p>keep</p></div>`))
+	require.NoError(t, err)
+
+	mc, err := nm.Parse(flattenhtml.NewTagFlattener())
+	require.NoError(t, err)
+
+	output := bytes.Buffer{}
+
+	require.NoError(t, flattenhtml.NewTextRenderer().RenderNode(mc.First().SelectNodes("div").First(), &output))
+	require.Equal(t, "keep\n", output.String())
+}